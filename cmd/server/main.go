@@ -9,8 +9,13 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	mcplog "github.com/apecloud/kb-cloud-mcp-server/pkg/log"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/metrics"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/toolsets"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/tracing"
 	"github.com/mark3labs/mcp-go/server"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -21,6 +26,9 @@ var version = "version"
 var commit = "commit"
 var date = "date"
 
+// configFileUsed records the config file read by initConfig, logged once the logger is set up
+var configFileUsed string
+
 var (
 	rootCmd = &cobra.Command{
 		Use:     "server",
@@ -34,25 +42,50 @@ var (
 		Short: "Start stdio server",
 		Long:  `Start a server that communicates via standard input/output streams using JSON-RPC messages.`,
 		Run: func(_ *cobra.Command, _ []string) {
-			logFile := viper.GetString("log-file")
-			logger, err := initLogger(logFile)
+			logger, err := initLogger()
 			if err != nil {
 				stdlog.Fatal("Failed to initialize logger:", err)
 			}
+			defer func() { _ = logger.Close() }()
 
-			apiKey := viper.GetString("api-key")
-			apiSecret := viper.GetString("api-secret")
-			siteURL := viper.GetString("site-url")
+			if configFileUsed != "" {
+				logger.Component(mcplog.ComponentServer).Infof("Using config file: %s", configFileUsed)
+			}
 
 			cfg := runConfig{
-				logger:    logger,
-				apiKey:    apiKey,
-				apiSecret: apiSecret,
-				siteURL:   siteURL,
+				logger: logger,
+				server: kbcloud.ServerOptions{
+					Version:            version,
+					APIKey:             viper.GetString("api-key"),
+					APISecret:          viper.GetString("api-secret"),
+					Site:               viper.GetString("site-url"),
+					Logger:             logger,
+					Toolsets:           getStringSlice("toolsets"),
+					DisabledTools:      getStringSlice("disable-tools"),
+					ReadOnly:           viper.GetBool("read-only"),
+					DynamicToolsets:    viper.GetBool("dynamic-toolsets"),
+					DefaultOrg:         viper.GetString("default-org"),
+					DefaultEnv:         viper.GetString("default-env"),
+					DefaultFormat:      viper.GetString("default-format"),
+					CacheTTL:           viper.GetDuration("cache-ttl"),
+					CatalogCacheTTL:    catalogCacheTTL(viper.GetDuration("catalog-cache-ttl")),
+					AllowOpenAllowlist: viper.GetBool("allow-open-allowlist"),
+				},
+				metricsAddr: viper.GetString("metrics-addr"),
+				logIO:       viper.GetBool("log-io"),
+				logIOMax:    viper.GetInt("log-io-max-bytes"),
+				tracing: tracing.Config{
+					Exporter:       viper.GetString("trace-exporter"),
+					Endpoint:       viper.GetString("otlp-endpoint"),
+					Insecure:       viper.GetBool("otlp-insecure"),
+					ServiceVersion: version,
+				},
 			}
 
 			if err := runStdioServer(cfg); err != nil {
-				stdlog.Fatal("failed to run stdio server:", err)
+				logger.Component(mcplog.ComponentServer).WithError(err).Error("Failed to run stdio server")
+				_ = logger.Close()
+				os.Exit(1)
 			}
 		},
 	}
@@ -65,17 +98,62 @@ func init() {
 	rootCmd.PersistentFlags().String("config", "", "config file (default is $HOME/.kb-cloud-mcp-server.yaml)")
 	rootCmd.PersistentFlags().String("log-file", "", "Path to log file")
 	rootCmd.PersistentFlags().String("log-level", "info", "Log level (debug, info, warn, error)")
+	rootCmd.PersistentFlags().String("log-format", mcplog.FormatJSON, "Log format (text, json, logfmt)")
+	rootCmd.PersistentFlags().String("log-levels", "", "Per-component log levels, e.g. kbcloud=debug,http=debug (components: server, stdio, kbcloud, http)")
+	rootCmd.PersistentFlags().Int("log-max-size", 100, "Maximum size in megabytes of the log file before it is rotated")
+	rootCmd.PersistentFlags().Int("log-max-backups", 5, "Maximum number of rotated log files to keep")
+	rootCmd.PersistentFlags().Int("log-max-age", 28, "Maximum number of days to keep rotated log files")
+	rootCmd.PersistentFlags().Bool("log-compress", false, "Compress rotated log files")
 	rootCmd.PersistentFlags().String("api-key", "", "KB Cloud API key name")
 	rootCmd.PersistentFlags().String("api-secret", "", "KB Cloud API key secret")
 	rootCmd.PersistentFlags().String("site-url", "", "KB Cloud site URL")
+	rootCmd.PersistentFlags().StringSlice("toolsets", []string{toolsets.All},
+		fmt.Sprintf("Comma-separated toolsets to enable (%s, or %s)", strings.Join(kbcloud.ToolsetNames(), ", "), toolsets.All))
+	rootCmd.PersistentFlags().StringSlice("disable-tools", nil, "Comma-separated tool names to never register")
+	rootCmd.PersistentFlags().String("default-org", "", "Organization used when a tool call omits org_name")
+	rootCmd.PersistentFlags().String("default-env", "", "Environment within --default-org used when a tool call omits env_name")
+	rootCmd.PersistentFlags().String("default-format", "json", "Output format used when a tool call omits format (json, yaml, markdown, csv)")
+	rootCmd.PersistentFlags().Bool("dynamic-toolsets", false, "Start with only discover_toolsets and enable_toolset and let the agent enable toolsets on demand")
+	rootCmd.PersistentFlags().Bool("read-only", false, "Only register tools that do not modify KB Cloud resources")
+	rootCmd.PersistentFlags().Duration("cache-ttl", 0, "Reuse successful KB Cloud GET responses for this long, e.g. 30s (disabled if 0)")
+	rootCmd.PersistentFlags().Duration("catalog-cache-ttl", kbcloud.DefaultCatalogCacheTTL, "Reuse engine, version and instance class lookups for this long (disabled if 0)")
+	rootCmd.PersistentFlags().Bool("allow-open-allowlist", false, "Allow add_allowlist_entries to add entries allowing every address, 0.0.0.0/0 and ::/0")
+	rootCmd.PersistentFlags().Bool("log-io", false, "Log JSON-RPC frames exchanged over stdio (credentials are redacted)")
+	rootCmd.PersistentFlags().Int("log-io-max-bytes", mcplog.DefaultMaxPayloadSize, "Maximum payload bytes logged per frame with --log-io (0 logs metadata only)")
+	rootCmd.PersistentFlags().String("metrics-addr", "", "Address to expose Prometheus metrics on, e.g. :9090 (disabled if empty)")
+	rootCmd.PersistentFlags().String("trace-exporter", "none", "Trace exporter (otlp, stdout, none); stdout writes to stderr")
+	rootCmd.PersistentFlags().String("otlp-endpoint", "", "OTLP/HTTP collector endpoint, e.g. localhost:4318 (defaults to OTEL_EXPORTER_OTLP_ENDPOINT)")
+	rootCmd.PersistentFlags().Bool("otlp-insecure", false, "Disable TLS for the OTLP exporter")
 
 	// Bind to viper
 	_ = viper.BindPFlag("config", rootCmd.PersistentFlags().Lookup("config"))
 	_ = viper.BindPFlag("log-file", rootCmd.PersistentFlags().Lookup("log-file"))
 	_ = viper.BindPFlag("log-level", rootCmd.PersistentFlags().Lookup("log-level"))
+	_ = viper.BindPFlag("log-format", rootCmd.PersistentFlags().Lookup("log-format"))
+	_ = viper.BindPFlag("log-levels", rootCmd.PersistentFlags().Lookup("log-levels"))
+	_ = viper.BindPFlag("log-max-size", rootCmd.PersistentFlags().Lookup("log-max-size"))
+	_ = viper.BindPFlag("log-max-backups", rootCmd.PersistentFlags().Lookup("log-max-backups"))
+	_ = viper.BindPFlag("log-max-age", rootCmd.PersistentFlags().Lookup("log-max-age"))
+	_ = viper.BindPFlag("log-compress", rootCmd.PersistentFlags().Lookup("log-compress"))
 	_ = viper.BindPFlag("api-key", rootCmd.PersistentFlags().Lookup("api-key"))
 	_ = viper.BindPFlag("api-secret", rootCmd.PersistentFlags().Lookup("api-secret"))
 	_ = viper.BindPFlag("site-url", rootCmd.PersistentFlags().Lookup("site-url"))
+	_ = viper.BindPFlag("toolsets", rootCmd.PersistentFlags().Lookup("toolsets"))
+	_ = viper.BindPFlag("disable-tools", rootCmd.PersistentFlags().Lookup("disable-tools"))
+	_ = viper.BindPFlag("default-org", rootCmd.PersistentFlags().Lookup("default-org"))
+	_ = viper.BindPFlag("default-env", rootCmd.PersistentFlags().Lookup("default-env"))
+	_ = viper.BindPFlag("default-format", rootCmd.PersistentFlags().Lookup("default-format"))
+	_ = viper.BindPFlag("dynamic-toolsets", rootCmd.PersistentFlags().Lookup("dynamic-toolsets"))
+	_ = viper.BindPFlag("read-only", rootCmd.PersistentFlags().Lookup("read-only"))
+	_ = viper.BindPFlag("cache-ttl", rootCmd.PersistentFlags().Lookup("cache-ttl"))
+	_ = viper.BindPFlag("catalog-cache-ttl", rootCmd.PersistentFlags().Lookup("catalog-cache-ttl"))
+	_ = viper.BindPFlag("allow-open-allowlist", rootCmd.PersistentFlags().Lookup("allow-open-allowlist"))
+	_ = viper.BindPFlag("log-io", rootCmd.PersistentFlags().Lookup("log-io"))
+	_ = viper.BindPFlag("log-io-max-bytes", rootCmd.PersistentFlags().Lookup("log-io-max-bytes"))
+	_ = viper.BindPFlag("metrics-addr", rootCmd.PersistentFlags().Lookup("metrics-addr"))
+	_ = viper.BindPFlag("trace-exporter", rootCmd.PersistentFlags().Lookup("trace-exporter"))
+	_ = viper.BindPFlag("otlp-endpoint", rootCmd.PersistentFlags().Lookup("otlp-endpoint"))
+	_ = viper.BindPFlag("otlp-insecure", rootCmd.PersistentFlags().Lookup("otlp-insecure"))
 
 	// Add subcommands
 	rootCmd.AddCommand(stdioCmd)
@@ -105,7 +183,7 @@ func initConfig() {
 
 	// Read config if present
 	if err := viper.ReadInConfig(); err == nil {
-		log.Infof("Using config file: %s", viper.ConfigFileUsed())
+		configFileUsed = viper.ConfigFileUsed()
 	}
 
 	// Override with environment variables
@@ -120,37 +198,67 @@ func initConfig() {
 	}
 }
 
-func initLogger(outPath string) (*log.Logger, error) {
-	logger := log.New()
-
-	// Set log level
-	level, err := log.ParseLevel(viper.GetString("log-level"))
+func initLogger() (*mcplog.Logger, error) {
+	componentLevels, err := mcplog.ParseComponentLevels(viper.GetString("log-levels"))
 	if err != nil {
-		level = log.InfoLevel
+		return nil, err
 	}
-	logger.SetLevel(level)
 
-	if outPath == "" {
-		logger.SetFormatter(&log.JSONFormatter{})
-		return logger, nil
-	}
-
-	file, err := os.OpenFile(outPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	logger, err := mcplog.New(mcplog.Config{
+		Level:           viper.GetString("log-level"),
+		Format:          viper.GetString("log-format"),
+		ComponentLevels: componentLevels,
+		File:            viper.GetString("log-file"),
+		MaxSizeMB:       viper.GetInt("log-max-size"),
+		MaxBackups:      viper.GetInt("log-max-backups"),
+		MaxAgeDays:      viper.GetInt("log-max-age"),
+		Compress:        viper.GetBool("log-compress"),
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to open log file: %w", err)
+		return nil, err
 	}
 
-	logger.SetOutput(file)
-	logger.SetFormatter(&log.JSONFormatter{})
+	// Route the package-level logrus logger and the standard library logger
+	// (used by dependencies) through the configured logger
+	log.SetOutput(logger.Out)
+	log.SetFormatter(logger.Formatter)
+	log.SetLevel(logger.GetLevel())
+	stdlog.SetFlags(0)
+	stdlog.SetOutput(logger.Component(mcplog.ComponentServer).WriterLevel(log.WarnLevel))
 
 	return logger, nil
 }
 
 type runConfig struct {
-	logger    *log.Logger
-	apiKey    string
-	apiSecret string
-	siteURL   string
+	logger      *mcplog.Logger
+	server      kbcloud.ServerOptions
+	metricsAddr string
+	logIO       bool
+	logIOMax    int
+	tracing     tracing.Config
+}
+
+// getStringSlice reads a list setting, accepting comma-separated values from
+// environment variables and config files as well as repeated flags
+func getStringSlice(key string) []string {
+	var values []string
+	for _, v := range viper.GetStringSlice(key) {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+	}
+	return values
+}
+
+// catalogCacheTTL maps --catalog-cache-ttl to ServerOptions.CatalogCacheTTL,
+// where zero means the default rather than disabled
+func catalogCacheTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return -1
+	}
+	return ttl
 }
 
 func runStdioServer(cfg runConfig) error {
@@ -158,36 +266,56 @@ func runStdioServer(cfg runConfig) error {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	// Set environment variables for KB Cloud client
-	if cfg.apiKey != "" {
-		os.Setenv("KB_CLOUD_API_KEY_NAME", cfg.apiKey)
-	}
-	if cfg.apiSecret != "" {
-		os.Setenv("KB_CLOUD_API_KEY_SECRET", cfg.apiSecret)
-	}
-	if cfg.siteURL != "" {
-		os.Setenv("KB_CLOUD_SITE", cfg.siteURL)
+	return serveStdio(ctx, cfg, os.Stdin, os.Stdout)
+}
+
+// serveStdio runs the MCP server over in and out until ctx is done or in is closed
+func serveStdio(ctx context.Context, cfg runConfig, in io.Reader, out io.Writer) error {
+	logger := cfg.logger.Component(mcplog.ComponentServer)
+
+	// Set up tracing
+	shutdownTracing, err := tracing.Setup(ctx, cfg.tracing)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
 	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.WithError(err).Error("Failed to shut down tracing")
+		}
+	}()
 
 	// Create MCP server
-	s := server.NewMCPServer(
-		"kb-cloud-mcp-server",
-		version,
-		server.WithLogging(),
-	)
+	s, err := kbcloud.NewServer(cfg.server)
+	if err != nil {
+		return fmt.Errorf("failed to create server: %w", err)
+	}
 
-	// Register KB Cloud tools
-	kbcloud.RegisterTools(s)
+	// Expose metrics on a separate admin port since stdio has no HTTP listener
+	if cfg.metricsAddr != "" {
+		go func() {
+			if err := metrics.Serve(ctx, cfg.metricsAddr); err != nil {
+				logger.WithError(err).Error("Metrics server stopped")
+			}
+		}()
+		logger.Infof("Serving metrics on %s/metrics", cfg.metricsAddr)
+	}
 
 	// Create stdio server
 	stdioServer := server.NewStdioServer(s)
-	stdLogger := stdlog.New(cfg.logger.Writer(), "stdioserver", 0)
+	stdioLogger := cfg.logger.Component(mcplog.ComponentStdio)
+	stdLogger := stdlog.New(stdioLogger.WriterLevel(log.ErrorLevel), "stdioserver", 0)
 	stdioServer.SetErrorLogger(stdLogger)
 
 	// Start listening for messages
 	errC := make(chan error, 1)
 	go func() {
-		in, out := io.Reader(os.Stdin), io.Writer(os.Stdout)
+		if cfg.logIO {
+			loggedIO := mcplog.NewIOLogger(in, out, stdioLogger, mcplog.WithMaxPayloadSize(cfg.logIOMax))
+			in, out = loggedIO, loggedIO
+		}
+
+		metrics.ActiveSessions.Inc()
+		defer metrics.ActiveSessions.Dec()
 		errC <- stdioServer.Listen(ctx, in, out)
 	}()
 
@@ -197,7 +325,7 @@ func runStdioServer(cfg runConfig) error {
 	// Wait for shutdown signal
 	select {
 	case <-ctx.Done():
-		cfg.logger.Info("Shutting down server...")
+		logger.Info("Shutting down server...")
 	case err := <-errC:
 		if err != nil {
 			return fmt.Errorf("error running server: %w", err)
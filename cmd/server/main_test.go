@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud/kbcloudtest"
+	mcplog "github.com/apecloud/kb-cloud-mcp-server/pkg/log"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startStdioServer runs the stdio server on pipes against a fake KB Cloud
+// and returns an initialized MCP client connected to it. configure may adjust
+// the server options.
+func startStdioServer(t *testing.T, configure ...func(*kbcloud.ServerOptions)) (*client.Client, *mcp.InitializeResult) {
+	t.Helper()
+
+	kb := kbcloudtest.NewServer(t)
+	opts := kbcloud.ServerOptions{
+		Version:   "test",
+		APIKey:    kbcloudtest.APIKey,
+		APISecret: kbcloudtest.APISecret,
+		Site:      kb.URL,
+	}
+	for _, fn := range configure {
+		fn(&opts)
+	}
+
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- serveStdio(ctx, runConfig{
+			logger: mcplog.Nop(),
+			server: opts,
+		}, serverIn, serverOut)
+	}()
+
+	// client.Start assumes stdio transports were started when the subprocess
+	// was spawned, so the pipe transport is started explicitly
+	stdio := transport.NewIO(clientIn, clientOut, nil)
+	require.NoError(t, stdio.Start(ctx))
+	c := client.NewClient(stdio)
+	require.NoError(t, c.Start(ctx))
+
+	t.Cleanup(func() {
+		_ = c.Close()
+		cancel()
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Error("stdio server did not shut down")
+		}
+		_ = serverOut.Close()
+	})
+
+	request := mcp.InitializeRequest{}
+	request.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	request.Params.ClientInfo = mcp.Implementation{Name: "e2e-test", Version: "1.0.0"}
+	result, err := c.Initialize(ctx, request)
+	require.NoError(t, err)
+
+	return c, result
+}
+
+// callTool calls a tool over the protocol
+func callTool(t *testing.T, c *client.Client, name string, args map[string]any) (*mcp.CallToolResult, error) {
+	t.Helper()
+	request := mcp.CallToolRequest{}
+	request.Params.Name = name
+	request.Params.Arguments = args
+	return c.CallTool(context.Background(), request)
+}
+
+func TestStdioServer(t *testing.T) {
+	c, initResult := startStdioServer(t)
+
+	t.Run("Initialize", func(t *testing.T) {
+		assert.Equal(t, "kb-cloud-mcp-server", initResult.ServerInfo.Name)
+		assert.Equal(t, mcp.LATEST_PROTOCOL_VERSION, initResult.ProtocolVersion)
+		assert.NotNil(t, initResult.Capabilities.Tools)
+	})
+
+	t.Run("List tools", func(t *testing.T) {
+		result, err := c.ListTools(context.Background(), mcp.ListToolsRequest{})
+		require.NoError(t, err)
+
+		// Required arguments of every tool, which clients rely on to build calls
+		want := map[string][]string{
+			"list_organizations":  nil,
+			"get_organization":    nil,
+			"list_environments":   nil,
+			"get_environment":     nil,
+			"list_instances":      nil,
+			"get_instance":        nil,
+			"find_instance":       nil,
+			"get_connection_info": nil,
+			"list_backups":        nil,
+			"get_backup":          {"backup_id"},
+			"list_toolsets":       nil,
+			"set_context":         nil,
+			"get_context":         nil,
+
+			"list_accounts":            nil,
+			"create_account":           {"account_name"},
+			"reset_account_password":   {"account_name"},
+			"grant_account_privileges": {"account_name", "preset"},
+			"delete_account":           {"account_name"},
+
+			"list_databases":  nil,
+			"create_database": {"database_name"},
+			"drop_database":   {"database_name"},
+
+			"list_instance_parameters":     nil,
+			"diff_parameters_from_default": nil,
+			"update_instance_parameters":   {"parameters"},
+			"list_parameter_templates":     nil,
+			"get_parameter_template":       {"template_name"},
+			"create_parameter_template":    {"template_name"},
+			"apply_parameter_template":     {"instances", "template_name"},
+
+			"list_engines":          nil,
+			"list_engine_versions":  {"engine"},
+			"list_instance_classes": {"engine"},
+			"list_storage_classes":  nil,
+
+			"enable_public_endpoint":   nil,
+			"disable_public_endpoint":  nil,
+			"list_allowlist":           nil,
+			"add_allowlist_entries":    {"entries"},
+			"remove_allowlist_entries": {"entries"},
+
+			"get_instance_metrics": nil,
+			"search_instance_logs": {"log_type"},
+			"top_slow_queries":     nil,
+
+			"list_instance_events":    nil,
+			"get_instance_components": nil,
+		}
+
+		got := map[string][]string{}
+		for _, tool := range result.Tools {
+			assert.NotEmpty(t, tool.Description, tool.Name)
+			assert.Equal(t, "object", tool.InputSchema.Type, tool.Name)
+			for _, name := range tool.InputSchema.Required {
+				assert.Contains(t, tool.InputSchema.Properties, name, "%s requires undeclared argument %s", tool.Name, name)
+			}
+
+			// Tools returning KB Cloud data describe it
+			if tool.Name != "list_toolsets" {
+				assert.Equal(t, "object", tool.OutputSchema.Type, "%s has no output schema", tool.Name)
+			}
+
+			required := append([]string(nil), tool.InputSchema.Required...)
+			sort.Strings(required)
+			got[tool.Name] = required
+		}
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("Call tools", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			tool    string
+			args    map[string]any
+			wantErr bool
+			// wantToolErr expects an error result rather than a protocol error
+			wantToolErr bool
+			contains    string
+		}{
+			{name: "list organizations", tool: "list_organizations", contains: `"name":"globex"`},
+			{name: "get instance", tool: "get_instance", args: map[string]any{"org_name": "acme", "env_name": "prod", "instance_name": "cache"}, contains: `"engine":"redis"`},
+			{name: "paginated backups", tool: "list_backups", args: map[string]any{"org_name": "acme", "env_name": "prod", "instance_name": "orders-db", "page": 1, "perPage": 1}, contains: `"orders-db-backup-1"`},
+			{name: "instance ref", tool: "get_instance", args: map[string]any{"instance_ref": "inventory"}, contains: `"engine":"mongodb"`},
+			{name: "misspelled instance ref", tool: "get_instance", args: map[string]any{"instance_ref": "acme/prod/orders"}, wantToolErr: true, contains: "did you mean acme/prod/orders-db?"},
+			{name: "missing argument", tool: "get_environment", args: map[string]any{"org_name": "acme"}, wantToolErr: true, contains: "env_name"},
+			{name: "create account", tool: "create_account", args: map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "reporting", "preset": "readonly", "databases": []any{"orders"}}, contains: `"password_generated":true`},
+			{name: "unconfirmed drop", tool: "drop_database", args: map[string]any{"instance_ref": "acme/prod/orders-db", "database_name": "orders"}, wantToolErr: true, contains: "cannot be undone"},
+			{name: "update parameters", tool: "update_instance_parameters", args: map[string]any{"instance_ref": "acme/prod/orders-db", "parameters": map[string]any{"max_connections": 300}}, contains: `"to":"300"`},
+			{name: "parameter template", tool: "get_parameter_template", args: map[string]any{"org_name": "acme", "template_name": "mysql-oltp"}, contains: `"family":"mysql8.0"`},
+			{name: "engine versions", tool: "list_engine_versions", args: map[string]any{"org_name": "acme", "env_name": "prod", "engine": "mysql"}, contains: `"default_minor":"8.0.33"`},
+			{name: "connection info", tool: "get_connection_info", args: map[string]any{"instance_ref": "acme/prod/cache"}, contains: `"client":"redis-cli"`},
+			{name: "unconfirmed endpoint removal", tool: "disable_public_endpoint", args: map[string]any{"instance_ref": "acme/prod/orders-db"}, wantToolErr: true, contains: "confirm set to \"orders-db\""},
+			{name: "open allowlist", tool: "add_allowlist_entries", args: map[string]any{"instance_ref": "acme/prod/orders-db", "entries": []any{"0.0.0.0/0"}}, wantToolErr: true, contains: "--allow-open-allowlist"},
+			{name: "instance metrics", tool: "get_instance_metrics", args: map[string]any{"instance_ref": "acme/prod/orders-db", "metrics": []any{"cpu"}}, contains: `"max":1.9`},
+			{name: "slow queries", tool: "top_slow_queries", args: map[string]any{"instance_ref": "acme/prod/orders-db", "start": "2024-01-15T08:00:00Z", "end": "2024-01-15T09:00:00Z"}, contains: `"count":3`},
+			{name: "instance components", tool: "get_instance_components", args: map[string]any{"instance_ref": "acme/prod/cache"}, contains: `"restarts":7`},
+			{name: "KB Cloud error", tool: "get_organization", args: map[string]any{"name": "initech"}, wantErr: true},
+			{name: "unknown tool", tool: "drop_everything", wantErr: true},
+		}
+
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				result, err := callTool(t, c, tc.tool, tc.args)
+				if tc.wantErr {
+					assert.Error(t, err)
+					return
+				}
+				require.NoError(t, err)
+				require.Len(t, result.Content, 1)
+
+				text, ok := result.Content[0].(mcp.TextContent)
+				require.True(t, ok, "expected text content, got %T", result.Content[0])
+				assert.Equal(t, tc.wantToolErr, result.IsError, text.Text)
+				assert.Contains(t, text.Text, tc.contains)
+				if !tc.wantToolErr {
+					assert.True(t, json.Valid([]byte(text.Text)), "tool output is not JSON: %s", text.Text)
+					assert.NotNil(t, result.StructuredContent)
+				}
+			})
+		}
+	})
+}
+
+func TestStdioServerDynamicToolsets(t *testing.T) {
+	c, initResult := startStdioServer(t, func(opts *kbcloud.ServerOptions) {
+		opts.DynamicToolsets = true
+	})
+	require.NotNil(t, initResult.Capabilities.Tools)
+	assert.True(t, initResult.Capabilities.Tools.ListChanged)
+
+	listChanged := make(chan struct{}, 1)
+	c.OnNotification(func(notification mcp.JSONRPCNotification) {
+		if notification.Method == mcp.MethodNotificationToolsListChanged {
+			select {
+			case listChanged <- struct{}{}:
+			default:
+			}
+		}
+	})
+
+	listTools := func(t *testing.T) []string {
+		t.Helper()
+		result, err := c.ListTools(context.Background(), mcp.ListToolsRequest{})
+		require.NoError(t, err)
+		var names []string
+		for _, tool := range result.Tools {
+			names = append(names, tool.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	assert.Equal(t, []string{"discover_toolsets", "enable_toolset"}, listTools(t))
+
+	result, err := callTool(t, c, "enable_toolset", map[string]any{"toolset": kbcloud.ToolsetInstances})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	select {
+	case <-listChanged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no tools/list_changed notification after enabling a toolset")
+	}
+	assert.Equal(t, []string{"discover_toolsets", "enable_toolset", "find_instance", "get_connection_info", "get_instance", "list_instances"}, listTools(t))
+
+	result, err = callTool(t, c, "list_instances", map[string]any{"org_name": "acme", "env_name": "prod"})
+	require.NoError(t, err)
+	assert.False(t, result.IsError)
+}
+
+func TestToolsetsFlagUsage(t *testing.T) {
+	usage := rootCmd.PersistentFlags().Lookup("toolsets").Usage
+	for _, name := range []string{kbcloud.ToolsetInstances, kbcloud.ToolsetNetwork, kbcloud.ToolsetMonitoring, kbcloud.ToolsetContext} {
+		assert.Contains(t, usage, name)
+	}
+	assert.Contains(t, usage, "or all)")
+}
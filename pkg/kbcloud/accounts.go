@@ -0,0 +1,678 @@
+package kbcloud
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// passwordNotice is returned with every password a tool reveals
+const passwordNotice = "This password is shown once and is not stored or logged by the MCP server. Save it now."
+
+// Generated passwords use letters and digits only, so they need no quoting
+// in connection strings or shells
+const (
+	generatedPasswordLength = 24
+	passwordAlphabet        = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	minPasswordLength       = 8
+)
+
+// accountNamePattern matches account names valid on every supported engine
+var accountNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,31}$`)
+
+// privilegePreset is a named set of privileges on an engine and the KB Cloud
+// privilege type it maps to
+type privilegePreset struct {
+	Name      string                `json:"preset"`
+	Privilege kbcloud.PrivilegeType `json:"privilege"`
+	// Grants describes what the engine grants, for agents and humans
+	Grants string `json:"grants"`
+}
+
+// privilegePresets are the presets of each engine family, in the order they
+// are documented. Redis ACLs apply to the whole instance; the other engines
+// grant privileges per database.
+var privilegePresets = map[string][]privilegePreset{
+	engineMySQL: {
+		{Name: "readonly", Privilege: kbcloud.PrivilegeTypeReadonly, Grants: "SELECT, SHOW VIEW"},
+		{Name: "readwrite", Privilege: kbcloud.PrivilegeTypeReadwrite, Grants: "SELECT, INSERT, UPDATE, DELETE, CREATE, DROP, ALTER, INDEX, CREATE VIEW, SHOW VIEW"},
+		{Name: "ddl", Privilege: kbcloud.PrivilegeTypeDdlonly, Grants: "CREATE, DROP, ALTER, INDEX, CREATE VIEW"},
+		{Name: "dml", Privilege: kbcloud.PrivilegeTypeDmlonly, Grants: "SELECT, INSERT, UPDATE, DELETE"},
+		{Name: "admin", Privilege: kbcloud.PrivilegeTypeDbadmin, Grants: "ALL PRIVILEGES"},
+	},
+	enginePostgreSQL: {
+		{Name: "readonly", Privilege: kbcloud.PrivilegeTypeReadonly, Grants: "CONNECT, USAGE and SELECT on all tables"},
+		{Name: "readwrite", Privilege: kbcloud.PrivilegeTypeReadwrite, Grants: "CONNECT, USAGE, CREATE, and SELECT, INSERT, UPDATE, DELETE, TRUNCATE on all tables"},
+		{Name: "ddl", Privilege: kbcloud.PrivilegeTypeDdlonly, Grants: "CONNECT, USAGE, CREATE"},
+		{Name: "dml", Privilege: kbcloud.PrivilegeTypeDmlonly, Grants: "CONNECT, USAGE, and SELECT, INSERT, UPDATE, DELETE on all tables"},
+		{Name: "admin", Privilege: kbcloud.PrivilegeTypeDbadmin, Grants: "ALL PRIVILEGES"},
+	},
+	engineRedis: {
+		{Name: "readonly", Privilege: kbcloud.PrivilegeTypeReadonly, Grants: "ACL on ~* +@read"},
+		{Name: "readwrite", Privilege: kbcloud.PrivilegeTypeReadwrite, Grants: "ACL on ~* &* +@all -@dangerous"},
+	},
+	engineMongoDB: {
+		{Name: "read", Privilege: kbcloud.PrivilegeTypeReadonly, Grants: "read role"},
+		{Name: "readWrite", Privilege: kbcloud.PrivilegeTypeReadwrite, Grants: "readWrite role"},
+		{Name: "dbAdmin", Privilege: kbcloud.PrivilegeTypeDbadmin, Grants: "dbAdmin role"},
+	},
+}
+
+// presetDescription documents the presets of every engine family
+var presetDescription = "Privilege preset: readonly, readwrite, ddl, dml or admin on MySQL and PostgreSQL; " +
+	"readonly or readwrite (Redis ACL) on Redis; read, readWrite or dbAdmin (roles) on MongoDB"
+
+// accountList is the result of list_accounts
+type accountList struct {
+	Items     []kbcloud.AccountListItem `json:"items"`
+	TotalSize int                       `json:"totalSize"`
+}
+
+// accountGrant is a privilege of an account, described for its engine
+type accountGrant struct {
+	Database  string                `json:"database,omitempty"`
+	Preset    string                `json:"preset,omitempty"`
+	Privilege kbcloud.PrivilegeType `json:"privilege"`
+	Grants    string                `json:"grants,omitempty"`
+}
+
+// accountResult is the result of the tools that change an account. Password
+// is only set when the tool created or reset it.
+type accountResult struct {
+	InstanceRef       string         `json:"instance_ref"`
+	AccountName       string         `json:"account_name"`
+	Role              string         `json:"role,omitempty"`
+	Privileges        []accountGrant `json:"privileges,omitempty"`
+	Password          string         `json:"password,omitempty"`
+	PasswordGenerated bool           `json:"password_generated,omitempty"`
+	Notice            string         `json:"notice,omitempty"`
+	Deleted           bool           `json:"deleted,omitempty"`
+}
+
+// ListAccounts creates a tool to list the database accounts of an instance
+func ListAccounts(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("list_accounts",
+			mcp.WithDescription("List the database accounts of an instance with their roles and privileges. Passwords are never included"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			WithInstanceRef(),
+			WithResponseShaping(),
+			WithOutputSchema[accountList](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			ref, err := InstanceRefParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get response shaping parameters
+			shaping, err := ResponseShapingParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			// Look up the instance engine, which selects the account API
+			ref, instance, err := getInstance(client, ref)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+
+			// Call KB Cloud API
+			accounts, err := listAccounts(client, ref, instance.Engine)
+			if err != nil {
+				return nil, err
+			}
+
+			// Return result
+			return shaping.result(resourceAccount, accountList{Items: accounts, TotalSize: len(accounts)})
+		}
+}
+
+// CreateAccount creates a tool to create a database account on an instance
+func CreateAccount(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("create_account",
+			mcp.WithDescription("Create a database account on an instance, optionally granting a privilege preset. "+
+				"When no password is given one is generated; it is returned once and never logged"),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			WithInstanceRef(),
+			mcp.WithString("account_name",
+				mcp.Required(),
+				mcp.Description("Account name: a letter followed by up to 31 letters, digits or underscores"),
+			),
+			mcp.WithString("role",
+				mcp.Description("Account role (default BASICUSER). SUPERUSER has every privilege and ignores preset"),
+				mcp.Enum(string(kbcloud.AccountRoleTypeBasicuser), string(kbcloud.AccountRoleTypeSuperuser)),
+			),
+			mcp.WithString("preset",
+				mcp.Description(presetDescription),
+			),
+			mcp.WithArray("databases",
+				mcp.Description("Databases the preset applies to; required with preset except on Redis"),
+				mcp.WithStringItems(),
+			),
+			mcp.WithString("password",
+				mcp.Description("Password of at least 8 characters; generated when omitted"),
+			),
+			mcp.WithString("component",
+				mcp.Description("Component to create the account in; defaults to the first one"),
+			),
+			WithOutputSchema[accountResult](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			ref, err := InstanceRefParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			accountName, err := accountNameParam(request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get optional parameters
+			role, err := OptionalParam[string](request, "role")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			switch strings.ToUpper(role) {
+			case "", string(kbcloud.AccountRoleTypeBasicuser):
+				role = string(kbcloud.AccountRoleTypeBasicuser)
+			case string(kbcloud.AccountRoleTypeSuperuser):
+				role = string(kbcloud.AccountRoleTypeSuperuser)
+			default:
+				return mcp.NewToolResultError(fmt.Sprintf("invalid role %q: must be BASICUSER or SUPERUSER", role)), nil
+			}
+			preset, err := OptionalParam[string](request, "preset")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			databases, err := OptionalStringArrayParam(request, "databases")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			component, err := OptionalParam[string](request, "component")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			password, generated, err := passwordParam(request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			// Look up the instance engine, which selects the account API and presets
+			ref, instance, err := getInstance(client, ref)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+
+			var grants []accountGrant
+			if preset != "" {
+				if grants, err = presetGrants(instance.Engine, preset, databases); err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+			} else if len(databases) > 0 {
+				return mcp.NewToolResultError("databases requires preset"), nil
+			}
+
+			// Account names must be unique within the instance
+			accounts, err := listAccounts(client, ref, instance.Engine)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := findAccount(accounts, accountName); ok {
+				return mcp.NewToolResultError(fmt.Sprintf("account %q already exists on %s; use reset_account_password or grant_account_privileges to change it", accountName, ref)), nil
+			}
+
+			// Call KB Cloud API
+			body := kbcloud.NewAccount(accountName, kbcloud.AccountRoleType(role))
+			body.SetPassword(password)
+			if component != "" {
+				body.SetComponent(component)
+			}
+			if len(grants) > 0 {
+				body.SetPrivilegesList(privilegeList(grants))
+			}
+			_, resp, err := client.Account.CreateAccount(client.Context, instance.Engine, ref.Org, ref.Instance, *body)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create account: %w", err)
+			}
+			_ = resp.Body.Close()
+
+			// Return result
+			return marshalAccountResult(accountResult{
+				InstanceRef:       ref.String(),
+				AccountName:       accountName,
+				Role:              role,
+				Privileges:        grants,
+				Password:          password,
+				PasswordGenerated: generated,
+				Notice:            passwordNotice,
+			})
+		}
+}
+
+// ResetAccountPassword creates a tool to set a new password on a database account
+func ResetAccountPassword(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("reset_account_password",
+			mcp.WithDescription("Set a new password on a database account; the old password stops working. "+
+				"When no password is given one is generated; it is returned once and never logged"),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(true),
+			WithInstanceRef(),
+			mcp.WithString("account_name",
+				mcp.Required(),
+				mcp.Description("Account name"),
+			),
+			mcp.WithString("password",
+				mcp.Description("New password of at least 8 characters; generated when omitted"),
+			),
+			WithOutputSchema[accountResult](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			ref, err := InstanceRefParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			accountName, err := RequiredParam[string](request, "account_name")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			password, generated, err := passwordParam(request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			ref, instance, err := getInstance(client, ref)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+			account, err := getAccount(client, ref, instance.Engine, accountName)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+			if account.GetRole() == kbcloud.AccountListRoleTypeRoot {
+				return mcp.NewToolResultError(fmt.Sprintf("account %q is the root account of %s, whose password is managed by KB Cloud", accountName, ref)), nil
+			}
+
+			// Call KB Cloud API
+			body := kbcloud.NewAccount(accountName, kbcloud.AccountRoleType(account.GetRole()))
+			body.SetPassword(password)
+			resp, err := client.Account.UpdateAccount(client.Context, instance.Engine, ref.Org, ref.Instance, accountName, *body)
+			if err != nil {
+				return nil, fmt.Errorf("failed to reset account password: %w", err)
+			}
+			_ = resp.Body.Close()
+
+			// Return result
+			return marshalAccountResult(accountResult{
+				InstanceRef:       ref.String(),
+				AccountName:       accountName,
+				Role:              string(account.GetRole()),
+				Password:          password,
+				PasswordGenerated: generated,
+				Notice:            passwordNotice,
+			})
+		}
+}
+
+// GrantAccountPrivileges creates a tool to grant a privilege preset to a database account
+func GrantAccountPrivileges(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("grant_account_privileges",
+			mcp.WithDescription("Grant a privilege preset to a database account. The preset replaces the account's privileges on the given databases and leaves other databases unchanged"),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			WithInstanceRef(),
+			mcp.WithString("account_name",
+				mcp.Required(),
+				mcp.Description("Account name"),
+			),
+			mcp.WithString("preset",
+				mcp.Required(),
+				mcp.Description(presetDescription),
+			),
+			mcp.WithArray("databases",
+				mcp.Description("Databases the preset applies to; required except on Redis"),
+				mcp.WithStringItems(),
+			),
+			WithOutputSchema[accountResult](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			ref, err := InstanceRefParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			accountName, err := RequiredParam[string](request, "account_name")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			preset, err := RequiredParam[string](request, "preset")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			databases, err := OptionalStringArrayParam(request, "databases")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			ref, instance, err := getInstance(client, ref)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+			grants, err := presetGrants(instance.Engine, preset, databases)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			account, err := getAccount(client, ref, instance.Engine, accountName)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+
+			// Keep the privileges on other databases
+			privileges := mergePrivileges(account.GetPrivilegesList(), privilegeList(grants))
+
+			// Call KB Cloud API
+			resp, err := client.Account.UpdateAccountPrivileges(client.Context, instance.Engine, ref.Org, ref.Instance, accountName, privileges)
+			if err != nil {
+				return nil, fmt.Errorf("failed to grant account privileges: %w", err)
+			}
+			_ = resp.Body.Close()
+
+			// Return result
+			return marshalAccountResult(accountResult{
+				InstanceRef: ref.String(),
+				AccountName: accountName,
+				Role:        string(account.GetRole()),
+				Privileges:  describePrivileges(instance.Engine, privileges),
+			})
+		}
+}
+
+// DeleteAccount creates a tool to delete a database account
+func DeleteAccount(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("delete_account",
+			mcp.WithDescription("Delete a database account from an instance. Applications using it lose access immediately and root accounts cannot be deleted. Confirm must repeat the account name"),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(true),
+			WithInstanceRef(),
+			mcp.WithString("account_name",
+				mcp.Required(),
+				mcp.Description("Account name"),
+			),
+			WithConfirmation("account"),
+			WithOutputSchema[accountResult](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			ref, err := InstanceRefParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			accountName, err := RequiredParam[string](request, "account_name")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if err := ConfirmParam(request, "account", accountName); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			ref, instance, err := getInstance(client, ref)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+			account, err := getAccount(client, ref, instance.Engine, accountName)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+			if account.GetRole() == kbcloud.AccountListRoleTypeRoot {
+				return mcp.NewToolResultError(fmt.Sprintf("account %q is the root account of %s and cannot be deleted", accountName, ref)), nil
+			}
+
+			// Call KB Cloud API
+			resp, err := client.Account.DeleteAccount(client.Context, instance.Engine, ref.Org, ref.Instance, accountName)
+			if err != nil {
+				return nil, fmt.Errorf("failed to delete account: %w", err)
+			}
+			_ = resp.Body.Close()
+
+			// Return result
+			return marshalAccountResult(accountResult{
+				InstanceRef: ref.String(),
+				AccountName: accountName,
+				Deleted:     true,
+			})
+		}
+}
+
+// accountNameParam returns the account_name parameter of a new account
+func accountNameParam(r mcp.CallToolRequest) (string, error) {
+	name, err := RequiredParam[string](r, "account_name")
+	if err != nil {
+		return "", err
+	}
+	if !accountNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid account_name %q: use a letter followed by up to 31 letters, digits or underscores", name)
+	}
+	return name, nil
+}
+
+// passwordParam returns the password parameter, generating a password when
+// it is omitted
+func passwordParam(r mcp.CallToolRequest) (password string, generated bool, err error) {
+	password, err = OptionalParam[string](r, "password")
+	if err != nil {
+		return "", false, err
+	}
+	if password != "" {
+		if len(password) < minPasswordLength {
+			return "", false, fmt.Errorf("password must be at least %d characters", minPasswordLength)
+		}
+		return password, false, nil
+	}
+	password, err = generatePassword()
+	return password, true, err
+}
+
+// generatePassword returns a random password with upper and lower case
+// letters and digits
+func generatePassword() (string, error) {
+	max := big.NewInt(int64(len(passwordAlphabet)))
+	for {
+		b := make([]byte, generatedPasswordLength)
+		for i := range b {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return "", fmt.Errorf("failed to generate password: %w", err)
+			}
+			b[i] = passwordAlphabet[n.Int64()]
+		}
+		password := string(b)
+		if strings.ContainsAny(password, "abcdefghijklmnopqrstuvwxyz") &&
+			strings.ContainsAny(password, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") &&
+			strings.ContainsAny(password, "0123456789") {
+			return password, nil
+		}
+	}
+}
+
+// presetGrants returns the grants of a preset on the given databases of an
+// engine
+func presetGrants(engine, name string, databases []string) ([]accountGrant, error) {
+	family := engineFamily(engine)
+	presets, ok := privilegePresets[family]
+	if !ok {
+		return nil, fmt.Errorf("privilege presets are not available for engine %s", engine)
+	}
+
+	var preset *privilegePreset
+	var names []string
+	for i := range presets {
+		names = append(names, presets[i].Name)
+		if strings.EqualFold(presets[i].Name, name) {
+			preset = &presets[i]
+		}
+	}
+	if preset == nil {
+		return nil, fmt.Errorf("unknown preset %q for %s: must be one of %s", name, engine, strings.Join(names, ", "))
+	}
+
+	// Redis ACLs cover the whole instance
+	if family == engineRedis {
+		if len(databases) > 0 {
+			return nil, fmt.Errorf("redis privileges apply to the whole instance; omit databases")
+		}
+		return []accountGrant{{Preset: preset.Name, Privilege: preset.Privilege, Grants: preset.Grants}}, nil
+	}
+	if len(databases) == 0 {
+		return nil, fmt.Errorf("missing required parameter: databases (the preset is granted per database on %s)", engine)
+	}
+
+	grants := make([]accountGrant, 0, len(databases))
+	for _, database := range databases {
+		grants = append(grants, accountGrant{Database: database, Preset: preset.Name, Privilege: preset.Privilege, Grants: preset.Grants})
+	}
+	return grants, nil
+}
+
+// privilegeList converts grants to KB Cloud privileges
+func privilegeList(grants []accountGrant) []kbcloud.PrivilegeListItem {
+	privileges := make([]kbcloud.PrivilegeListItem, 0, len(grants))
+	for _, grant := range grants {
+		privilege := kbcloud.NewPrivilegeListItem(grant.Privilege)
+		if grant.Database != "" {
+			privilege.SetDatabaseName(grant.Database)
+		}
+		privileges = append(privileges, *privilege)
+	}
+	return privileges
+}
+
+// mergePrivileges replaces the privileges on the databases of granted,
+// keeping the others, ordered by database
+func mergePrivileges(existing, granted []kbcloud.PrivilegeListItem) []kbcloud.PrivilegeListItem {
+	byDatabase := map[string]kbcloud.PrivilegeListItem{}
+	for _, privilege := range existing {
+		byDatabase[privilege.GetDatabaseName()] = privilege
+	}
+	for _, privilege := range granted {
+		byDatabase[privilege.GetDatabaseName()] = privilege
+	}
+
+	merged := make([]kbcloud.PrivilegeListItem, 0, len(byDatabase))
+	for _, database := range sortedKeys(byDatabase) {
+		merged = append(merged, byDatabase[database])
+	}
+	return merged
+}
+
+// describePrivileges describes KB Cloud privileges with the presets of engine
+func describePrivileges(engine string, privileges []kbcloud.PrivilegeListItem) []accountGrant {
+	grants := make([]accountGrant, 0, len(privileges))
+	for _, privilege := range privileges {
+		grant := accountGrant{Database: privilege.GetDatabaseName(), Privilege: privilege.Privileges}
+		for _, preset := range privilegePresets[engineFamily(engine)] {
+			if preset.Privilege == privilege.Privileges {
+				grant.Preset, grant.Grants = preset.Name, preset.Grants
+				break
+			}
+		}
+		grants = append(grants, grant)
+	}
+	return grants
+}
+
+// listAccounts returns the accounts of an instance without their passwords,
+// sorted by name
+func listAccounts(client *Client, ref InstanceRef, engine string) ([]kbcloud.AccountListItem, error) {
+	accounts, resp, err := client.Account.ListAccounts(client.Context, engine, ref.Org, ref.Instance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounts: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	for i := range accounts {
+		accounts[i].Password = nil
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].Name < accounts[j].Name })
+	return accounts, nil
+}
+
+// findAccount returns the named account
+func findAccount(accounts []kbcloud.AccountListItem, name string) (kbcloud.AccountListItem, bool) {
+	for _, account := range accounts {
+		if account.Name == name {
+			return account, true
+		}
+	}
+	return kbcloud.AccountListItem{}, false
+}
+
+// getAccount returns the named account of an instance, or a lookupError
+// suggesting similar names
+func getAccount(client *Client, ref InstanceRef, engine, name string) (kbcloud.AccountListItem, error) {
+	accounts, err := listAccounts(client, ref, engine)
+	if err != nil {
+		return kbcloud.AccountListItem{}, err
+	}
+	if account, ok := findAccount(accounts, name); ok {
+		return account, nil
+	}
+
+	var names []string
+	for _, account := range accounts {
+		names = append(names, account.Name)
+	}
+	message := fmt.Sprintf("account %q not found on %s", name, ref)
+	if suggestions := suggest(name, names); len(suggestions) > 0 {
+		return kbcloud.AccountListItem{}, &lookupError{fmt.Sprintf("%s; did you mean %s?", message, strings.Join(suggestions, ", "))}
+	}
+	return kbcloud.AccountListItem{}, &lookupError{message + "; use list_accounts to see its accounts"}
+}
+
+// marshalAccountResult returns an account change as a JSON tool result.
+// Results can carry a password, so they are always JSON: log redaction
+// finds the password key there, and would miss it in other formats.
+func marshalAccountResult(result accountResult) (*mcp.CallToolResult, error) {
+	return renderResult(FormatJSON, result, nil)
+}
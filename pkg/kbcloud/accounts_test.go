@@ -0,0 +1,365 @@
+package kbcloud_test
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	client "github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud/kbcloudtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// accountResult is the JSON result of the tools that change an account
+type accountResult struct {
+	InstanceRef string `json:"instance_ref"`
+	AccountName string `json:"account_name"`
+	Role        string `json:"role"`
+	Privileges  []struct {
+		Database  string `json:"database"`
+		Preset    string `json:"preset"`
+		Privilege string `json:"privilege"`
+		Grants    string `json:"grants"`
+	} `json:"privileges"`
+	Password          string `json:"password"`
+	PasswordGenerated bool   `json:"password_generated"`
+	Notice            string `json:"notice"`
+	Deleted           bool   `json:"deleted"`
+}
+
+func TestListAccounts(t *testing.T) {
+	runToolTests(t, kbcloud.ListAccounts, []toolTest{
+		{
+			name: "accounts of an instance",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db"},
+			check: func(t *testing.T, text string) {
+				accounts := decode[struct {
+					Items     []client.AccountListItem `json:"items"`
+					TotalSize int                      `json:"totalSize"`
+				}](t, text)
+				require.Len(t, accounts.Items, 2)
+				assert.Equal(t, 2, accounts.TotalSize)
+				assert.Equal(t, "app_orders", accounts.Items[0].Name)
+				assert.Equal(t, client.PrivilegeTypeReadwrite, accounts.Items[0].PrivilegesList[0].Privileges)
+				assert.Equal(t, "root", accounts.Items[1].Name)
+			},
+		},
+		{
+			name: "passwords are never returned",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db"},
+			check: func(t *testing.T, text string) {
+				assert.NotContains(t, text, "password")
+			},
+		},
+		{
+			name: "summary view",
+			args: map[string]any{"instance_ref": "acme/prod/cache", "view": "summary"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"items":[{"name":"default","role":"ROOT"}],"totalSize":1}`, text)
+			},
+		},
+		{
+			name:        "unknown instance",
+			args:        map[string]any{"instance_ref": "acme/prod/orders"},
+			wantToolErr: "did you mean acme/prod/orders-db?",
+		},
+		{
+			name: "KB Cloud error",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db"},
+			setup: func(s *kbcloudtest.Server) {
+				s.InjectError(http.MethodGet, "/api/v1/data/mysql/organizations/acme/clusters/orders-db/accounts", http.StatusInternalServerError, "boom")
+			},
+			wantErr: "failed to list accounts",
+		},
+	})
+}
+
+func TestCreateAccount(t *testing.T) {
+	runToolTests(t, kbcloud.CreateAccount, []toolTest{
+		{
+			name: "generated password",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "reporting"},
+			check: func(t *testing.T, text string) {
+				result := decode[accountResult](t, text)
+				assert.Equal(t, "acme/prod/orders-db", result.InstanceRef)
+				assert.Equal(t, "BASICUSER", result.Role)
+				assert.True(t, result.PasswordGenerated)
+				assert.Regexp(t, regexp.MustCompile(`^[A-Za-z0-9]{24}$`), result.Password)
+				assert.Contains(t, result.Notice, "shown once")
+			},
+		},
+		{
+			name: "given password",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "reporting", "password": "correct-horse"},
+			check: func(t *testing.T, text string) {
+				result := decode[accountResult](t, text)
+				assert.Equal(t, "correct-horse", result.Password)
+				assert.False(t, result.PasswordGenerated)
+			},
+		},
+		{
+			name: "mysql preset",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "reporting", "preset": "readonly", "databases": []any{"orders", "billing"}},
+			check: func(t *testing.T, text string) {
+				result := decode[accountResult](t, text)
+				require.Len(t, result.Privileges, 2)
+				assert.Equal(t, "orders", result.Privileges[0].Database)
+				assert.Equal(t, "READONLY", result.Privileges[0].Privilege)
+				assert.Equal(t, "SELECT, SHOW VIEW", result.Privileges[0].Grants)
+			},
+		},
+		{
+			name: "postgresql preset",
+			args: map[string]any{"instance_ref": "acme/staging/analytics", "account_name": "etl", "preset": "ddl", "databases": []any{"warehouse"}},
+			check: func(t *testing.T, text string) {
+				result := decode[accountResult](t, text)
+				require.Len(t, result.Privileges, 1)
+				assert.Equal(t, "DDLONLY", result.Privileges[0].Privilege)
+			},
+		},
+		{
+			name: "redis ACL preset",
+			args: map[string]any{"instance_ref": "acme/prod/cache", "account_name": "worker", "preset": "readwrite"},
+			check: func(t *testing.T, text string) {
+				result := decode[accountResult](t, text)
+				require.Len(t, result.Privileges, 1)
+				assert.Empty(t, result.Privileges[0].Database)
+				assert.Contains(t, result.Privileges[0].Grants, "-@dangerous")
+			},
+		},
+		{
+			name: "mongodb role preset",
+			args: map[string]any{"instance_ref": "globex/dev/inventory", "account_name": "app", "preset": "readWrite", "databases": []any{"inventory"}},
+			check: func(t *testing.T, text string) {
+				result := decode[accountResult](t, text)
+				require.Len(t, result.Privileges, 1)
+				assert.Equal(t, "readWrite role", result.Privileges[0].Grants)
+			},
+		},
+		{
+			name: "superuser",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "dba", "role": "superuser"},
+			check: func(t *testing.T, text string) {
+				assert.Equal(t, "SUPERUSER", decode[accountResult](t, text).Role)
+			},
+		},
+		{
+			name:        "unknown preset",
+			args:        map[string]any{"instance_ref": "acme/prod/cache", "account_name": "worker", "preset": "admin"},
+			wantToolErr: "must be one of readonly, readwrite",
+		},
+		{
+			name:        "preset without databases",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "reporting", "preset": "readonly"},
+			wantToolErr: "databases",
+		},
+		{
+			name:        "redis preset with databases",
+			args:        map[string]any{"instance_ref": "acme/prod/cache", "account_name": "worker", "preset": "readonly", "databases": []any{"0"}},
+			wantToolErr: "omit databases",
+		},
+		{
+			name:        "databases without preset",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "reporting", "databases": []any{"orders"}},
+			wantToolErr: "databases requires preset",
+		},
+		{
+			name:        "existing account",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "app_orders"},
+			wantToolErr: `account "app_orders" already exists`,
+		},
+		{
+			name:        "invalid name",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "app-orders"},
+			wantToolErr: "invalid account_name",
+		},
+		{
+			name:        "short password",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "reporting", "password": "secret"},
+			wantToolErr: "at least 8 characters",
+		},
+		{
+			name:        "root role",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "reporting", "role": "root"},
+			wantToolErr: "invalid role",
+		},
+	})
+
+	t.Run("creates the account", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		_, handler := kbcloud.CreateAccount(s.GetClientFn())
+
+		result, err := callTool(handler, map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "reporting", "preset": "dml", "databases": []any{"orders"}})
+		require.NoError(t, err)
+		require.False(t, result.IsError, resultText(t, result))
+
+		account, ok := s.Account("acme", "orders-db", "reporting")
+		require.True(t, ok)
+		assert.Equal(t, client.AccountRoleTypeBasicuser, account.Role)
+		assert.Equal(t, decode[accountResult](t, resultText(t, result)).Password, account.GetPassword())
+		require.Len(t, account.PrivilegesList, 1)
+		assert.Equal(t, "orders", account.PrivilegesList[0].GetDatabaseName())
+		assert.Equal(t, client.PrivilegeTypeDmlonly, account.PrivilegesList[0].Privileges)
+	})
+
+	t.Run("generated passwords differ", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		_, handler := kbcloud.CreateAccount(s.GetClientFn())
+
+		passwords := map[string]bool{}
+		for _, name := range []string{"a1", "a2", "a3"} {
+			result, err := callTool(handler, map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": name})
+			require.NoError(t, err)
+			passwords[decode[accountResult](t, resultText(t, result)).Password] = true
+		}
+		assert.Len(t, passwords, 3)
+	})
+}
+
+func TestResetAccountPassword(t *testing.T) {
+	runToolTests(t, kbcloud.ResetAccountPassword, []toolTest{
+		{
+			name: "generated password",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "app_orders"},
+			check: func(t *testing.T, text string) {
+				result := decode[accountResult](t, text)
+				assert.True(t, result.PasswordGenerated)
+				assert.Len(t, result.Password, 24)
+				assert.Equal(t, "BASICUSER", result.Role)
+			},
+		},
+		{
+			name:        "root account",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "root"},
+			wantToolErr: "managed by KB Cloud",
+		},
+		{
+			name:        "misspelled account",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "app_order"},
+			wantToolErr: `did you mean app_orders?`,
+		},
+		{
+			name:        "short password",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "app_orders", "password": "short"},
+			wantToolErr: "at least 8 characters",
+		},
+	})
+
+	t.Run("sets the password", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		_, handler := kbcloud.ResetAccountPassword(s.GetClientFn())
+
+		result, err := callTool(handler, map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "app_orders", "password": "new-password-1"})
+		require.NoError(t, err)
+		require.False(t, result.IsError, resultText(t, result))
+
+		account, ok := s.Account("acme", "orders-db", "app_orders")
+		require.True(t, ok)
+		assert.Equal(t, "new-password-1", account.GetPassword())
+	})
+}
+
+func TestGrantAccountPrivileges(t *testing.T) {
+	runToolTests(t, kbcloud.GrantAccountPrivileges, []toolTest{
+		{
+			name: "adds a database",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "app_orders", "preset": "readonly", "databases": []any{"billing"}},
+			check: func(t *testing.T, text string) {
+				result := decode[accountResult](t, text)
+				require.Len(t, result.Privileges, 2)
+				assert.Equal(t, "billing", result.Privileges[0].Database)
+				assert.Equal(t, "readonly", result.Privileges[0].Preset)
+				assert.Equal(t, "orders", result.Privileges[1].Database)
+				assert.Equal(t, "readwrite", result.Privileges[1].Preset)
+				assert.Empty(t, result.Password)
+			},
+		},
+		{
+			name: "replaces a database",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "app_orders", "preset": "admin", "databases": []any{"orders"}},
+			check: func(t *testing.T, text string) {
+				result := decode[accountResult](t, text)
+				require.Len(t, result.Privileges, 1)
+				assert.Equal(t, "DBADMIN", result.Privileges[0].Privilege)
+			},
+		},
+		{
+			name:        "missing preset",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "app_orders"},
+			wantToolErr: "preset",
+		},
+		{
+			name:        "unknown account",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "nobody", "preset": "readonly", "databases": []any{"orders"}},
+			wantToolErr: "use list_accounts",
+		},
+		{
+			name: "KB Cloud error",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "app_orders", "preset": "readonly", "databases": []any{"orders"}},
+			setup: func(s *kbcloudtest.Server) {
+				s.InjectError(http.MethodPatch, "/api/v1/data/mysql/organizations/acme/clusters/orders-db/accounts/app_orders/privileges", http.StatusForbidden, "denied")
+			},
+			wantErr: "failed to grant account privileges",
+		},
+	})
+
+	t.Run("updates the privileges", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		_, handler := kbcloud.GrantAccountPrivileges(s.GetClientFn())
+
+		result, err := callTool(handler, map[string]any{"instance_ref": "acme/prod/cache", "account_name": "default", "preset": "readonly"})
+		require.NoError(t, err)
+		require.False(t, result.IsError, resultText(t, result))
+
+		account, ok := s.Account("acme", "cache", "default")
+		require.True(t, ok)
+		require.Len(t, account.PrivilegesList, 1)
+		assert.Equal(t, client.PrivilegeTypeReadonly, account.PrivilegesList[0].Privileges)
+	})
+}
+
+func TestDeleteAccount(t *testing.T) {
+	runToolTests(t, kbcloud.DeleteAccount, []toolTest{
+		{
+			name: "basic account",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "app_orders", "confirm": "app_orders"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"instance_ref":"acme/prod/orders-db","account_name":"app_orders","deleted":true}`, text)
+			},
+		},
+		{
+			name:        "unconfirmed",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "app_orders"},
+			wantToolErr: `call again with confirm set to "app_orders"`,
+		},
+		{
+			name:        "confirmation of another account",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "app_orders", "confirm": "root"},
+			wantToolErr: "does not match",
+		},
+		{
+			name:        "root account",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "root", "confirm": "root"},
+			wantToolErr: "cannot be deleted",
+		},
+		{
+			name:        "missing account name",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db"},
+			wantToolErr: "account_name",
+		},
+	})
+
+	t.Run("deletes the account", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		_, handler := kbcloud.DeleteAccount(s.GetClientFn())
+
+		result, err := callTool(handler, map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "app_orders", "confirm": "app_orders"})
+		require.NoError(t, err)
+		require.False(t, result.IsError, resultText(t, result))
+
+		_, ok := s.Account("acme", "orders-db", "app_orders")
+		assert.False(t, ok)
+	})
+}
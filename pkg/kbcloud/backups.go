@@ -2,11 +2,11 @@ package kbcloud
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
@@ -15,31 +15,27 @@ import (
 func ListBackups(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
 	return mcp.NewTool("list_backups",
 			mcp.WithDescription("List all backups for a KB Cloud instance"),
-			mcp.WithString("org_name",
-				mcp.Required(),
-				mcp.Description("Organization name"),
-			),
-			mcp.WithString("env_name",
-				mcp.Required(),
-				mcp.Description("Environment name"),
-			),
-			mcp.WithString("instance_name",
-				mcp.Required(),
-				mcp.Description("Instance name"),
-			),
+			mcp.WithReadOnlyHintAnnotation(true),
+			WithInstanceRef(),
 			WithPagination(),
+			WithResponseShaping(),
+			WithOutputSchema[kbcloud.BackupList](),
 		),
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			// Get required parameters
-			orgName, err := RequiredParam[string](request, "org_name")
+			ref, err := InstanceRefParams(ctx, request)
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
-			envName, err := RequiredParam[string](request, "env_name")
+
+			// Get pagination parameters
+			pagination, err := OptionalPaginationParams(request)
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
-			instanceName, err := RequiredParam[string](request, "instance_name")
+
+			// Get response shaping parameters
+			shaping, err := ResponseShapingParams(ctx, request)
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
@@ -50,8 +46,19 @@ func ListBackups(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandl
 				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
 			}
 
+			// Backups are listed by organization and instance name, so make
+			// sure the instance lives in the requested environment
+			ref, _, err = getInstance(client, ref)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+
 			// Call KB Cloud API
-			backups, resp, err := client.Backup.ListBackups(client.Context, orgName, &[]interface{}{})
+			opts := kbcloud.NewListBackupsOptionalParameters().
+				WithClusterName(ref.Instance).
+				WithPage(int32(pagination.Page)).
+				WithPageSize(int32(pagination.PerPage))
+			backups, resp, err := client.Backup.ListBackups(client.Context, ref.Org, *opts)
 			if err != nil {
 				return nil, fmt.Errorf("failed to list backups: %w", err)
 			}
@@ -67,12 +74,7 @@ func ListBackups(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandl
 			}
 
 			// Return result
-			result, err := json.Marshal(backups)
-			if err != nil {
-				return nil, fmt.Errorf("failed to marshal response: %w", err)
-			}
-
-			return mcp.NewToolResultText(string(result)), nil
+			return shaping.result(resourceBackup, backups)
 		}
 }
 
@@ -80,18 +82,20 @@ func ListBackups(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandl
 func GetBackup(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
 	return mcp.NewTool("get_backup",
 			mcp.WithDescription("Get details of a specific backup in KB Cloud"),
+			mcp.WithReadOnlyHintAnnotation(true),
 			mcp.WithString("org_name",
-				mcp.Required(),
-				mcp.Description("Organization name"),
+				mcp.Description("Organization name; defaults to the session context"),
 			),
 			mcp.WithString("backup_id",
 				mcp.Required(),
 				mcp.Description("Backup ID"),
 			),
+			WithResponseShaping(),
+			WithOutputSchema[kbcloud.Backup](),
 		),
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			// Get required parameters
-			orgName, err := RequiredParam[string](request, "org_name")
+			orgName, err := orgParam(ctx, request, "org_name")
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
@@ -100,6 +104,12 @@ func GetBackup(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandler
 				return mcp.NewToolResultError(err.Error()), nil
 			}
 
+			// Get response shaping parameters
+			shaping, err := ResponseShapingParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
 			// Get KB Cloud client
 			client, err := getClient(ctx)
 			if err != nil {
@@ -123,11 +133,6 @@ func GetBackup(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandler
 			}
 
 			// Return result
-			result, err := json.Marshal(backup)
-			if err != nil {
-				return nil, fmt.Errorf("failed to marshal response: %w", err)
-			}
-
-			return mcp.NewToolResultText(string(result)), nil
+			return shaping.result(resourceBackup, backup)
 		}
 }
@@ -0,0 +1,94 @@
+package kbcloud_test
+
+import (
+	"net/http"
+	"testing"
+
+	client "github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud/kbcloudtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListBackups(t *testing.T) {
+	runToolTests(t, kbcloud.ListBackups, []toolTest{
+		{
+			name: "backups of an instance",
+			args: map[string]any{"org_name": "acme", "env_name": "prod", "instance_name": "orders-db"},
+			check: func(t *testing.T, text string) {
+				backups := decode[client.BackupList](t, text)
+				require.Len(t, backups.Items, 3)
+				assert.Equal(t, int64(3), backups.PageResult.GetTotalSize())
+				for _, b := range backups.Items {
+					assert.Equal(t, "orders-db", b.SourceCluster)
+				}
+			},
+		},
+		{
+			name: "server side pagination",
+			args: map[string]any{"org_name": "acme", "env_name": "prod", "instance_name": "orders-db", "page": float64(2), "perPage": float64(2)},
+			check: func(t *testing.T, text string) {
+				backups := decode[client.BackupList](t, text)
+				require.Len(t, backups.Items, 1)
+				assert.Equal(t, "orders-db-backup-3", backups.Items[0].Name)
+			},
+		},
+		{
+			name:        "missing environment",
+			args:        map[string]any{"org_name": "acme", "instance_name": "orders-db"},
+			wantToolErr: "env_name",
+		},
+		{
+			name:        "instance of another environment",
+			args:        map[string]any{"org_name": "acme", "env_name": "staging", "instance_name": "orders-db"},
+			wantToolErr: "instance orders-db not found in environment staging",
+		},
+		{
+			name: "instance ref",
+			args: map[string]any{"instance_ref": "acme/staging/analytics"},
+			check: func(t *testing.T, text string) {
+				backups := decode[client.BackupList](t, text)
+				require.Len(t, backups.Items, 1)
+				assert.Equal(t, "analytics-backup-1", backups.Items[0].Name)
+			},
+		},
+		{
+			name:        "unknown instance ref",
+			args:        map[string]any{"instance_ref": "acme/prod/analytic"},
+			wantToolErr: "did you mean acme/staging/analytics?",
+		},
+		{
+			name: "rate limited",
+			args: map[string]any{"org_name": "acme", "env_name": "prod", "instance_name": "orders-db"},
+			setup: func(s *kbcloudtest.Server) {
+				s.InjectError(http.MethodGet, "/api/v1/organizations/acme/backups", http.StatusTooManyRequests, "slow down")
+			},
+			wantErr: "failed to list backups",
+		},
+	})
+}
+
+func TestGetBackup(t *testing.T) {
+	runToolTests(t, kbcloud.GetBackup, []toolTest{
+		{
+			name: "existing backup",
+			args: map[string]any{"org_name": "acme", "backup_id": "analytics-backup-1"},
+			check: func(t *testing.T, text string) {
+				backup := decode[client.Backup](t, text)
+				assert.Equal(t, "analytics", backup.SourceCluster)
+				assert.Equal(t, client.BackupStatusFailed, backup.Status)
+			},
+		},
+		{
+			name:        "missing backup id",
+			args:        map[string]any{"org_name": "acme"},
+			wantToolErr: "backup_id",
+		},
+		{
+			name:    "backup of another organization",
+			args:    map[string]any{"org_name": "acme", "backup_id": "inventory-backup-1"},
+			wantErr: "404",
+		},
+	})
+}
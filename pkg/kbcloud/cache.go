@@ -0,0 +1,146 @@
+package kbcloud
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/metrics"
+)
+
+// apiCacheName labels KB Cloud API response cache lookups in metrics
+const apiCacheName = "kbcloud_api"
+
+// maxCacheEntries bounds the number of cached responses
+const maxCacheEntries = 1024
+
+// responseCache caches successful KB Cloud GET responses for a fixed TTL.
+// It is shared by every client a server creates, so entries are keyed by
+// API key as well as URL.
+type responseCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cachedResponse
+}
+
+// cachedResponse is a response body kept by responseCache
+type cachedResponse struct {
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+// newResponseCache creates a cache whose entries live for ttl
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[string]cachedResponse{},
+	}
+}
+
+// get returns the unexpired entry for key
+func (c *responseCache) get(key string) (cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || c.now().After(entry.expires) {
+		return cachedResponse{}, false
+	}
+	return entry, true
+}
+
+// put stores an entry for key, evicting expired entries when the cache is full
+func (c *responseCache) put(key string, entry cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= maxCacheEntries {
+		now := c.now()
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxCacheEntries {
+			c.entries = map[string]cachedResponse{}
+		}
+	}
+	entry.expires = c.now().Add(c.ttl)
+	c.entries[key] = entry
+}
+
+// invalidate drops every entry whose key starts with prefix
+func (c *responseCache) invalidate(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k := range c.entries {
+		if strings.HasPrefix(k, prefix) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// cachingTransport answers repeated GET requests from a responseCache
+type cachingTransport struct {
+	next   http.RoundTripper
+	cache  *responseCache
+	apiKey string
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Changes make cached responses of the same caller stale
+	if req.Method != http.MethodGet {
+		resp, err := t.next.RoundTrip(req)
+		if err == nil && resp.StatusCode < http.StatusMultipleChoices {
+			t.cache.invalidate(t.apiKey + " ")
+		}
+		return resp, err
+	}
+
+	// Operation status is polled and must always be fresh
+	if strings.Contains(req.URL.Path, "/opsrequests/") {
+		return t.next.RoundTrip(req)
+	}
+
+	key := t.apiKey + " " + req.URL.String()
+	if entry, ok := t.cache.get(key); ok {
+		metrics.ObserveCache(apiCacheName, true)
+		return &http.Response{
+			Status:        http.StatusText(entry.status),
+			StatusCode:    entry.status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        entry.header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(entry.body)),
+			ContentLength: int64(len(entry.body)),
+			Request:       req,
+		}, nil
+	}
+	metrics.ObserveCache(apiCacheName, false)
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.cache.put(key, cachedResponse{status: resp.StatusCode, header: resp.Header.Clone(), body: body})
+	return resp, nil
+}
@@ -0,0 +1,621 @@
+package kbcloud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/metrics"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// catalogCacheName labels catalog cache lookups in metrics
+const catalogCacheName = "catalog"
+
+// catalogEngine is an engine available in an environment
+type catalogEngine struct {
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Version     string `json:"version,omitempty"`
+	Provider    string `json:"provider,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// engineList is the result of list_engines
+type engineList struct {
+	Environment string          `json:"environment"`
+	Items       []catalogEngine `json:"items"`
+}
+
+// engineVersion is a major version of an engine with its minor versions
+type engineVersion struct {
+	Major         string   `json:"major"`
+	Default       bool     `json:"default"`
+	DefaultMinor  string   `json:"default_minor,omitempty"`
+	MinorVersions []string `json:"minor_versions"`
+}
+
+// engineVersionList is the result of list_engine_versions
+type engineVersionList struct {
+	Engine string          `json:"engine"`
+	Mode   string          `json:"mode"`
+	Items  []engineVersion `json:"items"`
+}
+
+// instanceClass is a CPU and memory size an engine component can run with
+type instanceClass struct {
+	Code      string  `json:"code"`
+	Component string  `json:"component,omitempty"`
+	Mode      string  `json:"mode,omitempty"`
+	CPU       float64 `json:"cpu"`
+	MemoryGiB float64 `json:"memory_gib"`
+}
+
+// instanceClassList is the result of list_instance_classes
+type instanceClassList struct {
+	Engine string          `json:"engine"`
+	Items  []instanceClass `json:"items"`
+}
+
+// storageClass is a storage class of an environment
+type storageClass struct {
+	Name                 string `json:"name"`
+	DisplayName          string `json:"display_name,omitempty"`
+	Type                 string `json:"type,omitempty"`
+	Provisioner          string `json:"provisioner,omitempty"`
+	Default              bool   `json:"default"`
+	Enabled              bool   `json:"enabled"`
+	AllowVolumeExpansion bool   `json:"allow_volume_expansion"`
+}
+
+// storageClassList is the result of list_storage_classes
+type storageClassList struct {
+	Environment string         `json:"environment"`
+	Items       []storageClass `json:"items"`
+}
+
+// ListEngines creates a tool to list the engines of an environment
+func ListEngines(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("list_engines",
+			mcp.WithDescription("List the database engines instances can be created with in an environment. Use the engine names exactly as listed"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithString("org_name",
+				mcp.Description("Organization name; defaults to the session context"),
+			),
+			mcp.WithString("env_name",
+				mcp.Description("Environment name; defaults to the session context"),
+			),
+			mcp.WithBoolean("include_disabled",
+				mcp.Description("Also list engines that are installed but disabled (default false)"),
+			),
+			WithResponseShaping(),
+			WithOutputSchema[engineList](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			orgName, err := orgParam(ctx, request, "org_name")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			envName, err := envParam(ctx, request, orgName)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get optional parameters
+			includeDisabled, err := OptionalParam[bool](request, "include_disabled")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			shaping, err := ResponseShapingParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			// Call KB Cloud API
+			engines, err := catalogEngines(client, orgName, envName)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+
+			result := engineList{Environment: envName, Items: []catalogEngine{}}
+			for _, e := range engines {
+				if includeDisabled || engineEnabled(e) {
+					result.Items = append(result.Items, e)
+				}
+			}
+
+			// Return result
+			return shaping.result(resourceEngine, result)
+		}
+}
+
+// ListEngineVersions creates a tool to list the versions of an engine
+func ListEngineVersions(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("list_engine_versions",
+			mcp.WithDescription("List the versions of an engine that instances can be created with in an environment, with the default of each major version"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithString("org_name",
+				mcp.Description("Organization name; defaults to the session context"),
+			),
+			mcp.WithString("env_name",
+				mcp.Description("Environment name; defaults to the session context"),
+			),
+			mcp.WithString("engine",
+				mcp.Required(),
+				mcp.Description("Engine name as returned by list_engines"),
+			),
+			mcp.WithString("mode",
+				mcp.Description("Deployment mode, e.g. standalone or replication; defaults to standalone"),
+			),
+			mcp.WithString("component",
+				mcp.Description("Component whose versions to list; defaults to the main one"),
+			),
+			WithResponseShaping(),
+			WithOutputSchema[engineVersionList](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			orgName, err := orgParam(ctx, request, "org_name")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			envName, err := envParam(ctx, request, orgName)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			engine, err := RequiredParam[string](request, "engine")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get optional parameters
+			mode, err := OptionalParam[string](request, "mode")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if mode == "" {
+				mode = "standalone"
+			}
+			component, err := OptionalParam[string](request, "component")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			shaping, err := ResponseShapingParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			if err := CheckEngine(client, orgName, envName, engine); err != nil {
+				return lookupErrorResult(err)
+			}
+
+			// Call KB Cloud API
+			versions, err := catalogVersions(client, envName, engine, mode, component)
+			if err != nil {
+				return nil, err
+			}
+
+			// Return result
+			return shaping.result(resourceEngineVersion, engineVersionList{Engine: engine, Mode: mode, Items: versions})
+		}
+}
+
+// ListInstanceClasses creates a tool to list the instance classes of an engine
+func ListInstanceClasses(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("list_instance_classes",
+			mcp.WithDescription("List the instance classes of an engine with the CPU cores and memory of each. Use the class codes exactly as listed"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithString("org_name",
+				mcp.Description("Organization name; defaults to the session context"),
+			),
+			mcp.WithString("env_name",
+				mcp.Description("Environment name; defaults to the session context"),
+			),
+			mcp.WithString("engine",
+				mcp.Required(),
+				mcp.Description("Engine name as returned by list_engines"),
+			),
+			mcp.WithString("mode",
+				mcp.Description("Only classes of this deployment mode"),
+			),
+			mcp.WithString("component",
+				mcp.Description("Only classes of this component"),
+			),
+			WithResponseShaping(),
+			WithOutputSchema[instanceClassList](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			orgName, err := orgParam(ctx, request, "org_name")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			envName, err := envParam(ctx, request, orgName)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			engine, err := RequiredParam[string](request, "engine")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get optional parameters
+			mode, err := OptionalParam[string](request, "mode")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			component, err := OptionalParam[string](request, "component")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			shaping, err := ResponseShapingParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			if err := CheckEngine(client, orgName, envName, engine); err != nil {
+				return lookupErrorResult(err)
+			}
+
+			// Call KB Cloud API
+			classes, err := catalogClasses(client, engine)
+			if err != nil {
+				return nil, err
+			}
+
+			result := instanceClassList{Engine: engine, Items: []instanceClass{}}
+			for _, c := range classes {
+				if (mode == "" || c.Mode == "" || c.Mode == mode) && (component == "" || c.Component == "" || c.Component == component) {
+					result.Items = append(result.Items, c)
+				}
+			}
+
+			// Return result
+			return shaping.result(resourceInstanceClass, result)
+		}
+}
+
+// ListStorageClasses creates a tool to list the storage classes of an environment
+func ListStorageClasses(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("list_storage_classes",
+			mcp.WithDescription("List the storage classes of an environment, marking the default one and those whose volumes can be expanded"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithString("org_name",
+				mcp.Description("Organization name; defaults to the session context"),
+			),
+			mcp.WithString("env_name",
+				mcp.Description("Environment name; defaults to the session context"),
+			),
+			WithResponseShaping(),
+			WithOutputSchema[storageClassList](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			orgName, err := orgParam(ctx, request, "org_name")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			envName, err := envParam(ctx, request, orgName)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get response shaping parameters
+			shaping, err := ResponseShapingParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			// Call KB Cloud API
+			classes, err := catalogStorageClasses(client, orgName, envName)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+
+			// Return result
+			return shaping.result(resourceStorageClass, storageClassList{Environment: envName, Items: classes})
+		}
+}
+
+// catalogEngines returns the engines of an environment, sorted by name
+func catalogEngines(client *Client, orgName, envName string) ([]catalogEngine, error) {
+	return cachedCatalog(client, "engines "+orgName+"/"+envName, func() ([]catalogEngine, error) {
+		engines, resp, err := client.Engine.ListEnginesInEnv(client.Context, envName)
+		if resp != nil {
+			defer func() { _ = resp.Body.Close() }()
+			if resp.StatusCode == http.StatusNotFound {
+				return nil, &lookupError{fmt.Sprintf("environment %q not found; use list_environments to see the environments of %s", envName, orgName)}
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list engines: %w", err)
+		}
+
+		items := []catalogEngine{}
+		for _, e := range engines {
+			items = append(items, catalogEngine{
+				Name:        e.GetName(),
+				Type:        string(e.GetType()),
+				Status:      string(e.GetStatus()),
+				Version:     e.GetVersion(),
+				Provider:    e.GetProvider(),
+				Description: e.GetDescription(),
+			})
+		}
+		sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+		return items, nil
+	})
+}
+
+// engineEnabled reports whether instances can be created with an engine
+func engineEnabled(e catalogEngine) bool {
+	return e.Status == "" || e.Status == string(kbcloud.EngineStatusEnabled)
+}
+
+// CheckEngine makes sure an engine is enabled in an environment, reporting
+// unknown engines as a lookupError suggesting similar names. Like
+// CheckEngineVersion and CheckInstanceClass, it validates tool arguments
+// against the cached catalog before they are sent to KB Cloud.
+func CheckEngine(client *Client, orgName, envName, engine string) error {
+	engines, err := catalogEngines(client, orgName, envName)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, e := range engines {
+		if !engineEnabled(e) {
+			if e.Name == engine {
+				return &lookupError{fmt.Sprintf("engine %s is %s in %s/%s", engine, strings.ToLower(e.Status), orgName, envName)}
+			}
+			continue
+		}
+		if e.Name == engine {
+			return nil
+		}
+		names = append(names, e.Name)
+	}
+
+	message := fmt.Sprintf("engine %q is not available in %s/%s", engine, orgName, envName)
+	if suggestions := suggest(engine, names); len(suggestions) > 0 {
+		return &lookupError{fmt.Sprintf("%s; did you mean %s?", message, strings.Join(suggestions, ", "))}
+	}
+	return &lookupError{fmt.Sprintf("%s; available engines: %s", message, strings.Join(names, ", "))}
+}
+
+// catalogVersions returns the versions of an engine mode in an environment
+func catalogVersions(client *Client, envName, engine, mode, component string) ([]engineVersion, error) {
+	return cachedCatalog(client, "versions "+envName+"/"+engine+"/"+mode+"/"+component, func() ([]engineVersion, error) {
+		opts := kbcloud.NewListServiceVersionOptionalParameters()
+		if component != "" {
+			opts.WithComponent(component)
+		}
+		versions, resp, err := client.Engine.ListServiceVersion(client.Context, envName, engine, mode, *opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list engine versions: %w", err)
+		}
+		_ = resp.Body.Close()
+
+		items := []engineVersion{}
+		for _, v := range versions.Versions {
+			items = append(items, engineVersion{
+				Major:         v.GetMajorVersion(),
+				Default:       v.GetDefault(),
+				DefaultMinor:  v.GetDefaultMinorVersion(),
+				MinorVersions: append([]string{}, v.MinorVersions...),
+			})
+		}
+		return items, nil
+	})
+}
+
+// CheckEngineVersion makes sure version, a major or minor version, is
+// available for an engine mode in an environment
+func CheckEngineVersion(client *Client, envName, engine, mode, component, version string) error {
+	versions, err := catalogVersions(client, envName, engine, mode, component)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, v := range versions {
+		if v.Major == version {
+			return nil
+		}
+		for _, minor := range v.MinorVersions {
+			if minor == version {
+				return nil
+			}
+			names = append(names, minor)
+		}
+	}
+
+	message := fmt.Sprintf("version %q of %s %s is not available in environment %s", version, engine, mode, envName)
+	if suggestions := suggest(version, names); len(suggestions) > 0 {
+		return &lookupError{fmt.Sprintf("%s; did you mean %s?", message, strings.Join(suggestions, ", "))}
+	}
+	return &lookupError{fmt.Sprintf("%s; use list_engine_versions to see the available versions", message)}
+}
+
+// CheckInstanceClass makes sure code is an instance class of an engine for
+// mode and component, either of which may be empty to match any
+func CheckInstanceClass(client *Client, engine, mode, component, code string) error {
+	classes, err := catalogClasses(client, engine)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, c := range classes {
+		if (mode != "" && c.Mode != "" && c.Mode != mode) || (component != "" && c.Component != "" && c.Component != component) {
+			continue
+		}
+		if c.Code == code {
+			return nil
+		}
+		names = append(names, c.Code)
+	}
+
+	message := fmt.Sprintf("instance class %q is not available for %s", code, engine)
+	if suggestions := suggest(code, names); len(suggestions) > 0 {
+		return &lookupError{fmt.Sprintf("%s; did you mean %s?", message, strings.Join(suggestions, ", "))}
+	}
+	return &lookupError{fmt.Sprintf("%s; use list_instance_classes to see the available classes", message)}
+}
+
+// catalogClasses returns the instance classes of an engine, smallest first
+func catalogClasses(client *Client, engine string) ([]instanceClass, error) {
+	return cachedCatalog(client, "classes "+engine, func() ([]instanceClass, error) {
+		classes, resp, err := client.Class.ListClasses(client.Context, *kbcloud.NewListClassesOptionalParameters().WithEngineName(engine))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list instance classes: %w", err)
+		}
+		_ = resp.Body.Close()
+
+		items := []instanceClass{}
+		for _, c := range classes {
+			items = append(items, instanceClass{
+				Code:      c.GetCode(),
+				Component: c.GetComponent(),
+				Mode:      c.GetMode(),
+				CPU:       c.GetCpu(),
+				MemoryGiB: c.GetMemory(),
+			})
+		}
+		sort.SliceStable(items, func(i, j int) bool {
+			if items[i].CPU != items[j].CPU {
+				return items[i].CPU < items[j].CPU
+			}
+			return items[i].MemoryGiB < items[j].MemoryGiB
+		})
+		return items, nil
+	})
+}
+
+// catalogStorageClasses returns the storage classes of an environment, the
+// default one first
+func catalogStorageClasses(client *Client, orgName, envName string) ([]storageClass, error) {
+	return cachedCatalog(client, "storage "+orgName+"/"+envName, func() ([]storageClass, error) {
+		classes, resp, err := client.StorageClass.GetStorageClassStats(client.Context, orgName, envName)
+		if resp != nil {
+			defer func() { _ = resp.Body.Close() }()
+			if resp.StatusCode == http.StatusNotFound {
+				return nil, &lookupError{fmt.Sprintf("environment %q not found; use list_environments to see the environments of %s", envName, orgName)}
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list storage classes: %w", err)
+		}
+
+		items := []storageClass{}
+		for _, c := range classes.Items {
+			items = append(items, storageClass{
+				Name:                 c.Name,
+				DisplayName:          c.DisplayName,
+				Type:                 c.Type,
+				Provisioner:          c.Provisioner,
+				Default:              c.IsDefaultClass,
+				Enabled:              c.Enabled,
+				AllowVolumeExpansion: c.AllowVolumeExpansion,
+			})
+		}
+		sort.SliceStable(items, func(i, j int) bool { return items[i].Default && !items[j].Default })
+		return items, nil
+	})
+}
+
+// catalogCache keeps catalog lookups for a fixed TTL. Unlike the response
+// cache it keeps the decoded result, so engine checks and listings share
+// entries.
+type catalogCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]catalogEntry
+}
+
+// catalogEntry is a value kept by catalogCache
+type catalogEntry struct {
+	value   any
+	expires time.Time
+}
+
+// newCatalogCache creates a cache whose entries live for ttl
+func newCatalogCache(ttl time.Duration) *catalogCache {
+	return &catalogCache{ttl: ttl, now: time.Now, entries: map[string]catalogEntry{}}
+}
+
+// get returns the unexpired value for key
+func (c *catalogCache) get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || c.now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// put stores a value for key, dropping every entry when the cache is full
+func (c *catalogCache) put(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= maxCacheEntries {
+		c.entries = map[string]catalogEntry{}
+	}
+	c.entries[key] = catalogEntry{value: value, expires: c.now().Add(c.ttl)}
+}
+
+// cachedCatalog returns the cached result of load for key, calling load and
+// caching its result on a miss. Errors are not cached.
+func cachedCatalog[T any](client *Client, key string, load func() (T, error)) (T, error) {
+	if client.catalog == nil {
+		return load()
+	}
+
+	key = client.identity + " " + key
+	if v, ok := client.catalog.get(key); ok {
+		metrics.ObserveCache(catalogCacheName, true)
+		return v.(T), nil
+	}
+	metrics.ObserveCache(catalogCacheName, false)
+
+	v, err := load()
+	if err != nil {
+		return v, err
+	}
+	client.catalog.put(key, v)
+	return v, nil
+}
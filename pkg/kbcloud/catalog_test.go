@@ -0,0 +1,235 @@
+package kbcloud_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud/kbcloudtest"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/translations"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListEngines(t *testing.T) {
+	runToolTests(t, kbcloud.ListEngines, []toolTest{
+		{
+			name: "enabled engines",
+			args: map[string]any{"org_name": "acme", "env_name": "prod", "fields": "name,type"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"environment":"prod","items":[
+					{"name":"mysql","type":"RDBMS"},
+					{"name":"postgresql","type":"RDBMS"},
+					{"name":"redis","type":"key-value"}
+				]}`, text)
+			},
+		},
+		{
+			name: "disabled engines",
+			args: map[string]any{"org_name": "acme", "env_name": "prod", "include_disabled": true, "view": "summary"},
+			check: func(t *testing.T, text string) {
+				assert.Contains(t, text, `{"name":"mongodb","status":"Disabled","type":"document"}`)
+			},
+		},
+		{
+			name:        "unknown environment",
+			args:        map[string]any{"org_name": "acme", "env_name": "qa"},
+			wantToolErr: `environment "qa" not found`,
+		},
+		{
+			name:        "missing environment",
+			args:        map[string]any{"org_name": "acme"},
+			wantToolErr: "env_name",
+		},
+	})
+}
+
+func TestListEngineVersions(t *testing.T) {
+	runToolTests(t, kbcloud.ListEngineVersions, []toolTest{
+		{
+			name: "versions of an engine",
+			args: map[string]any{"org_name": "acme", "env_name": "prod", "engine": "mysql"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"engine":"mysql","mode":"standalone","items":[
+					{"major":"8.0","default":true,"default_minor":"8.0.33","minor_versions":["8.0.33","8.0.30"]},
+					{"major":"5.7","default":false,"default_minor":"5.7.44","minor_versions":["5.7.44"]}
+				]}`, text)
+			},
+		},
+		{
+			name: "summary",
+			args: map[string]any{"org_name": "acme", "env_name": "staging", "engine": "postgresql", "mode": "replication", "view": "summary"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"engine":"postgresql","mode":"replication","items":[
+					{"major":"15","default":true,"default_minor":"15.7.0"},
+					{"major":"14","default":false,"default_minor":"14.8.0"}
+				]}`, text)
+			},
+		},
+		{
+			name:        "misspelled engine",
+			args:        map[string]any{"org_name": "acme", "env_name": "prod", "engine": "mysq"},
+			wantToolErr: `engine "mysq" is not available in acme/prod; did you mean mysql?`,
+		},
+		{
+			name:        "engine of another environment",
+			args:        map[string]any{"org_name": "acme", "env_name": "staging", "engine": "redis"},
+			wantToolErr: "available engines: mysql, postgresql",
+		},
+		{
+			name:        "disabled engine",
+			args:        map[string]any{"org_name": "acme", "env_name": "prod", "engine": "mongodb"},
+			wantToolErr: "engine mongodb is disabled in acme/prod",
+		},
+		{
+			name:        "missing engine",
+			args:        map[string]any{"org_name": "acme", "env_name": "prod"},
+			wantToolErr: "missing required parameter: engine",
+		},
+	})
+}
+
+func TestListInstanceClasses(t *testing.T) {
+	runToolTests(t, kbcloud.ListInstanceClasses, []toolTest{
+		{
+			name: "classes of an engine, smallest first",
+			args: map[string]any{"org_name": "acme", "env_name": "prod", "engine": "mysql", "view": "summary"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"engine":"mysql","items":[
+					{"code":"general-1c2g","cpu":1,"memory_gib":2},
+					{"code":"general-2c4g","cpu":2,"memory_gib":4},
+					{"code":"memory-4c32g","cpu":4,"memory_gib":32}
+				]}`, text)
+			},
+		},
+		{
+			name: "other component",
+			args: map[string]any{"org_name": "acme", "env_name": "prod", "engine": "mysql", "component": "proxy"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"engine":"mysql","items":[]}`, text)
+			},
+		},
+		{
+			name:        "misspelled engine",
+			args:        map[string]any{"org_name": "acme", "env_name": "prod", "engine": "postgres"},
+			wantToolErr: "did you mean postgresql?",
+		},
+	})
+}
+
+func TestListStorageClasses(t *testing.T) {
+	runToolTests(t, kbcloud.ListStorageClasses, []toolTest{
+		{
+			name: "default class first",
+			args: map[string]any{"org_name": "acme", "env_name": "prod", "view": "summary"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"environment":"prod","items":[
+					{"name":"gp3","default":true,"allow_volume_expansion":true},
+					{"name":"io2","default":false,"allow_volume_expansion":true}
+				]}`, text)
+			},
+		},
+		{
+			name: "other organization",
+			args: map[string]any{"org_name": "globex", "env_name": "dev", "fields": "name,allow_volume_expansion"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"environment":"dev","items":[{"name":"pd-ssd","allow_volume_expansion":false}]}`, text)
+			},
+		},
+		{
+			name:        "environment of another organization",
+			args:        map[string]any{"org_name": "globex", "env_name": "prod"},
+			wantToolErr: `environment "prod" not found`,
+		},
+	})
+}
+
+func TestCheckEngineVersion(t *testing.T) {
+	s := kbcloudtest.NewServer(t)
+	client, err := s.GetClientFn()(context.Background())
+	require.NoError(t, err)
+
+	assert.NoError(t, kbcloud.CheckEngineVersion(client, "prod", "mysql", "standalone", "", "8.0"))
+	assert.NoError(t, kbcloud.CheckEngineVersion(client, "prod", "mysql", "standalone", "", "8.0.30"))
+
+	err = kbcloud.CheckEngineVersion(client, "prod", "mysql", "standalone", "", "8.0.31")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `version "8.0.31" of mysql standalone is not available in environment prod; did you mean 8.0.30, 8.0.33?`)
+
+	err = kbcloud.CheckEngineVersion(client, "prod", "mysql", "standalone", "", "9")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "use list_engine_versions to see the available versions")
+}
+
+func TestCheckInstanceClass(t *testing.T) {
+	s := kbcloudtest.NewServer(t)
+	client, err := s.GetClientFn()(context.Background())
+	require.NoError(t, err)
+
+	assert.NoError(t, kbcloud.CheckInstanceClass(client, "mysql", "", "", "general-2c4g"))
+
+	err = kbcloud.CheckInstanceClass(client, "mysql", "", "", "general-2c8g")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `instance class "general-2c8g" is not available for mysql; did you mean general-2c4g`)
+
+	err = kbcloud.CheckInstanceClass(client, "mysql", "", "proxy", "general-2c4g")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "use list_instance_classes to see the available classes")
+}
+
+// catalogServer returns a server with the given catalog cache TTL and the
+// KB Cloud fake it calls
+func catalogServer(t *testing.T, catalogTTL time.Duration) (*server.MCPServer, *kbcloudtest.Server) {
+	s := kbcloudtest.NewServer(t)
+	mcpServer, err := kbcloud.NewServer(kbcloud.ServerOptions{
+		APIKey:          kbcloudtest.APIKey,
+		APISecret:       kbcloudtest.APISecret,
+		Site:            s.URL,
+		Translator:      translations.NullTranslationHelper,
+		CatalogCacheTTL: catalogTTL,
+	})
+	require.NoError(t, err)
+	return mcpServer, s
+}
+
+func TestCatalogCache(t *testing.T) {
+	// The catalog is cached by default, without a response cache
+	mcpServer, s := catalogServer(t, 0)
+	listEngines := mcpServer.GetTool("list_engines").Handler
+	listVersions := mcpServer.GetTool("list_engine_versions").Handler
+
+	for i := 0; i < 3; i++ {
+		result, err := callTool(listEngines, map[string]any{"org_name": "acme", "env_name": "prod"})
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+	}
+	assert.Len(t, s.Requests(), 1, "repeated catalog lookups should be served from the cache")
+
+	// Engine checks reuse the cached engines
+	result, err := callTool(listVersions, map[string]any{"org_name": "acme", "env_name": "prod", "engine": "redis"})
+	require.NoError(t, err)
+	assert.Contains(t, resultText(t, result), `"major":"7.2"`)
+	assert.Len(t, s.Requests(), 2)
+
+	// Errors are never cached
+	for i := 0; i < 2; i++ {
+		result, err := callTool(listEngines, map[string]any{"org_name": "acme", "env_name": "qa"})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	}
+	assert.Len(t, s.Requests(), 4)
+}
+
+func TestCatalogCacheDisabled(t *testing.T) {
+	mcpServer, s := catalogServer(t, -1)
+	listEngines := mcpServer.GetTool("list_engines").Handler
+
+	for i := 0; i < 3; i++ {
+		result, err := callTool(listEngines, map[string]any{"org_name": "acme", "env_name": "prod"})
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+	}
+	assert.Len(t, s.Requests(), 3, "catalog lookups should not be cached with a negative TTL")
+}
@@ -3,10 +3,16 @@ package kbcloud
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 
 	"github.com/apecloud/kb-cloud-client-go/api/common"
 	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+	mcplog "github.com/apecloud/kb-cloud-mcp-server/pkg/log"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/metrics"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/tracing"
+	"github.com/icholy/digest"
+	log "github.com/sirupsen/logrus"
 )
 
 // GetClientFn is a function type that returns a KB Cloud API client
@@ -28,6 +34,40 @@ type Client struct {
 
 	// Backup API
 	Backup *kbcloud.BackupApi
+
+	// Account API (for database accounts)
+	Account *kbcloud.AccountApi
+
+	// Database API (for logical databases)
+	Database *kbcloud.DatabaseApi
+
+	// Parameter API (for engine parameters)
+	Parameter *kbcloud.ParameterApi
+
+	// Ops request API (for cluster operations)
+	Opsrequest *kbcloud.OpsrequestApi
+
+	// Parameter template API
+	ParamTpl *kbcloud.ParamTplApi
+
+	// Engine, class and storage class APIs (for the catalog)
+	Engine       *kbcloud.EngineApi
+	Class        *kbcloud.ClassApi
+	StorageClass *kbcloud.StorageClassApi
+
+	// IP whitelist API (for instance allowlists)
+	IpWhitelist *kbcloud.IpWhitelistApi
+
+	// Cluster log API (for instance logs)
+	ClusterLog *kbcloud.ClusterLogApi
+
+	// Event API (for instance events)
+	Event *kbcloud.EventApi
+
+	// catalog caches catalog lookups across clients of the same factory,
+	// keyed by identity; nil disables caching
+	catalog  *catalogCache
+	identity string
 }
 
 // NewClient creates a new KB Cloud client
@@ -39,11 +79,62 @@ func NewClient(apiClient *common.APIClient, ctx context.Context) *Client {
 		Environment:  kbcloud.NewEnvironmentApi(apiClient),
 		Cluster:      kbcloud.NewClusterApi(apiClient),
 		Backup:       kbcloud.NewBackupApi(apiClient),
+		Account:      kbcloud.NewAccountApi(apiClient),
+		Database:     kbcloud.NewDatabaseApi(apiClient),
+		Parameter:    kbcloud.NewParameterApi(apiClient),
+		Opsrequest:   kbcloud.NewOpsrequestApi(apiClient),
+		ParamTpl:     kbcloud.NewParamTplApi(apiClient),
+		Engine:       kbcloud.NewEngineApi(apiClient),
+		Class:        kbcloud.NewClassApi(apiClient),
+		StorageClass: kbcloud.NewStorageClassApi(apiClient),
+		IpWhitelist:  kbcloud.NewIpWhitelistApi(apiClient),
+		ClusterLog:   kbcloud.NewClusterLogApi(apiClient),
+		Event:        kbcloud.NewEventApi(apiClient),
 	}
 }
 
 // GetDefaultClientFn returns a function that creates a KB Cloud client from request context
-func GetDefaultClientFn() GetClientFn {
+func GetDefaultClientFn(logger *mcplog.Logger) GetClientFn {
+	return newClientFactory(http.DefaultTransport, nil, nil, logger).contextClientFn("")
+}
+
+// NewStaticClientFn returns a function that creates KB Cloud clients with
+// fixed credentials and site, ignoring the request context. It is used when
+// the server is embedded or pointed at a test server.
+func NewStaticClientFn(apiKey, apiSecret, site string, logger *mcplog.Logger) GetClientFn {
+	return NewStaticClientFnWithTransport(apiKey, apiSecret, site, http.DefaultTransport, logger)
+}
+
+// NewStaticClientFnWithTransport is like NewStaticClientFn but sends requests
+// through base, e.g. to record or replay them
+func NewStaticClientFnWithTransport(apiKey, apiSecret, site string, base http.RoundTripper, logger *mcplog.Logger) GetClientFn {
+	return newClientFactory(base, nil, nil, logger).staticClientFn(apiKey, apiSecret, site)
+}
+
+// clientFactory creates KB Cloud clients sharing a base transport and
+// optional response and catalog caches
+type clientFactory struct {
+	base       http.RoundTripper
+	cache      *responseCache
+	catalog    *catalogCache
+	httpLogger *log.Entry
+}
+
+// newClientFactory creates a clientFactory; cache and catalog may be nil to
+// disable caching
+func newClientFactory(base http.RoundTripper, cache *responseCache, catalog *catalogCache, logger *mcplog.Logger) *clientFactory {
+	return &clientFactory{
+		base:       base,
+		cache:      cache,
+		catalog:    catalog,
+		httpLogger: logger.Component(mcplog.ComponentHTTP),
+	}
+}
+
+// contextClientFn returns a GetClientFn resolving credentials from the
+// request context or environment. A non-empty site overrides the site from
+// the context.
+func (f *clientFactory) contextClientFn(site string) GetClientFn {
 	return func(ctx context.Context) (*Client, error) {
 		// Extract API key and secret from the context
 		apiKey, apiSecret, ok := GetAPICredentials(ctx)
@@ -51,40 +142,65 @@ func GetDefaultClientFn() GetClientFn {
 			return nil, fmt.Errorf("KB Cloud API credentials not found in context")
 		}
 
-		// Set authentication context for KB Cloud API
-		authCtx := context.WithValue(
-			ctx,
-			common.ContextDigestAuth,
-			common.DigestAuth{
-				UserName: apiKey,
-				Password: apiSecret,
-			},
-		)
-
 		// Get site configuration if provided
-		site, hasSite := GetSiteConfiguration(ctx)
-		if hasSite {
-			authCtx = context.WithValue(
-				authCtx,
-				common.ContextServerVariables,
-				map[string]string{"site": site},
-			)
+		requestSite := site
+		if requestSite == "" {
+			requestSite, _ = GetSiteConfiguration(ctx)
 		}
 
-		// Create configuration
-		config := common.NewConfiguration()
+		return f.newClient(ctx, apiKey, apiSecret, requestSite, isDebug(ctx)), nil
+	}
+}
 
-		// Set debug mode based on context
-		if isDebug(ctx) {
-			config.Debug = true
-		}
+// staticClientFn returns a GetClientFn using fixed credentials and site
+func (f *clientFactory) staticClientFn(apiKey, apiSecret, site string) GetClientFn {
+	return func(ctx context.Context) (*Client, error) {
+		return f.newClient(ctx, apiKey, apiSecret, site, isDebug(ctx)), nil
+	}
+}
 
-		// Create API client
-		apiClient := common.NewAPIClient(config)
+// newClient creates a KB Cloud client for the given credentials and site
+func (f *clientFactory) newClient(ctx context.Context, apiKey, apiSecret, site string, debug bool) *Client {
+	apiCtx := ctx
+	if site != "" {
+		apiCtx = context.WithValue(
+			apiCtx,
+			common.ContextServerVariables,
+			map[string]string{"site": site},
+		)
+	}
 
-		// Create and return the KB Cloud client
-		return NewClient(apiClient, authCtx), nil
+	// Create configuration
+	config := common.NewConfiguration()
+	// HTTP dumps go through the logger rather than the client's Debug
+	// mode so they honor the log configuration and scrub auth headers
+	transport := newTransport(apiKey, apiSecret, mcplog.NewHTTPDebugTransport(f.base, f.httpLogger, debug))
+	if f.cache != nil {
+		// Cache hits skip instrumentation; they are counted as cache metrics instead
+		transport = &cachingTransport{next: transport, cache: f.cache, apiKey: apiKey}
 	}
+	config.HTTPClient = &http.Client{Transport: transport}
+
+	// Create API client
+	apiClient := common.NewAPIClient(config)
+
+	// Create and return the KB Cloud client
+	client := NewClient(apiClient, apiCtx)
+	client.catalog = f.catalog
+	client.identity = apiKey + "@" + site
+	return client
+}
+
+// newTransport builds the HTTP transport used for KB Cloud API calls.
+// Digest authentication is handled here rather than through the client's
+// ContextDigestAuth so that instrumentation sees one request per API call
+// instead of the 401 challenge round trip.
+func newTransport(apiKey, apiSecret string, base http.RoundTripper) http.RoundTripper {
+	return tracing.InstrumentRoundTripper(metrics.InstrumentRoundTripper(&digest.Transport{
+		Username:  apiKey,
+		Password:  apiSecret,
+		Transport: base,
+	}))
 }
 
 // GetAPICredentials extracts the KB Cloud API credentials from the context
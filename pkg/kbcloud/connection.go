@@ -0,0 +1,428 @@
+package kbcloud
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Networks an endpoint is reachable from
+const (
+	networkInternal = "internal"
+	networkExternal = "external"
+)
+
+// Placeholders left in connection strings for the values the caller fills in
+const (
+	userPlaceholder     = "<user>"
+	passwordPlaceholder = "<password>"
+)
+
+// shellSafe matches arguments that need no quoting in a POSIX shell
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_./:@=+-]+$`)
+
+// clientPorts are the port names and default port of the client protocol of
+// each engine family
+var clientPorts = map[string]struct {
+	name string
+	port int32
+}{
+	engineMySQL:      {"mysql", 3306},
+	enginePostgreSQL: {"postgresql", 5432},
+	engineRedis:      {"redis", 6379},
+	engineMongoDB:    {"mongodb", 27017},
+}
+
+// defaultDatabases are connected to when no database is given
+var defaultDatabases = map[string]string{
+	enginePostgreSQL: "postgres",
+	engineMongoDB:    "admin",
+}
+
+// connectionEndpoint is a network endpoint of an instance component
+type connectionEndpoint struct {
+	Component string   `json:"component"`
+	Network   string   `json:"network"`
+	Type      string   `json:"type"`
+	Hosts     []string `json:"hosts"`
+	Port      int32    `json:"port"`
+	PortName  string   `json:"port_name,omitempty"`
+}
+
+// connectionString is a ready-to-use command or URL for a client
+type connectionString struct {
+	Client  string `json:"client"`
+	Network string `json:"network"`
+	Value   string `json:"value"`
+}
+
+// connectionCredentials are the credentials of an account and the
+// connection strings that embed them
+type connectionCredentials struct {
+	Username          string             `json:"username"`
+	Password          string             `json:"password"`
+	ConnectionStrings []connectionString `json:"connection_strings"`
+}
+
+// connectionInfo is the result of get_connection_info. Credentials are only
+// set on request; their key keeps them out of logs.
+type connectionInfo struct {
+	InstanceRef       string                 `json:"instance_ref"`
+	Engine            string                 `json:"engine"`
+	Component         string                 `json:"component"`
+	Status            string                 `json:"status"`
+	Username          string                 `json:"username"`
+	Database          string                 `json:"database,omitempty"`
+	Endpoints         []connectionEndpoint   `json:"endpoints"`
+	ConnectionStrings []connectionString     `json:"connection_strings"`
+	Credentials       *connectionCredentials `json:"credentials,omitempty"`
+	Notices           []string               `json:"notices,omitempty"`
+}
+
+// GetConnectionInfo creates a tool to describe how to connect to an instance.
+// Passwords can only be requested with include_credentials when
+// allowCredentials; the tool is then not annotated read-only, so read-only
+// servers never return them.
+func GetConnectionInfo(getClient GetClientFn, allowCredentials bool) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	description := "Get the internal and external endpoints of an instance with ready-to-paste connection strings " +
+		"for JDBC, psql, the mysql CLI, redis-cli, mongosh and Go. Passwords are left as placeholders"
+	var credentialsOption mcp.ToolOption = func(*mcp.Tool) {}
+	if allowCredentials {
+		description += " unless include_credentials is set"
+		credentialsOption = mcp.WithBoolean("include_credentials",
+			mcp.Description("Return the password of account_name and connection strings embedding it (default false)"),
+		)
+	}
+	return mcp.NewTool("get_connection_info",
+			mcp.WithDescription(description),
+			mcp.WithReadOnlyHintAnnotation(!allowCredentials),
+			WithInstanceRef(),
+			mcp.WithString("account_name",
+				mcp.Description("Account to connect as; the connection strings use a placeholder when omitted"),
+			),
+			mcp.WithString("database",
+				mcp.Description("Database to connect to; the engine default when omitted"),
+			),
+			mcp.WithString("network",
+				mcp.Description("Only endpoints reachable from this network: internal within the environment, external from the internet"),
+				mcp.Enum(networkInternal, networkExternal),
+			),
+			mcp.WithString("component",
+				mcp.Description("Component to connect to; defaults to the main one"),
+			),
+			credentialsOption,
+			WithResponseShaping(),
+			WithOutputSchema[connectionInfo](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			ref, err := InstanceRefParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get optional parameters
+			accountName, err := OptionalParam[string](request, "account_name")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			database, err := OptionalParam[string](request, "database")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			network, err := OptionalParam[string](request, "network")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if network != "" && network != networkInternal && network != networkExternal {
+				return mcp.NewToolResultError(fmt.Sprintf("invalid network %q: must be %s or %s", network, networkInternal, networkExternal)), nil
+			}
+			componentName, err := OptionalParam[string](request, "component")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			includeCredentials, err := OptionalParam[bool](request, "include_credentials")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if includeCredentials && !allowCredentials {
+				return mcp.NewToolResultError("include_credentials is disabled on this server because it is read-only"), nil
+			}
+			if includeCredentials && accountName == "" {
+				return mcp.NewToolResultError("include_credentials requires account_name; use list_accounts to see the accounts of the instance"), nil
+			}
+
+			// Get response shaping parameters
+			shaping, err := ResponseShapingParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if includeCredentials {
+				if err := checkCredentialsShaping(request, shaping); err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			ref, instance, err := getInstance(client, ref)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+			component, err := instanceComponent(ref, instance, componentName)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			family := engineFamily(instance.Engine)
+			if database == "" {
+				database = defaultDatabases[family]
+			}
+
+			username, password := userPlaceholder, ""
+			if accountName != "" {
+				if _, err := getAccount(client, ref, instance.Engine, accountName); err != nil {
+					return lookupErrorResult(err)
+				}
+				username = accountName
+			}
+			if includeCredentials {
+				if password, err = accountPassword(client, ref, instance.Engine, accountName); err != nil {
+					return nil, err
+				}
+				if password == "" {
+					return mcp.NewToolResultError(fmt.Sprintf("KB Cloud did not return the password of account %q on %s; use reset_account_password to set a new one", accountName, ref)), nil
+				}
+			}
+
+			// Call KB Cloud API
+			endpoints, resp, err := client.Cluster.ListEndpoints(client.Context, ref.Org, ref.Instance)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list endpoints: %w", err)
+			}
+			_ = resp.Body.Close()
+
+			info := connectionInfo{
+				InstanceRef:       ref.String(),
+				Engine:            instance.Engine,
+				Component:         component,
+				Status:            instance.GetStatus(),
+				Username:          username,
+				Database:          database,
+				Endpoints:         []connectionEndpoint{},
+				ConnectionStrings: []connectionString{},
+			}
+			if includeCredentials {
+				info.Credentials = &connectionCredentials{Username: username, Password: password, ConnectionStrings: []connectionString{}}
+			}
+			external := false
+			for _, e := range endpoints.Items {
+				endpoint := newConnectionEndpoint(e)
+				if (e.Component != "" && e.Component != component) || (network != "" && endpoint.Network != network) {
+					continue
+				}
+				info.Endpoints = append(info.Endpoints, endpoint)
+				if !isClientEndpoint(family, e) || len(e.Hosts) == 0 {
+					continue
+				}
+				external = external || endpoint.Network == networkExternal
+				info.ConnectionStrings = append(info.ConnectionStrings,
+					connectionStrings(family, endpoint.Network, e.Hosts[0], e.Port, username, "", database)...)
+				if info.Credentials != nil {
+					info.Credentials.ConnectionStrings = append(info.Credentials.ConnectionStrings,
+						connectionStrings(family, endpoint.Network, e.Hosts[0], e.Port, username, password, database)...)
+				}
+			}
+
+			if info.Status != "" && info.Status != "Running" {
+				info.Notices = append(info.Notices, fmt.Sprintf("%s is %s; connections fail until it is Running", ref, info.Status))
+			}
+			switch {
+			case len(info.Endpoints) == 0:
+				info.Notices = append(info.Notices, fmt.Sprintf("%s has no matching endpoints", ref))
+			case family == "":
+				info.Notices = append(info.Notices, fmt.Sprintf("no connection strings are known for engine %s", instance.Engine))
+			case !external && network != networkInternal:
+				info.Notices = append(info.Notices, fmt.Sprintf("%s has no public endpoint, so it is only reachable from within environment %s", ref, ref.Env))
+			}
+			if includeCredentials {
+				info.Notices = append(info.Notices, passwordNotice)
+			}
+
+			// Return result. Credentials are always JSON: log redaction finds
+			// the password key there, and would miss it in other formats.
+			if includeCredentials {
+				return renderResult(FormatJSON, info, shaping.Fields)
+			}
+			return shaping.result(resourceConnection, info)
+		}
+}
+
+// checkCredentialsShaping refuses shaping that would render credentials in a
+// format log redaction does not parse, or drop them from the result
+func checkCredentialsShaping(r mcp.CallToolRequest, shaping ResponseShaping) error {
+	format, err := OptionalParam[string](r, "format")
+	if err != nil {
+		return err
+	}
+	if format != "" && format != FormatJSON {
+		return fmt.Errorf("include_credentials is only returned as %s; drop format or set it to %s", FormatJSON, FormatJSON)
+	}
+	if shaping.View == ViewSummary {
+		return fmt.Errorf("include_credentials cannot be combined with view %s, which leaves out the credentials", ViewSummary)
+	}
+	if len(shaping.Fields) == 0 {
+		return nil
+	}
+	for _, path := range shaping.Fields {
+		if path == "credentials" || strings.HasPrefix(path, "credentials.") {
+			return nil
+		}
+	}
+	return fmt.Errorf("include_credentials requires fields to select credentials, or no fields at all")
+}
+
+// newConnectionEndpoint describes a KB Cloud endpoint
+func newConnectionEndpoint(e kbcloud.Endpoint) connectionEndpoint {
+	network := networkInternal
+	if e.NetworkType == kbcloud.EndpointNetworkTypeInternet {
+		network = networkExternal
+	}
+	return connectionEndpoint{
+		Component: e.Component,
+		Network:   network,
+		Type:      string(e.Type),
+		Hosts:     append([]string{}, e.Hosts...),
+		Port:      e.Port,
+		PortName:  e.PortName,
+	}
+}
+
+// isClientEndpoint reports whether an endpoint serves the client protocol of
+// an engine family rather than, say, metrics
+func isClientEndpoint(family string, e kbcloud.Endpoint) bool {
+	client, ok := clientPorts[family]
+	if !ok {
+		return false
+	}
+	return e.Port == client.port || strings.Contains(strings.ToLower(e.PortName), client.name)
+}
+
+// accountPassword returns the password of an account, or "" when KB Cloud
+// does not return it
+func accountPassword(client *Client, ref InstanceRef, engine, name string) (string, error) {
+	accounts, resp, err := client.Account.ListAccounts(client.Context, engine, ref.Org, ref.Instance)
+	if err != nil {
+		return "", fmt.Errorf("failed to list accounts: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	for _, account := range accounts {
+		if account.Name == name {
+			return account.GetPassword(), nil
+		}
+	}
+	return "", nil
+}
+
+// connectionStrings returns the connection strings of the clients of an
+// engine family for an endpoint. Without a password, command line clients
+// prompt for it and the others carry a placeholder.
+func connectionStrings(family, network, host string, port int32, user, password, database string) []connectionString {
+	address := host + ":" + strconv.Itoa(int(port))
+	prompt := password == ""
+	if prompt {
+		password = passwordPlaceholder
+	}
+
+	var strs [][2]string
+	switch family {
+	case engineMySQL:
+		cli := fmt.Sprintf("mysql -h %s -P %d -u %s -p", shellQuote(host), port, shellQuote(user))
+		if !prompt {
+			cli += shellQuote(password)
+		}
+		if database != "" {
+			cli += " " + shellQuote(database)
+		}
+		strs = [][2]string{
+			{"mysql", cli},
+			{"jdbc", fmt.Sprintf("jdbc:mysql://%s/%s?user=%s&password=%s", address, database, queryEscape(user), queryEscape(password))},
+			{"go", fmt.Sprintf("%s:%s@tcp(%s)/%s", user, password, address, database)},
+		}
+	case enginePostgreSQL:
+		cli := "postgresql://" + userInfo(user, password, prompt) + "@" + address + "/" + database
+		strs = [][2]string{
+			{"psql", "psql " + shellQuote(cli)},
+			{"jdbc", fmt.Sprintf("jdbc:postgresql://%s/%s?user=%s&password=%s", address, database, queryEscape(user), queryEscape(password))},
+			{"go", "postgres://" + userInfo(user, password, false) + "@" + address + "/" + database},
+		}
+	case engineRedis:
+		cli := fmt.Sprintf("redis-cli -h %s -p %d --user %s", shellQuote(host), port, shellQuote(user))
+		if prompt {
+			cli += " --askpass"
+		} else {
+			cli += " --pass " + shellQuote(password)
+		}
+		strs = [][2]string{
+			{"redis-cli", cli},
+			{"go", "redis://" + userInfo(user, password, false) + "@" + address + "/0"},
+		}
+	case engineMongoDB:
+		query := "/" + database + "?authSource=admin"
+		strs = [][2]string{
+			{"mongosh", "mongosh " + shellQuote("mongodb://"+userInfo(user, password, prompt)+"@"+address+query)},
+			{"go", "mongodb://" + userInfo(user, password, false) + "@" + address + query},
+		}
+	}
+
+	result := make([]connectionString, 0, len(strs))
+	for _, s := range strs {
+		result = append(result, connectionString{Client: s[0], Network: network, Value: s[1]})
+	}
+	return result
+}
+
+// userInfo returns the user information of a URL, leaving out the password
+// when the client prompts for it. Placeholders are not escaped.
+func userInfo(user, password string, prompt bool) string {
+	info := pathEscape(user)
+	if !prompt {
+		info += ":" + pathEscape(password)
+	}
+	return info
+}
+
+// pathEscape escapes s for the user information of a URL
+func pathEscape(s string) string {
+	if s == userPlaceholder || s == passwordPlaceholder {
+		return s
+	}
+	return url.PathEscape(s)
+}
+
+// queryEscape escapes s for a URL query
+func queryEscape(s string) string {
+	if s == userPlaceholder || s == passwordPlaceholder {
+		return s
+	}
+	return url.QueryEscape(s)
+}
+
+// shellQuote quotes s for a POSIX shell when it needs quoting
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,285 @@
+package kbcloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Context sources reported to clients
+const (
+	contextSourceSession = "session"
+	contextSourceDefault = "server default"
+)
+
+// ContextStore holds the current organization and environment of each MCP
+// session, on top of server-wide defaults
+type ContextStore struct {
+	defaults sessionContext
+
+	mu       sync.Mutex
+	sessions map[string]sessionContext
+}
+
+// sessionContext is an organization and an environment within it
+type sessionContext struct {
+	Org string
+	Env string
+}
+
+// appliedContext is the context in effect for a tool call
+type appliedContext struct {
+	OrgName   string `json:"org_name,omitempty"`
+	OrgSource string `json:"org_source,omitempty"`
+	EnvName   string `json:"env_name,omitempty"`
+	EnvSource string `json:"env_source,omitempty"`
+}
+
+// NewContextStore creates a store whose sessions start in defaultOrg and
+// defaultEnv; either may be empty
+func NewContextStore(defaultOrg, defaultEnv string) *ContextStore {
+	return &ContextStore{
+		defaults: sessionContext{Org: defaultOrg, Env: defaultEnv},
+		sessions: map[string]sessionContext{},
+	}
+}
+
+// current returns the context in effect for the session of ctx. A session
+// environment or default environment only applies within its organization.
+func (s *ContextStore) current(ctx context.Context) appliedContext {
+	s.mu.Lock()
+	session, ok := s.sessions[sessionID(ctx)]
+	s.mu.Unlock()
+
+	var current appliedContext
+	if ok && session.Org != "" {
+		current.OrgName, current.OrgSource = session.Org, contextSourceSession
+	} else if s.defaults.Org != "" {
+		current.OrgName, current.OrgSource = s.defaults.Org, contextSourceDefault
+	}
+
+	switch {
+	case ok && session.Env != "":
+		current.EnvName, current.EnvSource = session.Env, contextSourceSession
+	case s.defaults.Env != "" && s.defaults.Org == current.OrgName:
+		current.EnvName, current.EnvSource = s.defaults.Env, contextSourceDefault
+	}
+	return current
+}
+
+// set stores the context of the session of ctx
+func (s *ContextStore) set(ctx context.Context, c sessionContext) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[sessionID(ctx)] = c
+}
+
+// clear resets the session of ctx to the server defaults
+func (s *ContextStore) clear(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, sessionID(ctx))
+}
+
+// sessionID identifies the MCP session of ctx; calls outside a session
+// share one context
+func sessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
+
+// toolContextKey is the context key of a tool call's toolContext
+type toolContextKey struct{}
+
+// toolContext is the session context available to a tool call, and the
+// parameters that were filled from it
+type toolContext struct {
+	current appliedContext
+
+	mu      sync.Mutex
+	applied []string
+}
+
+// record notes that a parameter was filled from the context
+func (c *toolContext) record(param, value, source string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.applied = append(c.applied, fmt.Sprintf("%s=%s (%s)", param, value, source))
+}
+
+// WrapHandler makes the session context available to a tool handler. When
+// the handler falls back to the context for a parameter, a note saying so
+// is appended to its result.
+func (s *ContextStore) WrapHandler(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		tc := &toolContext{current: s.current(ctx)}
+		result, err := next(context.WithValue(ctx, toolContextKey{}, tc), request)
+
+		tc.mu.Lock()
+		defer tc.mu.Unlock()
+		if result != nil && len(tc.applied) > 0 {
+			result.Content = append(result.Content, mcp.NewTextContent("Context applied: "+strings.Join(tc.applied, ", ")))
+		}
+		return result, err
+	}
+}
+
+// orgParam returns the organization parameter p, falling back to the
+// organization of the current context
+func orgParam(ctx context.Context, r mcp.CallToolRequest, p string) (string, error) {
+	org, err := OptionalParam[string](r, p)
+	if err != nil || org != "" {
+		return org, err
+	}
+	if tc, ok := ctx.Value(toolContextKey{}).(*toolContext); ok && tc.current.OrgName != "" {
+		tc.record(p, tc.current.OrgName, tc.current.OrgSource)
+		return tc.current.OrgName, nil
+	}
+	return "", fmt.Errorf("missing required parameter: %s (or set a default organization with set_context)", p)
+}
+
+// envParam returns the env_name parameter, falling back to the environment
+// of the current context when org is the current organization
+func envParam(ctx context.Context, r mcp.CallToolRequest, org string) (string, error) {
+	env, err := OptionalParam[string](r, "env_name")
+	if err != nil || env != "" {
+		return env, err
+	}
+	if tc, ok := ctx.Value(toolContextKey{}).(*toolContext); ok && tc.current.EnvName != "" && tc.current.OrgName == org {
+		tc.record("env_name", tc.current.EnvName, tc.current.EnvSource)
+		return tc.current.EnvName, nil
+	}
+	return "", fmt.Errorf("missing required parameter: env_name (or set a default environment with set_context)")
+}
+
+// SetContext creates a tool to set the current organization and environment of the session
+func SetContext(getClient GetClientFn, store *ContextStore) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("set_context",
+			mcp.WithDescription("Set the current organization and environment for this session. Tools use them when org_name or env_name is omitted"),
+			mcp.WithString("org_name",
+				mcp.Description("Organization to use by default; keeps the current organization when omitted"),
+			),
+			mcp.WithString("env_name",
+				mcp.Description("Environment to use by default, within the organization"),
+			),
+			mcp.WithBoolean("clear",
+				mcp.Description("Reset the session to the server defaults"),
+			),
+			WithOutputSchema[appliedContext](),
+			// Only the session state changes, never KB Cloud resources
+			mcp.WithReadOnlyHintAnnotation(true),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get optional parameters
+			orgName, err := OptionalParam[string](request, "org_name")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			envName, err := OptionalParam[string](request, "env_name")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			reset, err := OptionalParam[bool](request, "clear")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			if reset {
+				if orgName != "" || envName != "" {
+					return mcp.NewToolResultError("clear cannot be combined with org_name or env_name"), nil
+				}
+				store.clear(ctx)
+				return marshalContext(store.current(ctx))
+			}
+			if orgName == "" && envName == "" {
+				return mcp.NewToolResultError("missing required parameter: org_name or env_name"), nil
+			}
+
+			// An environment alone is set within the current organization
+			if orgName == "" {
+				orgName = store.current(ctx).OrgName
+				if orgName == "" {
+					return mcp.NewToolResultError("missing required parameter: org_name (no organization is set)"), nil
+				}
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			// Make sure the context exists before storing it
+			if err := checkContext(client, orgName, envName); err != nil {
+				return lookupErrorResult(err)
+			}
+
+			store.set(ctx, sessionContext{Org: orgName, Env: envName})
+			return marshalContext(store.current(ctx))
+		}
+}
+
+// GetContext creates a tool to show the current organization and environment of the session
+func GetContext(store *ContextStore) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("get_context",
+			mcp.WithDescription("Show the current organization and environment for this session and where they come from"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			WithOutputSchema[appliedContext](),
+		),
+		func(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return marshalContext(store.current(ctx))
+		}
+}
+
+// marshalContext returns a context as a tool result
+func marshalContext(c appliedContext) (*mcp.CallToolResult, error) {
+	result, err := json.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response: %w", err)
+	}
+	return mcp.NewToolResultStructured(c, string(result)), nil
+}
+
+// checkContext verifies that org exists and, when set, that env exists in it
+func checkContext(client *Client, org, env string) error {
+	orgs, err := listOrgNames(client)
+	if err != nil {
+		return err
+	}
+	if !contains(orgs, org) {
+		return orgNotFoundError(org, orgs)
+	}
+	if env == "" {
+		return nil
+	}
+
+	envs, resp, err := client.Environment.ListEnvironment(client.Context, org)
+	if err != nil {
+		return fmt.Errorf("failed to list environments: %w", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to list environments: status %d", resp.StatusCode)
+	}
+
+	var names []string
+	for _, e := range envs.Items {
+		names = append(names, e.Name)
+	}
+	if contains(names, env) {
+		return nil
+	}
+	message := fmt.Sprintf("environment %q not found in organization %q", env, org)
+	if suggestions := suggest(env, names); len(suggestions) > 0 {
+		return &lookupError{fmt.Sprintf("%s; did you mean %s?", message, strings.Join(suggestions, ", "))}
+	}
+	return &lookupError{fmt.Sprintf("%s; available environments: %s", message, strings.Join(names, ", "))}
+}
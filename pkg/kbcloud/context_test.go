@@ -0,0 +1,160 @@
+package kbcloud_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud/kbcloudtest"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/translations"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSession is an MCP client session identified by id
+type fakeSession struct {
+	id string
+}
+
+func (s fakeSession) SessionID() string                                   { return s.id }
+func (s fakeSession) Initialize()                                         {}
+func (s fakeSession) Initialized() bool                                   { return true }
+func (s fakeSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return nil }
+
+// contextNote returns the context note appended to a result, if any
+func contextNote(result *mcp.CallToolResult) string {
+	if len(result.Content) < 2 {
+		return ""
+	}
+	text, _ := result.Content[len(result.Content)-1].(mcp.TextContent)
+	return text.Text
+}
+
+func TestSessionContext(t *testing.T) {
+	kb := kbcloudtest.NewServer(t)
+	s, err := kbcloud.NewServer(kbcloud.ServerOptions{
+		GetClient:  kb.GetClientFn(),
+		DefaultOrg: "acme",
+		DefaultEnv: "prod",
+		Translator: translations.NullTranslationHelper,
+	})
+	require.NoError(t, err)
+
+	// call invokes a tool within the session named id
+	call := func(t *testing.T, id, tool string, args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		ctx := s.WithContext(context.Background(), fakeSession{id: id})
+		result, err := s.GetTool(tool).Handler(ctx, callRequest(args))
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("Server defaults", func(t *testing.T) {
+		result := call(t, "a", "list_instances", nil)
+		require.False(t, result.IsError, resultText(t, result))
+		assert.Contains(t, resultText(t, result), `"name":"orders-db"`)
+		assert.Equal(t, "Context applied: org_name=acme (server default), env_name=prod (server default)", contextNote(result))
+
+		result = call(t, "a", "get_context", nil)
+		assert.JSONEq(t, `{"org_name":"acme","org_source":"server default","env_name":"prod","env_source":"server default"}`, resultText(t, result))
+	})
+
+	t.Run("Explicit parameters win", func(t *testing.T) {
+		result := call(t, "a", "list_instances", map[string]any{"org_name": "globex", "env_name": "dev"})
+		assert.Contains(t, resultText(t, result), `"name":"inventory"`)
+		assert.Empty(t, contextNote(result))
+	})
+
+	t.Run("Default environment only applies to the default organization", func(t *testing.T) {
+		result := call(t, "a", "list_instances", map[string]any{"org_name": "globex"})
+		assert.True(t, result.IsError)
+		assert.Contains(t, resultText(t, result), "missing required parameter: env_name")
+	})
+
+	t.Run("Set organization and environment", func(t *testing.T) {
+		result := call(t, "b", "set_context", map[string]any{"org_name": "globex", "env_name": "dev"})
+		require.False(t, result.IsError, resultText(t, result))
+		assert.JSONEq(t, `{"org_name":"globex","org_source":"session","env_name":"dev","env_source":"session"}`, resultText(t, result))
+
+		result = call(t, "b", "get_instance", map[string]any{"instance_name": "inventory"})
+		require.False(t, result.IsError, resultText(t, result))
+		assert.Equal(t, "Context applied: org_name=globex (session), env_name=dev (session)", contextNote(result))
+
+		// Other sessions keep the server defaults
+		result = call(t, "a", "get_context", nil)
+		assert.Contains(t, resultText(t, result), `"org_name":"acme"`)
+	})
+
+	t.Run("Set environment within the current organization", func(t *testing.T) {
+		result := call(t, "c", "set_context", map[string]any{"env_name": "staging"})
+		require.False(t, result.IsError, resultText(t, result))
+		assert.JSONEq(t, `{"org_name":"acme","org_source":"session","env_name":"staging","env_source":"session"}`, resultText(t, result))
+	})
+
+	t.Run("Organization alone drops the default environment", func(t *testing.T) {
+		result := call(t, "d", "set_context", map[string]any{"org_name": "globex"})
+		require.False(t, result.IsError, resultText(t, result))
+		assert.JSONEq(t, `{"org_name":"globex","org_source":"session"}`, resultText(t, result))
+
+		result = call(t, "d", "list_environments", nil)
+		assert.Contains(t, resultText(t, result), `"name":"dev"`)
+		assert.Equal(t, "Context applied: org_name=globex (session)", contextNote(result))
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		call(t, "e", "set_context", map[string]any{"org_name": "globex"})
+		result := call(t, "e", "set_context", map[string]any{"clear": true})
+		assert.JSONEq(t, `{"org_name":"acme","org_source":"server default","env_name":"prod","env_source":"server default"}`, resultText(t, result))
+	})
+
+	t.Run("Invalid context", func(t *testing.T) {
+		tests := []struct {
+			name string
+			args map[string]any
+			want string
+		}{
+			{name: "misspelled organization", args: map[string]any{"org_name": "globx"}, want: `organization "globx" not found; did you mean globex?`},
+			{name: "misspelled environment", args: map[string]any{"org_name": "acme", "env_name": "stagin"}, want: `environment "stagin" not found in organization "acme"; did you mean staging?`},
+			{name: "nothing to set", args: nil, want: "missing required parameter: org_name or env_name"},
+			{name: "clear with values", args: map[string]any{"clear": true, "org_name": "acme"}, want: "clear cannot be combined"},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				result := call(t, "f", "set_context", tc.args)
+				assert.True(t, result.IsError)
+				assert.Contains(t, resultText(t, result), tc.want)
+			})
+		}
+
+		// Failed updates leave the context unchanged
+		assert.Contains(t, resultText(t, call(t, "f", "get_context", nil)), `"org_name":"acme"`)
+	})
+}
+
+func TestNoContext(t *testing.T) {
+	kb := kbcloudtest.NewServer(t)
+	s, err := kbcloud.NewServer(kbcloud.ServerOptions{
+		GetClient:  kb.GetClientFn(),
+		Translator: translations.NullTranslationHelper,
+	})
+	require.NoError(t, err)
+
+	result, err := callTool(s.GetTool("list_environments").Handler, nil)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, resultText(t, result), "missing required parameter: org_name (or set a default organization with set_context)")
+
+	result, err = callTool(s.GetTool("get_context").Handler, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "{}", resultText(t, result))
+
+	// Calls outside an MCP session share one context
+	result, err = callTool(s.GetTool("set_context").Handler, map[string]any{"org_name": "acme", "env_name": "prod"})
+	require.NoError(t, err)
+	require.False(t, result.IsError, resultText(t, result))
+
+	result, err = callTool(s.GetTool("list_instances").Handler, nil)
+	require.NoError(t, err)
+	assert.Contains(t, resultText(t, result), `"name":"cache"`)
+}
@@ -0,0 +1,340 @@
+package kbcloud
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// databaseNamePattern matches database names valid on MySQL and PostgreSQL
+// without quoting
+var databaseNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,63}$`)
+
+// databaseCharsets are the character sets accepted on each relational engine
+// family, in the case the engine reports them
+var databaseCharsets = map[string][]string{
+	engineMySQL: {"utf8mb4", "utf8mb3", "utf8", "latin1", "ascii", "binary", "gbk", "gb18030", "big5", "utf16", "utf32", "ucs2"},
+	enginePostgreSQL: {
+		"UTF8", "SQL_ASCII", "LATIN1", "LATIN2", "LATIN9", "WIN1250", "WIN1251", "WIN1252",
+		"EUC_CN", "EUC_JP", "EUC_KR", "EUC_TW", "KOI8R",
+	},
+}
+
+// systemDatabases are created and used by the engine itself and are never
+// created or dropped by tools
+var systemDatabases = map[string][]string{
+	engineMySQL:      {"information_schema", "mysql", "performance_schema", "sys"},
+	enginePostgreSQL: {"postgres", "template0", "template1"},
+}
+
+// databaseResult is the result of the tools that change a database
+type databaseResult struct {
+	InstanceRef  string `json:"instance_ref"`
+	DatabaseName string `json:"database_name"`
+	Charset      string `json:"charset,omitempty"`
+	Owner        string `json:"owner,omitempty"`
+	Created      bool   `json:"created,omitempty"`
+	Dropped      bool   `json:"dropped,omitempty"`
+}
+
+// ListDatabases creates a tool to list the logical databases of an instance
+func ListDatabases(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("list_databases",
+			mcp.WithDescription("List the logical databases of a MySQL or PostgreSQL instance"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			WithInstanceRef(),
+			WithResponseShaping(),
+			WithOutputSchema[kbcloud.DatabaseList](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			ref, err := InstanceRefParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get response shaping parameters
+			shaping, err := ResponseShapingParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			ref, instance, err := getInstance(client, ref)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+			if err := checkRelational(ref, instance.Engine); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Call KB Cloud API
+			databases, err := listDatabases(client, ref, instance.Engine)
+			if err != nil {
+				return nil, err
+			}
+
+			// Return result
+			return shaping.result(resourceDatabase, databases)
+		}
+}
+
+// CreateDatabase creates a tool to create a logical database on an instance
+func CreateDatabase(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("create_database",
+			mcp.WithDescription("Create a logical database on a MySQL or PostgreSQL instance"),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			WithInstanceRef(),
+			mcp.WithString("database_name",
+				mcp.Required(),
+				mcp.Description("Database name: a letter or underscore followed by up to 63 letters, digits or underscores"),
+			),
+			mcp.WithString("charset",
+				mcp.Description("Character set, e.g. utf8mb4 on MySQL or UTF8 on PostgreSQL; the engine default when omitted"),
+			),
+			mcp.WithString("owner",
+				mcp.Description("Existing account that owns and manages the database"),
+			),
+			mcp.WithString("description",
+				mcp.Description("Description of the database"),
+			),
+			mcp.WithString("component",
+				mcp.Description("Component to create the database in; defaults to the first one"),
+			),
+			WithOutputSchema[databaseResult](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			ref, err := InstanceRefParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			name, err := RequiredParam[string](request, "database_name")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if !databaseNamePattern.MatchString(name) {
+				return mcp.NewToolResultError(fmt.Sprintf("invalid database_name %q: use a letter or underscore followed by up to 63 letters, digits or underscores", name)), nil
+			}
+
+			// Get optional parameters
+			charset, err := OptionalParam[string](request, "charset")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			owner, err := OptionalParam[string](request, "owner")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			description, err := OptionalParam[string](request, "description")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			component, err := OptionalParam[string](request, "component")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			ref, instance, err := getInstance(client, ref)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+			if err := checkRelational(ref, instance.Engine); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			family := engineFamily(instance.Engine)
+			if contains(systemDatabases[family], name) {
+				return mcp.NewToolResultError(fmt.Sprintf("%q is a system database of %s", name, instance.Engine)), nil
+			}
+			if charset, err = databaseCharset(family, charset); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Database names are unique within the instance
+			databases, err := listDatabases(client, ref, instance.Engine)
+			if err != nil {
+				return nil, err
+			}
+			for _, database := range databases.Items {
+				if database.Name == name {
+					return mcp.NewToolResultError(fmt.Sprintf("database %q already exists on %s", name, ref)), nil
+				}
+			}
+			if owner != "" {
+				if _, err := getAccount(client, ref, instance.Engine, owner); err != nil {
+					return lookupErrorResult(err)
+				}
+			}
+
+			// Call KB Cloud API
+			body := kbcloud.NewDatabase(name)
+			if charset != "" {
+				body.SetCharset(charset)
+			}
+			if owner != "" {
+				body.SetAccountName(owner)
+			}
+			if description != "" {
+				body.SetDescription(description)
+			}
+			if component != "" {
+				body.SetComponent(component)
+			}
+			resp, err := client.Database.CreateDatabase(client.Context, instance.Engine, ref.Org, ref.Instance, *body)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create database: %w", err)
+			}
+			_ = resp.Body.Close()
+
+			// Return result
+			return renderResult(FormatJSON, databaseResult{
+				InstanceRef:  ref.String(),
+				DatabaseName: name,
+				Charset:      charset,
+				Owner:        owner,
+				Created:      true,
+			}, nil)
+		}
+}
+
+// DropDatabase creates a tool to drop a logical database
+func DropDatabase(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("drop_database",
+			mcp.WithDescription("Drop a logical database and all of its data from a MySQL or PostgreSQL instance. This cannot be undone: confirm must repeat the database name"),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(true),
+			WithInstanceRef(),
+			mcp.WithString("database_name",
+				mcp.Required(),
+				mcp.Description("Database name"),
+			),
+			WithConfirmation("database"),
+			WithOutputSchema[databaseResult](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			ref, err := InstanceRefParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			name, err := RequiredParam[string](request, "database_name")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if err := ConfirmParam(request, "database", name); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			ref, instance, err := getInstance(client, ref)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+			if err := checkRelational(ref, instance.Engine); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if contains(systemDatabases[engineFamily(instance.Engine)], name) {
+				return mcp.NewToolResultError(fmt.Sprintf("%q is a system database of %s and cannot be dropped", name, instance.Engine)), nil
+			}
+
+			// Only drop a database that exists, suggesting similar names otherwise
+			databases, err := listDatabases(client, ref, instance.Engine)
+			if err != nil {
+				return nil, err
+			}
+			if err := findDatabase(ref, databases, name); err != nil {
+				return lookupErrorResult(err)
+			}
+
+			// Call KB Cloud API
+			resp, err := client.Database.DeleteDatabase(client.Context, instance.Engine, ref.Org, ref.Instance, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to drop database: %w", err)
+			}
+			_ = resp.Body.Close()
+
+			// Return result
+			return renderResult(FormatJSON, databaseResult{
+				InstanceRef:  ref.String(),
+				DatabaseName: name,
+				Dropped:      true,
+			}, nil)
+		}
+}
+
+// checkRelational reports an instance whose engine has no logical databases
+func checkRelational(ref InstanceRef, engine string) error {
+	switch engineFamily(engine) {
+	case engineMySQL, enginePostgreSQL:
+		return nil
+	default:
+		return fmt.Errorf("%s runs %s; logical databases are only managed on MySQL and PostgreSQL instances", ref, engine)
+	}
+}
+
+// databaseCharset validates a character set for an engine family,
+// returning it as the engine spells it
+func databaseCharset(family, charset string) (string, error) {
+	charsets := databaseCharsets[family]
+	if family == enginePostgreSQL {
+		charset = strings.ToUpper(charset)
+	} else {
+		charset = strings.ToLower(charset)
+	}
+	if charset != "" && !contains(charsets, charset) {
+		return "", fmt.Errorf("invalid charset %q: must be one of %s", charset, strings.Join(charsets, ", "))
+	}
+	return charset, nil
+}
+
+// listDatabases returns the databases of an instance sorted by name
+func listDatabases(client *Client, ref InstanceRef, engine string) (kbcloud.DatabaseList, error) {
+	databases, resp, err := client.Database.ListDatabases(client.Context, engine, ref.Org, ref.Instance)
+	if err != nil {
+		return kbcloud.DatabaseList{}, fmt.Errorf("failed to list databases: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	sort.Slice(databases.Items, func(i, j int) bool { return databases.Items[i].Name < databases.Items[j].Name })
+	return databases, nil
+}
+
+// findDatabase returns a lookupError unless databases includes name
+func findDatabase(ref InstanceRef, databases kbcloud.DatabaseList, name string) error {
+	var names []string
+	for _, database := range databases.Items {
+		if database.Name == name {
+			return nil
+		}
+		names = append(names, database.Name)
+	}
+
+	message := fmt.Sprintf("database %q not found on %s", name, ref)
+	if suggestions := suggest(name, names); len(suggestions) > 0 {
+		return &lookupError{fmt.Sprintf("%s; did you mean %s?", message, strings.Join(suggestions, ", "))}
+	}
+	return &lookupError{message + "; use list_databases to see its databases"}
+}
@@ -0,0 +1,161 @@
+package kbcloud_test
+
+import (
+	"net/http"
+	"testing"
+
+	client "github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud/kbcloudtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListDatabases(t *testing.T) {
+	runToolTests(t, kbcloud.ListDatabases, []toolTest{
+		{
+			name: "databases of an instance",
+			args: map[string]any{"instance_ref": "acme/staging/analytics"},
+			check: func(t *testing.T, text string) {
+				databases := decode[client.DatabaseList](t, text)
+				require.Len(t, databases.Items, 2)
+				assert.Equal(t, "analytics", databases.Items[0].Name)
+				assert.Equal(t, "events", databases.Items[1].Name)
+			},
+		},
+		{
+			name: "KB Cloud error",
+			args: map[string]any{"org_name": "acme", "env_name": "prod", "instance_name": "orders-db"},
+			setup: func(s *kbcloudtest.Server) {
+				s.InjectError(http.MethodGet, "/api/v1/data/mysql/organizations/acme/clusters/orders-db/databases", http.StatusServiceUnavailable, "unavailable")
+			},
+			wantErr: "failed to list databases",
+		},
+		{
+			name:        "non-relational engine",
+			args:        map[string]any{"instance_ref": "acme/prod/cache"},
+			wantToolErr: "only managed on MySQL and PostgreSQL instances",
+		},
+	})
+}
+
+func TestCreateDatabase(t *testing.T) {
+	runToolTests(t, kbcloud.CreateDatabase, []toolTest{
+		{
+			name: "mysql database",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "database_name": "billing", "charset": "UTF8MB4", "owner": "app_orders"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"instance_ref":"acme/prod/orders-db","database_name":"billing","charset":"utf8mb4","owner":"app_orders","created":true}`, text)
+			},
+		},
+		{
+			name: "postgresql database",
+			args: map[string]any{"instance_ref": "acme/staging/analytics", "database_name": "reports", "charset": "utf8", "owner": "postgres"},
+			check: func(t *testing.T, text string) {
+				assert.Contains(t, text, `"charset":"UTF8"`)
+			},
+		},
+		{
+			name:        "existing database",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "database_name": "orders"},
+			wantToolErr: `database "orders" already exists`,
+		},
+		{
+			name:        "system database",
+			args:        map[string]any{"instance_ref": "acme/staging/analytics", "database_name": "template1"},
+			wantToolErr: "system database",
+		},
+		{
+			name:        "invalid name",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "database_name": "order-history"},
+			wantToolErr: "invalid database_name",
+		},
+		{
+			name:        "unknown charset",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "database_name": "billing", "charset": "klingon"},
+			wantToolErr: "invalid charset",
+		},
+		{
+			name:        "unknown owner",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "database_name": "billing", "owner": "app_order"},
+			wantToolErr: "did you mean app_orders?",
+		},
+		{
+			name:        "non-relational engine",
+			args:        map[string]any{"instance_ref": "globex/dev/inventory", "database_name": "billing"},
+			wantToolErr: "runs mongodb",
+		},
+	})
+
+	t.Run("creates the database", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		_, handler := kbcloud.CreateDatabase(s.GetClientFn())
+
+		result, err := callTool(handler, map[string]any{"instance_ref": "acme/prod/orders-db", "database_name": "billing", "charset": "utf8mb4", "owner": "app_orders", "description": "Invoices"})
+		require.NoError(t, err)
+		require.False(t, result.IsError, resultText(t, result))
+
+		database, ok := s.Database("acme", "orders-db", "billing")
+		require.True(t, ok)
+		assert.Equal(t, "utf8mb4", database.GetCharset())
+		assert.Empty(t, database.AdditionalProperties)
+		assert.Equal(t, "app_orders", database.GetAccountName())
+		assert.Equal(t, "Invoices", database.GetDescription())
+	})
+}
+
+func TestDropDatabase(t *testing.T) {
+	runToolTests(t, kbcloud.DropDatabase, []toolTest{
+		{
+			name: "confirmed",
+			args: map[string]any{"instance_ref": "acme/staging/analytics", "database_name": "events", "confirm": "events"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"instance_ref":"acme/staging/analytics","database_name":"events","dropped":true}`, text)
+			},
+		},
+		{
+			name:        "unconfirmed",
+			args:        map[string]any{"instance_ref": "acme/staging/analytics", "database_name": "events"},
+			wantToolErr: `call again with confirm set to "events"`,
+		},
+		{
+			name:        "confirmation of another database",
+			args:        map[string]any{"instance_ref": "acme/staging/analytics", "database_name": "events", "confirm": "analytics"},
+			wantToolErr: `confirm "analytics" does not match database "events"; nothing was removed`,
+		},
+		{
+			name:        "system database",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "database_name": "mysql", "confirm": "mysql"},
+			wantToolErr: "cannot be dropped",
+		},
+		{
+			name:        "misspelled database",
+			args:        map[string]any{"instance_ref": "acme/staging/analytics", "database_name": "event", "confirm": "event"},
+			wantToolErr: "did you mean events?",
+		},
+	})
+
+	t.Run("drops only the named database", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		_, handler := kbcloud.DropDatabase(s.GetClientFn())
+
+		result, err := callTool(handler, map[string]any{"instance_ref": "acme/staging/analytics", "database_name": "events", "confirm": "events"})
+		require.NoError(t, err)
+		require.False(t, result.IsError, resultText(t, result))
+
+		_, ok := s.Database("acme", "analytics", "events")
+		assert.False(t, ok)
+		_, ok = s.Database("acme", "analytics", "analytics")
+		assert.True(t, ok)
+	})
+
+	t.Run("unconfirmed calls never reach KB Cloud", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		_, handler := kbcloud.DropDatabase(s.GetClientFn())
+
+		result, err := callTool(handler, map[string]any{"instance_ref": "acme/staging/analytics", "database_name": "events", "confirm": "event"})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Empty(t, s.Requests())
+	})
+}
@@ -0,0 +1,59 @@
+package kbcloud
+
+import (
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// WithConfirmation adds the confirm parameter of a destructive tool. The
+// call must repeat the name of what it destroys, so an agent cannot drop
+// the wrong resource by filling in a single mistaken argument. The
+// parameter is optional in the schema: a call without it is refused with
+// an explanation of what it would remove and how to confirm.
+func WithConfirmation(resource string) mcp.ToolOption {
+	return mcp.WithString("confirm",
+		mcp.Description(fmt.Sprintf("Name of the %s again, confirming that it should be permanently removed; nothing is removed when omitted", resource)),
+	)
+}
+
+// ConfirmParam checks that the confirm parameter repeats name
+func ConfirmParam(r mcp.CallToolRequest, resource, name string) error {
+	confirm, err := OptionalParam[string](r, "confirm")
+	if err != nil {
+		return err
+	}
+	if confirm == "" {
+		return fmt.Errorf("removing %s %q cannot be undone; call again with confirm set to %q to proceed", resource, name, name)
+	}
+	if confirm != name {
+		return fmt.Errorf("confirm %q does not match %s %q; nothing was removed", confirm, resource, name)
+	}
+	return nil
+}
+
+// WithInstanceConfirmation adds the confirm parameter of a tool that cuts
+// clients off an instance, such as by removing its public endpoint. The
+// call must repeat the instance name, as with WithConfirmation, since the
+// access it removes is not always restored as it was.
+func WithInstanceConfirmation(what string) mcp.ToolOption {
+	return mcp.WithString("confirm",
+		mcp.Description(fmt.Sprintf("Name of the instance again, confirming that %s should be removed; nothing is removed when omitted", what)),
+	)
+}
+
+// ConfirmInstanceParam checks that the confirm parameter repeats the name of
+// the instance ref points to
+func ConfirmInstanceParam(r mcp.CallToolRequest, ref InstanceRef, what string) error {
+	confirm, err := OptionalParam[string](r, "confirm")
+	if err != nil {
+		return err
+	}
+	if confirm == "" {
+		return fmt.Errorf("removing %s of %s can cut off its clients; call again with confirm set to %q to proceed", what, ref, ref.Instance)
+	}
+	if confirm != ref.Instance {
+		return fmt.Errorf("confirm %q does not match instance %q; nothing was removed", confirm, ref.Instance)
+	}
+	return nil
+}
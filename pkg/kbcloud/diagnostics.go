@@ -0,0 +1,455 @@
+package kbcloud
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Event types of list_instance_events
+const (
+	eventTypeWarning = "warning"
+	eventTypeNormal  = "normal"
+)
+
+// Limits of event queries
+const (
+	defaultEventsRange  = 24 * time.Hour
+	maxEventsRange      = 30 * 24 * time.Hour
+	defaultEventLimit   = 50
+	maxEventLimit       = 500
+	eventsPageSize      = 100
+	maxEventScan        = 1000
+	maxEventMessageSize = 1024
+)
+
+// primaryRoles are the pod roles that accept writes
+var primaryRoles = []string{"primary", "leader", "master"}
+
+// instanceEvent is an event of an instance, with repeats of the same event
+// counted rather than listed
+type instanceEvent struct {
+	Type      string    `json:"type"`
+	Reason    string    `json:"reason"`
+	Message   string    `json:"message,omitempty"`
+	Source    string    `json:"source,omitempty"`
+	Operator  string    `json:"operator,omitempty"`
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// instanceEvents is the result of list_instance_events. Warnings counts the
+// warning events in the range before deduplication and filtering.
+type instanceEvents struct {
+	InstanceRef string          `json:"instance_ref"`
+	Status      string          `json:"status"`
+	Start       time.Time       `json:"start"`
+	End         time.Time       `json:"end"`
+	Warnings    int             `json:"warnings"`
+	Truncated   bool            `json:"truncated"`
+	Events      []instanceEvent `json:"events"`
+	Notices     []string        `json:"notices,omitempty"`
+}
+
+// componentPod is a pod of an instance component
+type componentPod struct {
+	Name      string `json:"name"`
+	Role      string `json:"role,omitempty"`
+	Status    string `json:"status"`
+	Ready     bool   `json:"ready"`
+	Restarts  *int   `json:"restarts,omitempty"`
+	Node      string `json:"node,omitempty"`
+	Zone      string `json:"zone,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	Message   string `json:"message,omitempty"`
+	CPU       string `json:"cpu,omitempty"`
+	Memory    string `json:"memory,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// componentPods is a component of an instance with its pods
+type componentPods struct {
+	Name      string         `json:"name"`
+	Component string         `json:"component,omitempty"`
+	Replicas  int            `json:"replicas"`
+	Ready     int            `json:"ready"`
+	Primary   string         `json:"primary,omitempty"`
+	Pods      []componentPod `json:"pods"`
+}
+
+// instanceComponents is the result of get_instance_components
+type instanceComponents struct {
+	InstanceRef string          `json:"instance_ref"`
+	Status      string          `json:"status"`
+	Components  []componentPods `json:"components"`
+	Notices     []string        `json:"notices,omitempty"`
+}
+
+// ListInstanceEvents creates a tool to list the events of an instance
+func ListInstanceEvents(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("list_instance_events",
+			mcp.WithDescription("List the events of an instance over a time range, newest first, with repeated events counted once. "+
+				"Warnings are failed operations; use them to find why an instance is stuck Creating or Updating"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			WithInstanceRef(),
+			mcp.WithString("start",
+				mcp.Description("Start of the range as an RFC 3339 time or a duration before end such as 6h or 7d (default 24h)"),
+			),
+			mcp.WithString("end",
+				mcp.Description("End of the range as an RFC 3339 time or a duration before now (default now)"),
+			),
+			mcp.WithString("type",
+				mcp.Description("Only warning or normal events; both by default"),
+				mcp.Enum(eventTypeWarning, eventTypeNormal),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description(fmt.Sprintf("Maximum number of events to return (default %d, max %d)", defaultEventLimit, maxEventLimit)),
+			),
+			WithResponseShaping(),
+			WithOutputSchema[instanceEvents](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			ref, err := InstanceRefParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get optional parameters
+			start, end, err := timeRangeParams(request, time.Now(), defaultEventsRange, maxEventsRange)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			eventType, err := OptionalParam[string](request, "type")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if eventType != "" && eventType != eventTypeWarning && eventType != eventTypeNormal {
+				return mcp.NewToolResultError(fmt.Sprintf("invalid type %q: must be warning or normal", eventType)), nil
+			}
+			limit, err := OptionalIntParamWithDefault(request, "limit", defaultEventLimit)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if limit < 1 || limit > maxEventLimit {
+				return mcp.NewToolResultError(fmt.Sprintf("limit must be between 1 and %d", maxEventLimit)), nil
+			}
+
+			// Get response shaping parameters
+			shaping, err := ResponseShapingParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			ref, instance, err := getInstance(client, ref)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+
+			// Call KB Cloud API
+			events, scanLimited, err := listEvents(client, ref, instance.GetId(), start, end)
+			if err != nil {
+				return nil, err
+			}
+
+			result := instanceEvents{
+				InstanceRef: ref.String(),
+				Status:      instance.GetStatus(),
+				Start:       start,
+				End:         end,
+				Events:      []instanceEvent{},
+			}
+			for _, e := range dedupEvents(events) {
+				if e.Type == eventTypeWarning {
+					result.Warnings += e.Count
+				}
+				if eventType != "" && e.Type != eventType {
+					continue
+				}
+				if len(result.Events) == limit {
+					result.Truncated = true
+					continue
+				}
+				result.Events = append(result.Events, e)
+			}
+
+			if result.Truncated {
+				result.Notices = append(result.Notices, fmt.Sprintf("only the newest %d events are returned; narrow the range or raise limit", limit))
+			}
+			if scanLimited {
+				result.Notices = append(result.Notices, fmt.Sprintf("only the newest %d events of the range were read; narrow the range to see older ones", maxEventScan))
+			}
+			if status := instance.GetStatus(); status != "" && status != "Running" && status != "Stopped" {
+				result.Notices = append(result.Notices, fmt.Sprintf("%s is %s; get_instance_components shows which pods are not ready", ref, status))
+			}
+
+			// Return result
+			return shaping.result(resourceInstanceEvent, result)
+		}
+}
+
+// GetInstanceComponents creates a tool to show the pods of each component
+// of an instance
+func GetInstanceComponents(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("get_instance_components",
+			mcp.WithDescription("Get the components of an instance with their pods: role (primary or secondary), status, readiness, "+
+				"restart count and node placement, with notices about pods that are not ready, restarting or sharing a node"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			WithInstanceRef(),
+			WithResponseShaping(),
+			WithOutputSchema[instanceComponents](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			ref, err := InstanceRefParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get response shaping parameters
+			shaping, err := ResponseShapingParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			ref, instance, err := getInstance(client, ref)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+
+			// Call KB Cloud API
+			pods, resp, err := client.Cluster.ListInstance(client.Context, ref.Org, ref.Instance)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list pods: %w", err)
+			}
+			_ = resp.Body.Close()
+
+			result := instanceComponents{
+				InstanceRef: ref.String(),
+				Status:      instance.GetStatus(),
+				Components:  groupPods(instance, pods.Items),
+			}
+			result.Notices = componentNotices(ref, instance, result.Components)
+
+			// Return result
+			return shaping.result(resourceInstanceComponent, result)
+		}
+}
+
+// listEvents returns the events of an instance created in a range, reading
+// at most maxEventScan events; the flag reports whether more were left
+func listEvents(client *Client, ref InstanceRef, id string, start, end time.Time) ([]kbcloud.Event, bool, error) {
+	var events []kbcloud.Event
+	for page, scanned := int32(1), 0; ; page++ {
+		opts := kbcloud.NewListEventsOptionalParameters().
+			WithResourceType(string(kbcloud.EventResourceTypeCluster)).
+			WithResourceId(id).
+			WithPageNumber(page).
+			WithPageSize(eventsPageSize)
+		list, resp, err := client.Event.ListEvents(client.Context, ref.Org, start.Unix(), end.Unix(), *opts)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to list events: %w", err)
+		}
+		_ = resp.Body.Close()
+
+		for _, e := range list.Items {
+			if e.GetResourceId() == id || e.GetResourceName() == ref.Instance {
+				events = append(events, e)
+			}
+		}
+		scanned += len(list.Items)
+		if len(list.Items) < eventsPageSize || (list.Pagination != nil && scanned >= int(list.Pagination.Total)) {
+			return events, false, nil
+		}
+		if scanned >= maxEventScan {
+			return events, true, nil
+		}
+	}
+}
+
+// dedupEvents merges events with the same type, reason and message and
+// orders them by when they were last seen, newest first
+func dedupEvents(events []kbcloud.Event) []instanceEvent {
+	var result []instanceEvent
+	index := map[string]int{}
+	for _, e := range events {
+		event := instanceEvent{
+			Type:     eventTypeNormal,
+			Reason:   e.GetEventName(),
+			Message:  e.GetDetails(),
+			Source:   string(e.GetSource()),
+			Operator: e.GetOperator(),
+			Count:    1,
+		}
+		if e.GetResultStatus() == kbcloud.EventResultStatusFailed {
+			event.Type = eventTypeWarning
+		}
+		if name := e.GetDisplayName(); name != "" {
+			event.Reason = name
+		}
+		if event.Message == "" {
+			event.Message = e.GetResult()
+		}
+		event.Message = truncateText(event.Message, maxEventMessageSize)
+		seen := e.GetCreatedAt()
+		if seen.IsZero() {
+			seen = e.GetStart()
+		}
+		event.FirstSeen, event.LastSeen = seen.UTC(), seen.UTC()
+
+		key := event.Type + "\x00" + event.Reason + "\x00" + event.Message
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(result)
+			result = append(result, event)
+			continue
+		}
+		merged := &result[i]
+		merged.Count++
+		if event.FirstSeen.Before(merged.FirstSeen) {
+			merged.FirstSeen = event.FirstSeen
+		}
+		if event.LastSeen.After(merged.LastSeen) {
+			merged.LastSeen = event.LastSeen
+			merged.Operator = event.Operator
+		}
+	}
+	slices.SortStableFunc(result, func(a, b instanceEvent) int {
+		if c := b.LastSeen.Compare(a.LastSeen); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Reason, b.Reason)
+	})
+	return result
+}
+
+// groupPods groups the pods of an instance by component, in the order of
+// the instance's components
+func groupPods(instance kbcloud.Cluster, pods []kbcloud.Instance) []componentPods {
+	components := []componentPods{}
+	index := map[string]int{}
+	for _, c := range instance.GetComponents() {
+		name := c.GetName()
+		if name == "" {
+			name = c.GetComponent()
+		}
+		index[name] = len(components)
+		components = append(components, componentPods{Name: name, Component: c.GetComponent(), Replicas: int(c.GetReplicas()), Pods: []componentPod{}})
+	}
+
+	for _, p := range pods {
+		name := p.GetComponentName()
+		if name == "" {
+			name = p.Component
+		}
+		i, ok := index[name]
+		if !ok {
+			index[name] = len(components)
+			i = len(components)
+			components = append(components, componentPods{Name: name, Component: p.Component, Pods: []componentPod{}})
+		}
+		pod := componentPod{
+			Name:      p.Name,
+			Role:      p.Role,
+			Status:    p.Status.Phase,
+			Node:      p.Node,
+			Zone:      p.Zone,
+			Reason:    p.Status.GetReason(),
+			Message:   p.Status.GetMessage(),
+			CPU:       p.Cpu,
+			Memory:    p.Memory,
+			CreatedAt: p.CreatedAt,
+		}
+		// Readiness and restarts are only reported by some KB Cloud
+		// versions; a running pod without a reason is taken as ready
+		pod.Ready = p.Status.Phase == "Running" && pod.Reason == ""
+		if ready, ok := p.AdditionalProperties["ready"].(bool); ok {
+			pod.Ready = ready
+		}
+		for _, key := range []string{"restartCount", "restarts"} {
+			if n, ok := p.AdditionalProperties[key].(float64); ok {
+				restarts := int(n)
+				pod.Restarts = &restarts
+				break
+			}
+		}
+
+		c := &components[i]
+		c.Pods = append(c.Pods, pod)
+		if pod.Ready {
+			c.Ready++
+		}
+		if contains(primaryRoles, strings.ToLower(pod.Role)) {
+			c.Primary = pod.Name
+		}
+	}
+
+	for i := range components {
+		slices.SortFunc(components[i].Pods, func(a, b componentPod) int { return strings.Compare(a.Name, b.Name) })
+	}
+	return components
+}
+
+// componentNotices explains what keeps the components of an instance from
+// being healthy
+func componentNotices(ref InstanceRef, instance kbcloud.Cluster, components []componentPods) []string {
+	var notices []string
+	for _, c := range components {
+		if len(c.Pods) == 0 {
+			if instance.GetStatus() == "Stopped" {
+				notices = append(notices, fmt.Sprintf("%s is Stopped, so component %s has no pods", ref, c.Name))
+			} else {
+				notices = append(notices, fmt.Sprintf("component %s has no pods yet; list_instance_events shows why they were not created", c.Name))
+			}
+			continue
+		}
+		if c.Ready < c.Replicas {
+			notices = append(notices, fmt.Sprintf("component %s has %d of %d pods ready", c.Name, c.Ready, c.Replicas))
+		}
+
+		nodes := map[string]int{}
+		for _, p := range c.Pods {
+			if !p.Ready {
+				notice := fmt.Sprintf("pod %s is not ready", p.Name)
+				if p.Reason != "" {
+					notice += ": " + p.Reason
+				}
+				if p.Message != "" {
+					notice += " (" + p.Message + ")"
+				}
+				notices = append(notices, notice)
+			}
+			if p.Restarts != nil && *p.Restarts > 0 {
+				notices = append(notices, fmt.Sprintf("pod %s has restarted %d times; search_instance_logs with log_type error may show why", p.Name, *p.Restarts))
+			}
+			if p.Node != "" {
+				nodes[p.Node]++
+			}
+		}
+		for node, n := range nodes {
+			if n > 1 && n == len(c.Pods) {
+				notices = append(notices, fmt.Sprintf("all %d pods of component %s run on node %s, so a failure of the node stops the component", n, c.Name, node))
+			}
+		}
+	}
+	return notices
+}
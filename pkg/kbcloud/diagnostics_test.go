@@ -0,0 +1,232 @@
+package kbcloud_test
+
+import (
+	"testing"
+	"time"
+
+	client "github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud/kbcloudtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// instanceEvents is the JSON result of list_instance_events
+type instanceEvents struct {
+	Warnings  int  `json:"warnings"`
+	Truncated bool `json:"truncated"`
+	Events    []struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+		Count  int    `json:"count"`
+	} `json:"events"`
+	Notices []string `json:"notices"`
+}
+
+// instanceComponents is the JSON result of get_instance_components
+type instanceComponents struct {
+	Components []struct {
+		Name  string `json:"name"`
+		Ready int    `json:"ready"`
+		Pods  []struct {
+			Name     string `json:"name"`
+			Ready    bool   `json:"ready"`
+			Restarts *int   `json:"restarts"`
+		} `json:"pods"`
+	} `json:"components"`
+	Notices []string `json:"notices"`
+}
+
+func TestListInstanceEvents(t *testing.T) {
+	args := func(extra map[string]any) map[string]any {
+		a := map[string]any{"instance_ref": "acme/prod/cache", "start": "2024-01-15T07:00:00Z", "end": "2024-01-15T09:00:00Z"}
+		for k, v := range extra {
+			a[k] = v
+		}
+		return a
+	}
+
+	runToolTests(t, kbcloud.ListInstanceEvents, []toolTest{
+		{
+			name: "deduplicated",
+			args: args(nil),
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"instance_ref":"acme/prod/cache","status":"Running",
+					"start":"2024-01-15T07:00:00Z","end":"2024-01-15T09:00:00Z","warnings":3,"truncated":false,
+					"events":[
+						{"type":"warning","reason":"RestartCluster","message":"pod cache-redis-0 failed readiness probe: connection refused",
+							"source":"system","operator":"system","count":3,
+							"first_seen":"2024-01-15T08:10:00Z","last_seen":"2024-01-15T08:30:00Z"},
+						{"type":"normal","reason":"CreateCluster","message":"cluster cache created",
+							"source":"system","operator":"system","count":1,
+							"first_seen":"2024-01-15T08:00:00Z","last_seen":"2024-01-15T08:00:00Z"}
+					]}`, text)
+			},
+		},
+		{
+			name: "normal events",
+			args: args(map[string]any{"type": "normal"}),
+			check: func(t *testing.T, text string) {
+				result := decode[instanceEvents](t, text)
+				require.Len(t, result.Events, 1)
+				assert.Equal(t, "CreateCluster", result.Events[0].Reason)
+				assert.Equal(t, 3, result.Warnings)
+			},
+		},
+		{
+			name: "limit",
+			args: args(map[string]any{"limit": 1.0}),
+			check: func(t *testing.T, text string) {
+				result := decode[instanceEvents](t, text)
+				require.Len(t, result.Events, 1)
+				assert.Equal(t, "RestartCluster", result.Events[0].Reason)
+				assert.True(t, result.Truncated)
+			},
+		},
+		{
+			name: "other instance",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "start": "2024-01-15T07:00:00Z", "end": "2024-01-15T09:00:00Z"},
+			check: func(t *testing.T, text string) {
+				result := decode[instanceEvents](t, text)
+				require.Len(t, result.Events, 1)
+				assert.Equal(t, "normal", result.Events[0].Type)
+			},
+		},
+		{
+			name: "yaml summary",
+			args: args(map[string]any{"type": "normal", "view": "summary", "format": "yaml"}),
+			check: func(t *testing.T, text string) {
+				assert.Equal(t, "events:\n    - count: 1\n      last_seen: \"2024-01-15T08:00:00Z\"\n      reason: CreateCluster\n      type: normal\n"+
+					"instance_ref: acme/prod/cache\nstatus: Running\ntruncated: false\nwarnings: 3\n", text)
+			},
+		},
+		{
+			name:        "invalid type",
+			args:        args(map[string]any{"type": "error"}),
+			wantToolErr: `invalid type "error": must be warning or normal`,
+		},
+		{
+			name:        "long range",
+			args:        map[string]any{"instance_ref": "acme/prod/cache", "start": "31d"},
+			wantToolErr: "longer than 30d",
+		},
+	})
+
+	t.Run("pages and status", func(t *testing.T) {
+		f := kbcloudtest.DefaultFixtures()
+		for i := range f.Clusters {
+			if f.Clusters[i].Name == "cache" {
+				f.Clusters[i].SetStatus("Creating")
+			}
+		}
+		at := time.Date(2024, time.January, 15, 8, 40, 0, 0, time.UTC)
+		for i := range 150 {
+			event := client.Event{}
+			event.SetOrgName("acme")
+			event.SetResourceId("cluster-cache")
+			event.SetResourceType(client.EventResourceTypeCluster)
+			event.SetResourceName("cache")
+			event.SetEventName("ScaleCluster")
+			event.SetResultStatus(client.EventResultStatusSuccess)
+			event.SetCreatedAt(at.Add(time.Duration(i) * time.Second))
+			f.Events = append(f.Events, event)
+		}
+		s := kbcloudtest.NewServer(t, kbcloudtest.WithFixtures(f))
+		_, handler := kbcloud.ListInstanceEvents(s.GetClientFn())
+
+		result, err := callTool(handler, args(nil))
+		require.NoError(t, err)
+		text := resultText(t, result)
+		require.False(t, result.IsError, text)
+
+		events := decode[instanceEvents](t, text)
+		require.Len(t, events.Events, 3)
+		assert.Equal(t, "ScaleCluster", events.Events[0].Reason)
+		assert.Equal(t, 150, events.Events[0].Count)
+		assert.Equal(t, []string{"acme/prod/cache is Creating; get_instance_components shows which pods are not ready"}, events.Notices)
+
+		var pages []string
+		for _, r := range s.Requests() {
+			if r.Path == "/api/v1/organizations/acme/events" {
+				pages = append(pages, r.Query.Get("pageNumber"))
+				assert.Equal(t, "cluster-cache", r.Query.Get("resourceId"))
+				assert.Equal(t, "1705302000", r.Query.Get("start"))
+			}
+		}
+		assert.Equal(t, []string{"1", "2"}, pages)
+	})
+
+	t.Run("failed query", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		s.InjectError("GET", "/api/v1/organizations/acme/events", 500, "event store unavailable")
+		_, handler := kbcloud.ListInstanceEvents(s.GetClientFn())
+
+		_, err := callTool(handler, args(nil))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to list events")
+	})
+}
+
+func TestGetInstanceComponents(t *testing.T) {
+	runToolTests(t, kbcloud.GetInstanceComponents, []toolTest{
+		{
+			name: "healthy",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"instance_ref":"acme/prod/orders-db","status":"Running",
+					"components":[{"name":"mysql","component":"mysql","replicas":1,"ready":1,"primary":"orders-db-mysql-0",
+						"pods":[{"name":"orders-db-mysql-0","role":"primary","status":"Running","ready":true,"restarts":0,
+							"node":"ip-10-0-1-12","zone":"us-east-1a","cpu":"1","memory":"2","created_at":"2024-01-15T08:00:00Z"}]}]}`, text)
+			},
+		},
+		{
+			name: "crash looping",
+			args: map[string]any{"instance_ref": "acme/prod/cache"},
+			check: func(t *testing.T, text string) {
+				result := decode[instanceComponents](t, text)
+				require.Len(t, result.Components, 1)
+				assert.Equal(t, 0, result.Components[0].Ready)
+				require.Len(t, result.Components[0].Pods, 1)
+				require.NotNil(t, result.Components[0].Pods[0].Restarts)
+				assert.Equal(t, 7, *result.Components[0].Pods[0].Restarts)
+				assert.Equal(t, []string{
+					"component redis has 0 of 1 pods ready",
+					"pod cache-redis-0 is not ready: CrashLoopBackOff (back-off 5m0s restarting failed container=redis pod=cache-redis-0)",
+					"pod cache-redis-0 has restarted 7 times; search_instance_logs with log_type error may show why",
+				}, result.Notices)
+			},
+		},
+		{
+			name: "stopped",
+			args: map[string]any{"instance_ref": "acme/staging/analytics"},
+			check: func(t *testing.T, text string) {
+				result := decode[instanceComponents](t, text)
+				require.Len(t, result.Components, 1)
+				assert.Empty(t, result.Components[0].Pods)
+				assert.Equal(t, []string{"acme/staging/analytics is Stopped, so component postgresql has no pods"}, result.Notices)
+			},
+		},
+		{
+			name:  "no pods yet",
+			args:  map[string]any{"instance_ref": "acme/prod/sessions"},
+			setup: func(s *kbcloudtest.Server) { s.AddCluster("acme", "prod", "sessions", "redis") },
+			check: func(t *testing.T, text string) {
+				assert.Equal(t, []string{"component redis has no pods yet; list_instance_events shows why they were not created"},
+					decode[instanceComponents](t, text).Notices)
+			},
+		},
+		{
+			name: "yaml summary",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "view": "summary", "format": "yaml"},
+			check: func(t *testing.T, text string) {
+				assert.Equal(t, "components:\n    - name: mysql\n      primary: orders-db-mysql-0\n      ready: 1\n      replicas: 1\n"+
+					"instance_ref: acme/prod/orders-db\nstatus: Running\n", text)
+			},
+		},
+		{
+			name:        "unknown instance",
+			args:        map[string]any{"instance_ref": "acme/prod/orders"},
+			wantToolErr: "did you mean acme/prod/orders-db?",
+		},
+	})
+}
@@ -0,0 +1,29 @@
+package kbcloud
+
+import "strings"
+
+// Engine families that tools treat differently
+const (
+	engineMySQL      = "mysql"
+	enginePostgreSQL = "postgresql"
+	engineRedis      = "redis"
+	engineMongoDB    = "mongodb"
+)
+
+// engineFamily returns the family of a KB Cloud engine name, such as mysql
+// for apecloud-mysql, or "" when the engine belongs to none
+func engineFamily(engine string) string {
+	engine = strings.ToLower(engine)
+	switch {
+	case strings.Contains(engine, "mysql"), strings.Contains(engine, "mariadb"):
+		return engineMySQL
+	case strings.Contains(engine, "postgres"):
+		return enginePostgreSQL
+	case strings.Contains(engine, "redis"), strings.Contains(engine, "valkey"):
+		return engineRedis
+	case strings.Contains(engine, "mongo"):
+		return engineMongoDB
+	default:
+		return ""
+	}
+}
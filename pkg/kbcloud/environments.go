@@ -2,11 +2,12 @@ package kbcloud
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
@@ -15,15 +16,17 @@ import (
 func ListEnvironments(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
 	return mcp.NewTool("list_environments",
 			mcp.WithDescription("List all environments within a KB Cloud organization"),
+			mcp.WithReadOnlyHintAnnotation(true),
 			mcp.WithString("org_name",
-				mcp.Required(),
-				mcp.Description("Organization name"),
+				mcp.Description("Organization name; defaults to the session context"),
 			),
 			WithPagination(),
+			WithResponseShaping(),
+			WithOutputSchema[kbcloud.EnvironmentList](),
 		),
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			// Get required parameters
-			orgName, err := RequiredParam[string](request, "org_name")
+			orgName, err := orgParam(ctx, request, "org_name")
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
@@ -34,6 +37,12 @@ func ListEnvironments(getClient GetClientFn) (tool mcp.Tool, handler server.Tool
 				return mcp.NewToolResultError(err.Error()), nil
 			}
 
+			// Get response shaping parameters
+			shaping, err := ResponseShapingParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
 			// Get KB Cloud client
 			client, err := getClient(ctx)
 			if err != nil {
@@ -56,13 +65,11 @@ func ListEnvironments(getClient GetClientFn) (tool mcp.Tool, handler server.Tool
 				return mcp.NewToolResultError(fmt.Sprintf("failed to list environments: %s", string(body))), nil
 			}
 
-			// Return result
-			result, err := json.Marshal(envs)
-			if err != nil {
-				return nil, fmt.Errorf("failed to marshal response: %w", err)
-			}
+			// Apply pagination
+			envs.Items = paginate(envs.Items, pagination)
 
-			return mcp.NewToolResultText(string(result)), nil
+			// Return result
+			return shaping.result(resourceEnvironment, envs)
 		}
 }
 
@@ -70,22 +77,29 @@ func ListEnvironments(getClient GetClientFn) (tool mcp.Tool, handler server.Tool
 func GetEnvironment(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
 	return mcp.NewTool("get_environment",
 			mcp.WithDescription("Get details of a specific environment in KB Cloud"),
+			mcp.WithReadOnlyHintAnnotation(true),
 			mcp.WithString("org_name",
-				mcp.Required(),
-				mcp.Description("Organization name"),
+				mcp.Description("Organization name; defaults to the session context"),
 			),
 			mcp.WithString("env_name",
-				mcp.Required(),
-				mcp.Description("Environment name"),
+				mcp.Description("Environment name; defaults to the session context"),
 			),
+			WithResponseShaping(),
+			WithOutputSchema[kbcloud.Environment](),
 		),
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			// Get required parameters
-			orgName, err := RequiredParam[string](request, "org_name")
+			orgName, err := orgParam(ctx, request, "org_name")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			envName, err := envParam(ctx, request, orgName)
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
-			envName, err := RequiredParam[string](request, "env_name")
+
+			// Get response shaping parameters
+			shaping, err := ResponseShapingParams(ctx, request)
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
@@ -113,11 +127,6 @@ func GetEnvironment(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHa
 			}
 
 			// Return result
-			result, err := json.Marshal(env)
-			if err != nil {
-				return nil, fmt.Errorf("failed to marshal response: %w", err)
-			}
-
-			return mcp.NewToolResultText(string(result)), nil
+			return shaping.result(resourceEnvironment, env)
 		}
 }
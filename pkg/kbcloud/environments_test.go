@@ -0,0 +1,79 @@
+package kbcloud_test
+
+import (
+	"net/http"
+	"testing"
+
+	client "github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud/kbcloudtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListEnvironments(t *testing.T) {
+	runToolTests(t, kbcloud.ListEnvironments, []toolTest{
+		{
+			name: "environments of an organization",
+			args: map[string]any{"org_name": "acme"},
+			check: func(t *testing.T, text string) {
+				envs := decode[client.EnvironmentList](t, text)
+				require.Len(t, envs.Items, 2)
+				assert.Equal(t, "prod", envs.Items[0].Name)
+				assert.Equal(t, "staging", envs.Items[1].Name)
+			},
+		},
+		{
+			name: "paginated",
+			args: map[string]any{"org_name": "acme", "page": float64(2), "perPage": float64(1)},
+			check: func(t *testing.T, text string) {
+				envs := decode[client.EnvironmentList](t, text)
+				require.Len(t, envs.Items, 1)
+				assert.Equal(t, "staging", envs.Items[0].Name)
+			},
+		},
+		{
+			name:        "missing organization",
+			args:        map[string]any{},
+			wantToolErr: "org_name",
+		},
+		{
+			name:    "unknown organization",
+			args:    map[string]any{"org_name": "initech"},
+			wantErr: "404",
+		},
+		{
+			name: "server error",
+			args: map[string]any{"org_name": "acme"},
+			setup: func(s *kbcloudtest.Server) {
+				s.InjectError(http.MethodGet, "/api/v1/organizations/acme/environments", http.StatusServiceUnavailable, "maintenance")
+			},
+			wantErr: "failed to list environments",
+		},
+	})
+}
+
+func TestGetEnvironment(t *testing.T) {
+	runToolTests(t, kbcloud.GetEnvironment, []toolTest{
+		{
+			name: "existing environment",
+			args: map[string]any{"org_name": "globex", "env_name": "dev"},
+			check: func(t *testing.T, text string) {
+				env := decode[client.Environment](t, text)
+				assert.Equal(t, "dev", env.Name)
+				assert.Equal(t, "globex", env.OrgName)
+				assert.Equal(t, "gcp", env.Provider)
+			},
+		},
+		{
+			name:        "missing environment name",
+			args:        map[string]any{"org_name": "acme"},
+			wantToolErr: "env_name",
+		},
+		{
+			name:    "environment of another organization",
+			args:    map[string]any{"org_name": "acme", "env_name": "dev"},
+			wantErr: "404",
+		},
+	})
+}
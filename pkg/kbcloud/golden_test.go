@@ -0,0 +1,50 @@
+package kbcloud_test
+
+import (
+	"flag"
+	"path/filepath"
+	"testing"
+
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud/kbcloudtest"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// TestGolden replays recorded KB Cloud traffic through each tool and
+// compares the rendered output with its golden file, so changes to a tool's
+// output shape show up in review
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		tool toolFactory
+		args map[string]any
+	}{
+		{name: "list_organizations", tool: kbcloud.ListOrganizations},
+		{name: "get_organization", tool: kbcloud.GetOrganization, args: map[string]any{"name": "acme"}},
+		{name: "list_environments", tool: kbcloud.ListEnvironments, args: map[string]any{"org_name": "acme"}},
+		{name: "get_environment", tool: kbcloud.GetEnvironment, args: map[string]any{"org_name": "acme", "env_name": "prod"}},
+		{name: "list_instances", tool: kbcloud.ListInstances, args: map[string]any{"org_name": "acme", "env_name": "prod"}},
+		{name: "get_instance", tool: kbcloud.GetInstance, args: map[string]any{"org_name": "acme", "env_name": "prod", "instance_name": "orders-db"}},
+		{name: "get_instance_summary", tool: kbcloud.GetInstance, args: map[string]any{"org_name": "acme", "env_name": "prod", "instance_name": "orders-db", "view": "summary"}},
+		{name: "list_instances_markdown", tool: kbcloud.ListInstances, args: map[string]any{"org_name": "acme", "env_name": "prod", "view": "summary", "format": "markdown"}},
+		{name: "get_instance_yaml", tool: kbcloud.GetInstance, args: map[string]any{"org_name": "acme", "env_name": "prod", "instance_name": "orders-db", "format": "yaml"}},
+		{name: "find_instance", tool: kbcloud.FindInstance, args: map[string]any{"query": "o"}},
+		{name: "list_backups", tool: kbcloud.ListBackups, args: map[string]any{"org_name": "acme", "env_name": "prod", "instance_name": "orders-db"}},
+		{name: "get_backup", tool: kbcloud.GetBackup, args: map[string]any{"org_name": "acme", "backup_id": "orders-db-backup-1"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			getClient := kbcloudtest.CassetteClientFn(t, filepath.Join("testdata", "cassettes", tc.name+".json"))
+			_, handler := tc.tool(getClient)
+
+			result, err := callTool(handler, tc.args)
+			require.NoError(t, err)
+			require.False(t, result.IsError, resultText(t, result))
+
+			kbcloudtest.AssertGolden(t, filepath.Join("testdata", "golden", tc.name+".golden"), resultText(t, result), *update)
+		})
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/apecloud/kb-cloud-client-go/api/common"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -16,20 +19,20 @@ func requiredParam[T comparable](r mcp.CallToolRequest, p string) (T, error) {
 	var zero T
 
 	// Check if the parameter is present in the request
-	if _, ok := r.Params.Arguments[p]; !ok {
+	if _, ok := r.GetArguments()[p]; !ok {
 		return zero, fmt.Errorf("missing required parameter: %s", p)
 	}
 
 	// Check if the parameter is of the expected type
-	if _, ok := r.Params.Arguments[p].(T); !ok {
+	if _, ok := r.GetArguments()[p].(T); !ok {
 		return zero, fmt.Errorf("parameter %s is not of type %T", p, zero)
 	}
 
-	if r.Params.Arguments[p].(T) == zero {
+	if r.GetArguments()[p].(T) == zero {
 		return zero, fmt.Errorf("missing required parameter: %s", p)
 	}
 
-	return r.Params.Arguments[p].(T), nil
+	return r.GetArguments()[p].(T), nil
 }
 
 // RequiredParam gets a required parameter of the specified type
@@ -51,22 +54,22 @@ func OptionalParam[T any](r mcp.CallToolRequest, p string) (T, error) {
 	var zero T
 
 	// Check if the parameter is present in the request
-	if _, ok := r.Params.Arguments[p]; !ok {
+	if _, ok := r.GetArguments()[p]; !ok {
 		return zero, nil
 	}
 
 	// Check if the parameter is of the expected type
-	if _, ok := r.Params.Arguments[p].(T); !ok {
-		return zero, fmt.Errorf("parameter %s is not of type %T, is %T", p, zero, r.Params.Arguments[p])
+	if _, ok := r.GetArguments()[p].(T); !ok {
+		return zero, fmt.Errorf("parameter %s is not of type %T, is %T", p, zero, r.GetArguments()[p])
 	}
 
-	return r.Params.Arguments[p].(T), nil
+	return r.GetArguments()[p].(T), nil
 }
 
 // OptionalParamOK returns the value, a boolean indicating if the parameter was present, and any error
 func OptionalParamOK[T any](r mcp.CallToolRequest, p string) (value T, ok bool, err error) {
 	// Check if the parameter is present in the request
-	val, exists := r.Params.Arguments[p]
+	val, exists := r.GetArguments()[p]
 	if !exists {
 		// Not present, return zero value, false, no error
 		return
@@ -110,11 +113,11 @@ func OptionalIntParamWithDefault(r mcp.CallToolRequest, p string, d int) (int, e
 // OptionalStringArrayParam gets an optional string array parameter
 func OptionalStringArrayParam(r mcp.CallToolRequest, p string) ([]string, error) {
 	// Check if the parameter is present in the request
-	if _, ok := r.Params.Arguments[p]; !ok {
+	if _, ok := r.GetArguments()[p]; !ok {
 		return []string{}, nil
 	}
 
-	switch v := r.Params.Arguments[p].(type) {
+	switch v := r.GetArguments()[p].(type) {
 	case nil:
 		return []string{}, nil
 	case []string:
@@ -130,7 +133,7 @@ func OptionalStringArrayParam(r mcp.CallToolRequest, p string) ([]string, error)
 		}
 		return strSlice, nil
 	default:
-		return []string{}, fmt.Errorf("parameter %s could not be coerced to []string, is %T", p, r.Params.Arguments[p])
+		return []string{}, fmt.Errorf("parameter %s could not be coerced to []string, is %T", p, r.GetArguments()[p])
 	}
 }
 
@@ -172,6 +175,19 @@ func OptionalPaginationParams(r mcp.CallToolRequest) (PaginationParams, error) {
 	}, nil
 }
 
+// paginate returns the page of items selected by the pagination parameters
+func paginate[T any](items []T, p PaginationParams) []T {
+	start := (p.Page - 1) * p.PerPage
+	if start >= len(items) {
+		return []T{}
+	}
+	end := start + p.PerPage
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end]
+}
+
 // isAcceptedError checks if the error is an accepted error
 func isAcceptedError(err error) bool {
 	var acceptedError *common.GenericOpenAPIError
@@ -197,3 +213,69 @@ func GetEnv(key string) (string, bool) {
 	val, ok := os.LookupEnv(key)
 	return val, ok
 }
+
+// timeRangeParams returns the start and end parameters of a time range.
+// end defaults to now and start to defaultRange before end; the range may
+// not be longer than maxRange.
+func timeRangeParams(r mcp.CallToolRequest, now time.Time, defaultRange, maxRange time.Duration) (start, end time.Time, err error) {
+	now = now.UTC().Truncate(time.Second)
+	endParam, err := OptionalParam[string](r, "end")
+	if err != nil {
+		return start, end, err
+	}
+	end = now
+	if endParam != "" {
+		if end, err = parseTimeParam("end", endParam, now); err != nil {
+			return start, end, err
+		}
+	}
+	startParam, err := OptionalParam[string](r, "start")
+	if err != nil {
+		return start, end, err
+	}
+	start = end.Add(-defaultRange)
+	if startParam != "" {
+		if start, err = parseTimeParam("start", startParam, end); err != nil {
+			return start, end, err
+		}
+	}
+	if !start.Before(end) {
+		return start, end, fmt.Errorf("start %s must be before end %s", start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+	if end.Sub(start) > maxRange {
+		return start, end, fmt.Errorf("the range from start to end is longer than %s; narrow it", formatDuration(maxRange))
+	}
+	return start, end, nil
+}
+
+// parseTimeParam parses an RFC 3339 time or a duration before base
+func parseTimeParam(name, value string, base time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	d, err := parseDuration(value)
+	if err != nil || d <= 0 {
+		return time.Time{}, fmt.Errorf("invalid %s %q: expected an RFC 3339 time such as 2024-01-15T08:00:00Z or a duration such as 6h", name, value)
+	}
+	return base.Add(-d), nil
+}
+
+// parseDuration parses a Go duration, also accepting whole days such as 7d
+func parseDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// formatDuration formats whole days as such, e.g. 30d
+func formatDuration(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return d.String()
+}
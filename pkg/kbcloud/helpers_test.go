@@ -0,0 +1,97 @@
+package kbcloud_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud/kbcloudtest"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toolFactory is the signature shared by every tool constructor
+type toolFactory func(getClient kbcloud.GetClientFn) (mcp.Tool, server.ToolHandlerFunc)
+
+// toolTest is a table-driven test case for a tool handler run against the
+// fake KB Cloud server
+type toolTest struct {
+	name string
+	args map[string]any
+	// setup prepares the fake server before the call
+	setup func(s *kbcloudtest.Server)
+	// wantErr is a substring of the error returned by the handler
+	wantErr string
+	// wantToolErr is a substring of the error result returned to the client
+	wantToolErr string
+	// check inspects the text of a successful result
+	check func(t *testing.T, text string)
+}
+
+// runToolTests runs each test case against a fresh fake server
+func runToolTests(t *testing.T, newTool toolFactory, tests []toolTest) {
+	t.Helper()
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := kbcloudtest.NewServer(t)
+			if tc.setup != nil {
+				tc.setup(s)
+			}
+			_, handler := newTool(s.GetClientFn())
+
+			result, err := callTool(handler, tc.args)
+
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, result)
+
+			text := resultText(t, result)
+			if tc.wantToolErr != "" {
+				assert.True(t, result.IsError, "expected an error result, got %s", text)
+				assert.Contains(t, text, tc.wantToolErr)
+				return
+			}
+			assert.False(t, result.IsError, "unexpected error result: %s", text)
+			if tc.check != nil {
+				tc.check(t, text)
+			}
+		})
+	}
+}
+
+// callTool invokes a tool handler with the given arguments
+func callTool(handler server.ToolHandlerFunc, args map[string]any) (*mcp.CallToolResult, error) {
+	return handler(context.Background(), callRequest(args))
+}
+
+// callRequest builds a tool call request with the given arguments
+func callRequest(args map[string]any) mcp.CallToolRequest {
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = args
+	return request
+}
+
+// resultText returns the text content of a tool result
+func resultText(t *testing.T, result *mcp.CallToolResult) string {
+	t.Helper()
+	require.NotEmpty(t, result.Content)
+	text, ok := result.Content[0].(mcp.TextContent)
+	require.True(t, ok, "expected text content, got %T", result.Content[0])
+	return text.Text
+}
+
+// decode unmarshals the JSON text of a tool result
+func decode[T any](t *testing.T, text string) T {
+	t.Helper()
+	var v T
+	require.NoError(t, json.Unmarshal([]byte(text), &v))
+	return v
+}
@@ -0,0 +1,632 @@
+package kbcloud
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Log types of search_instance_logs
+const (
+	logTypeError   = "error"
+	logTypeSlow    = "slow"
+	logTypeRunning = "running"
+	logTypeAudit   = "audit"
+)
+
+// Limits of log queries, which keep results small
+const (
+	defaultLogsRange     = time.Hour
+	defaultSlowLogsRange = 24 * time.Hour
+	maxLogsRange         = 7 * 24 * time.Hour
+	defaultLogLimit      = 50
+	maxLogLimit          = 500
+	// maxLogScan is the number of entries fetched when entries are filtered
+	// or aggregated by the MCP server rather than KB Cloud
+	maxLogScan        = 5000
+	maxLogMessageSize = 2048
+	defaultTopQueries = 10
+	maxExampleSize    = 512
+)
+
+var (
+	// mysqlQueryTime matches the header line of a MySQL slow log entry
+	mysqlQueryTime = regexp.MustCompile(`Query_time:\s*([0-9.]+)`)
+	// postgresDuration matches a PostgreSQL log_min_duration_statement line
+	postgresDuration = regexp.MustCompile(`(?s)duration:\s*([0-9.]+)\s*ms\s+(?:statement|execute [^:]*):\s*(.*)`)
+
+	// Patterns replaced to fingerprint a statement. Double-quoted text is a
+	// string on MySQL but an identifier on PostgreSQL.
+	sqlComments      = regexp.MustCompile(`(?s)/\*.*?\*/|--[^\n]*`)
+	sqlStrings       = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'`)
+	mysqlStrings     = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'|"(?:[^"\\]|\\.)*"`)
+	sqlNumbers       = regexp.MustCompile(`\b(?:0x[0-9a-f]+|[0-9]+(?:\.[0-9]+)?(?:e[+-]?[0-9]+)?)\b`)
+	sqlPlaceholders  = regexp.MustCompile(`\$[0-9]+`)
+	sqlValueLists    = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	sqlMultiValues   = regexp.MustCompile(`(\(\?\+?\))(?:\s*,\s*\(\?\+?\))+`)
+	sqlWhitespace    = regexp.MustCompile(`\s+`)
+	sqlOperatorSpace = regexp.MustCompile(`\s*([(,=<>])\s*|\s*(\))`)
+)
+
+// logEntry is an entry of an instance log
+type logEntry struct {
+	Time      time.Time `json:"time"`
+	Pod       string    `json:"pod,omitempty"`
+	Component string    `json:"component,omitempty"`
+	Message   string    `json:"message"`
+}
+
+// instanceLogs is the result of search_instance_logs
+type instanceLogs struct {
+	InstanceRef string     `json:"instance_ref"`
+	LogType     string     `json:"log_type"`
+	Start       time.Time  `json:"start"`
+	End         time.Time  `json:"end"`
+	Scanned     int        `json:"scanned"`
+	Truncated   bool       `json:"truncated"`
+	Entries     []logEntry `json:"entries"`
+	Notices     []string   `json:"notices,omitempty"`
+}
+
+// slowQuery aggregates the slow log entries of a statement fingerprint
+type slowQuery struct {
+	Fingerprint  string    `json:"fingerprint"`
+	Count        int       `json:"count"`
+	TotalSeconds float64   `json:"total_seconds"`
+	AvgSeconds   float64   `json:"avg_seconds"`
+	P50Seconds   float64   `json:"p50_seconds"`
+	P95Seconds   float64   `json:"p95_seconds"`
+	MaxSeconds   float64   `json:"max_seconds"`
+	FirstSeen    time.Time `json:"first_seen"`
+	LastSeen     time.Time `json:"last_seen"`
+	Example      string    `json:"example"`
+}
+
+// slowQueries is the result of top_slow_queries
+type slowQueries struct {
+	InstanceRef string      `json:"instance_ref"`
+	Start       time.Time   `json:"start"`
+	End         time.Time   `json:"end"`
+	Scanned     int         `json:"scanned"`
+	Unparsed    int         `json:"unparsed"`
+	OrderBy     string      `json:"order_by"`
+	Queries     []slowQuery `json:"queries"`
+	Notices     []string    `json:"notices,omitempty"`
+}
+
+// logQuery selects the entries of an instance log
+type logQuery struct {
+	logType    string
+	start, end time.Time
+	component  string
+	pod        string
+	limit      int
+}
+
+// withLogRange adds the range, component and pod parameters of a log query
+func withLogRange(defaultRange string) mcp.ToolOption {
+	return func(t *mcp.Tool) {
+		mcp.WithString("start",
+			mcp.Description("Start of the range as an RFC 3339 time or a duration before end such as 30m, 6h or 2d (default "+defaultRange+")"),
+		)(t)
+		mcp.WithString("end",
+			mcp.Description("End of the range as an RFC 3339 time or a duration before now (default now)"),
+		)(t)
+		mcp.WithString("component",
+			mcp.Description("Component whose logs to read; defaults to the main one"),
+		)(t)
+		mcp.WithString("pod",
+			mcp.Description("Only the logs of this pod of the component, e.g. orders-db-mysql-0"),
+		)(t)
+	}
+}
+
+// SearchInstanceLogs creates a tool to search the logs of an instance
+func SearchInstanceLogs(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("search_instance_logs",
+			mcp.WithDescription("Search the error, slow query, running or audit log of an instance over a time range, "+
+				"optionally filtered by keyword or regular expression. Newest entries first"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			WithInstanceRef(),
+			mcp.WithString("log_type",
+				mcp.Required(),
+				mcp.Description("Log to search: error, slow (slow queries), running (engine output) or audit"),
+				mcp.Enum(logTypeError, logTypeSlow, logTypeRunning, logTypeAudit),
+			),
+			withLogRange("1h"),
+			mcp.WithString("keyword",
+				mcp.Description("Only entries containing this text, ignoring case"),
+			),
+			mcp.WithString("pattern",
+				mcp.Description("Only entries matching this RE2 regular expression, e.g. (?i)deadlock|lock wait"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description(fmt.Sprintf("Maximum number of entries to return (default %d, max %d)", defaultLogLimit, maxLogLimit)),
+			),
+			WithResponseShaping(),
+			WithOutputSchema[instanceLogs](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			ref, err := InstanceRefParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			logType, err := RequiredParam[string](request, "log_type")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if !slices.Contains([]string{logTypeError, logTypeSlow, logTypeRunning, logTypeAudit}, logType) {
+				return mcp.NewToolResultError(fmt.Sprintf("invalid log_type %q: must be error, slow, running or audit", logType)), nil
+			}
+
+			// Get optional parameters
+			start, end, err := timeRangeParams(request, time.Now(), defaultLogsRange, maxLogsRange)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			keyword, err := OptionalParam[string](request, "keyword")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			patternParam, err := OptionalParam[string](request, "pattern")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			var pattern *regexp.Regexp
+			if patternParam != "" {
+				if pattern, err = regexp.Compile(patternParam); err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("invalid pattern %q: %v", patternParam, err)), nil
+				}
+			}
+			limit, err := OptionalIntParamWithDefault(request, "limit", defaultLogLimit)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if limit < 1 || limit > maxLogLimit {
+				return mcp.NewToolResultError(fmt.Sprintf("limit must be between 1 and %d", maxLogLimit)), nil
+			}
+
+			// Get response shaping parameters
+			shaping, err := ResponseShapingParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			ref, instance, err := getInstance(client, ref)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+			query, err := logQueryParams(request, ref, instance, logType, start, end)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			// Filters run here, so scan more entries than are returned
+			query.limit = limit + 1
+			if keyword != "" || pattern != nil {
+				query.limit = maxLogScan
+			}
+
+			// Call KB Cloud API
+			entries, err := queryLogs(client, ref, query)
+			if err != nil {
+				return nil, err
+			}
+
+			result := instanceLogs{
+				InstanceRef: ref.String(),
+				LogType:     logType,
+				Start:       start,
+				End:         end,
+				Scanned:     len(entries),
+				Entries:     []logEntry{},
+			}
+			lower := strings.ToLower(keyword)
+			for _, e := range entries {
+				if keyword != "" && !strings.Contains(strings.ToLower(e.Message), lower) {
+					continue
+				}
+				if pattern != nil && !pattern.MatchString(e.Message) {
+					continue
+				}
+				if len(result.Entries) == limit {
+					result.Truncated = true
+					break
+				}
+				e.Message = truncateText(e.Message, maxLogMessageSize)
+				result.Entries = append(result.Entries, e)
+			}
+
+			if result.Truncated {
+				result.Notices = append(result.Notices, fmt.Sprintf("only the newest %d matching entries are returned; narrow the range or raise limit", limit))
+			}
+			if len(entries) == query.limit && query.limit == maxLogScan {
+				result.Notices = append(result.Notices, fmt.Sprintf("only the newest %d entries of the range were searched; narrow the range to search older ones", maxLogScan))
+			}
+			if len(entries) == 0 && logType == logTypeAudit {
+				result.Notices = append(result.Notices, fmt.Sprintf("%s has no audit log entries in the range; audit logging may be disabled", ref))
+			}
+
+			// Return result
+			return shaping.result(resourceInstanceLogs, result)
+		}
+}
+
+// TopSlowQueries creates a tool to aggregate the slow query log of an
+// instance by statement
+func TopSlowQueries(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("top_slow_queries",
+			mcp.WithDescription("Group the slow query log of a MySQL or PostgreSQL instance by normalized statement fingerprint, "+
+				"with counts and latency percentiles, slowest in total first"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			WithInstanceRef(),
+			withLogRange("24h"),
+			mcp.WithString("order_by",
+				mcp.Description("Rank statements by total time, count, p95 or max latency (default total_time)"),
+				mcp.Enum("total_time", "count", "p95", "max"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description(fmt.Sprintf("Number of statements to return (default %d)", defaultTopQueries)),
+			),
+			WithResponseShaping(),
+			WithOutputSchema[slowQueries](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			ref, err := InstanceRefParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get optional parameters
+			start, end, err := timeRangeParams(request, time.Now(), defaultSlowLogsRange, maxLogsRange)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			orderBy, err := OptionalParam[string](request, "order_by")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if orderBy == "" {
+				orderBy = "total_time"
+			}
+			less, ok := slowQueryOrders[orderBy]
+			if !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("invalid order_by %q: must be total_time, count, p95 or max", orderBy)), nil
+			}
+			limit, err := OptionalIntParamWithDefault(request, "limit", defaultTopQueries)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if limit < 1 || limit > maxLogLimit {
+				return mcp.NewToolResultError(fmt.Sprintf("limit must be between 1 and %d", maxLogLimit)), nil
+			}
+
+			// Get response shaping parameters
+			shaping, err := ResponseShapingParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			ref, instance, err := getInstance(client, ref)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+			family := engineFamily(instance.Engine)
+			if family != engineMySQL && family != enginePostgreSQL {
+				return mcp.NewToolResultError(fmt.Sprintf("top_slow_queries supports MySQL and PostgreSQL; use search_instance_logs with log_type slow for %s instances", instance.Engine)), nil
+			}
+			query, err := logQueryParams(request, ref, instance, logTypeSlow, start, end)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			query.limit = maxLogScan
+
+			// Call KB Cloud API
+			entries, err := queryLogs(client, ref, query)
+			if err != nil {
+				return nil, err
+			}
+
+			result := slowQueries{
+				InstanceRef: ref.String(),
+				Start:       start,
+				End:         end,
+				Scanned:     len(entries),
+				OrderBy:     orderBy,
+				Queries:     []slowQuery{},
+			}
+			groups := map[string]*slowQuery{}
+			latencies := map[string][]float64{}
+			for _, e := range entries {
+				statement, seconds, ok := parseSlowLogEntry(family, e.Message)
+				if !ok {
+					result.Unparsed++
+					continue
+				}
+				fingerprint := fingerprintStatement(family, statement)
+				q, ok := groups[fingerprint]
+				if !ok {
+					q = &slowQuery{Fingerprint: fingerprint, FirstSeen: e.Time, LastSeen: e.Time, Example: truncateText(maskStatement(family, statement), maxExampleSize)}
+					groups[fingerprint] = q
+				}
+				q.Count++
+				q.TotalSeconds += seconds
+				if e.Time.Before(q.FirstSeen) {
+					q.FirstSeen = e.Time
+				}
+				if e.Time.After(q.LastSeen) {
+					q.LastSeen = e.Time
+				}
+				latencies[fingerprint] = append(latencies[fingerprint], seconds)
+			}
+			for fingerprint, q := range groups {
+				l := latencies[fingerprint]
+				slices.Sort(l)
+				q.AvgSeconds = roundMetric(q.TotalSeconds / float64(q.Count))
+				q.TotalSeconds = roundMetric(q.TotalSeconds)
+				q.P50Seconds = roundMetric(percentile(l, 0.5))
+				q.P95Seconds = roundMetric(percentile(l, 0.95))
+				q.MaxSeconds = roundMetric(l[len(l)-1])
+				result.Queries = append(result.Queries, *q)
+			}
+			slices.SortFunc(result.Queries, func(a, b slowQuery) int {
+				if c := less(b, a); c != 0 {
+					return c
+				}
+				return strings.Compare(a.Fingerprint, b.Fingerprint)
+			})
+			if len(result.Queries) > limit {
+				result.Queries = result.Queries[:limit]
+			}
+
+			if len(entries) == maxLogScan {
+				result.Notices = append(result.Notices, fmt.Sprintf("only the newest %d slow log entries of the range were aggregated; narrow the range for complete counts", maxLogScan))
+			}
+			if len(entries) == 0 {
+				result.Notices = append(result.Notices, fmt.Sprintf("%s has no slow log entries in the range; check that the slow query log is enabled with list_instance_parameters", ref))
+			}
+			if result.Unparsed > 0 {
+				result.Notices = append(result.Notices, fmt.Sprintf("%d entries were not recognized as slow statements and were skipped", result.Unparsed))
+			}
+
+			// Return result
+			return shaping.result(resourceSlowQuery, result)
+		}
+}
+
+// slowQueryOrders compare slow queries by each order_by value
+var slowQueryOrders = map[string]func(a, b slowQuery) int{
+	"total_time": func(a, b slowQuery) int { return compareFloat(a.TotalSeconds, b.TotalSeconds) },
+	"count":      func(a, b slowQuery) int { return a.Count - b.Count },
+	"p95":        func(a, b slowQuery) int { return compareFloat(a.P95Seconds, b.P95Seconds) },
+	"max":        func(a, b slowQuery) int { return compareFloat(a.MaxSeconds, b.MaxSeconds) },
+}
+
+// compareFloat compares two floats like cmp.Compare
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// logQueryParams returns the query of an instance log from the component
+// and pod parameters
+func logQueryParams(r mcp.CallToolRequest, ref InstanceRef, instance kbcloud.Cluster, logType string, start, end time.Time) (logQuery, error) {
+	componentName, err := OptionalParam[string](r, "component")
+	if err != nil {
+		return logQuery{}, err
+	}
+	pod, err := OptionalParam[string](r, "pod")
+	if err != nil {
+		return logQuery{}, err
+	}
+	component, err := instanceComponent(ref, instance, componentName)
+	if err != nil {
+		return logQuery{}, err
+	}
+	if prefix := ref.Instance + "-" + component + "-"; pod != "" && !strings.HasPrefix(pod, prefix) {
+		return logQuery{}, fmt.Errorf("pod %q is not a pod of component %s of %s; its pods are named %s<n>", pod, component, ref, prefix)
+	}
+	return logQuery{logType: logType, start: start, end: end, component: component, pod: pod}, nil
+}
+
+// queryLogs returns the entries of an instance log, newest first
+func queryLogs(client *Client, ref InstanceRef, q logQuery) ([]logEntry, error) {
+	start, end := strconv.FormatInt(q.start.Unix(), 10), strconv.FormatInt(q.end.Unix(), 10)
+	limit := strconv.Itoa(q.limit)
+	var instanceName *string
+	if q.pod != "" {
+		instanceName = &q.pod
+	}
+
+	var body any
+	var resp *http.Response
+	var err error
+	switch q.logType {
+	case logTypeError:
+		o := kbcloud.QueryErrorLogsOptionalParameters{ComponentName: &q.component, InstanceName: instanceName, Limit: &limit}
+		body, resp, err = client.ClusterLog.QueryErrorLogs(client.Context, ref.Org, ref.Instance, start, end, *o.WithSortType(kbcloud.SortTypeDesc))
+	case logTypeSlow:
+		o := kbcloud.QuerySlowLogsOptionalParameters{ComponentName: &q.component, InstanceName: instanceName, Limit: &limit}
+		body, resp, err = client.ClusterLog.QuerySlowLogs(client.Context, ref.Org, ref.Instance, start, end, *o.WithSortType(kbcloud.SortTypeDesc))
+	case logTypeRunning:
+		o := kbcloud.QueryRunningLogsOptionalParameters{ComponentName: &q.component, InstanceName: instanceName, Limit: &limit}
+		body, resp, err = client.ClusterLog.QueryRunningLogs(client.Context, ref.Org, ref.Instance, start, end, *o.WithSortType(kbcloud.SortTypeDesc))
+	case logTypeAudit:
+		o := kbcloud.QueryAuditLogsOptionalParameters{ComponentName: &q.component, InstanceName: instanceName, Limit: &limit}
+		body, resp, err = client.ClusterLog.QueryAuditLogs(client.Context, ref.Org, ref.Instance, start, end, *o.WithSortType(kbcloud.SortTypeDesc))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s logs: %w", q.logType, err)
+	}
+	_ = resp.Body.Close()
+
+	entries := parseLogEntries(body, q.component)
+	slices.SortStableFunc(entries, func(a, b logEntry) int { return b.Time.Compare(a.Time) })
+	return entries, nil
+}
+
+// parseLogEntries reads the entries of a KB Cloud log response, a list of
+// entries or an object listing them under items or data
+func parseLogEntries(body any, component string) []logEntry {
+	var items []any
+	switch body := body.(type) {
+	case []any:
+		items = body
+	case map[string]any:
+		for _, key := range []string{"items", "data", "logs"} {
+			if list, ok := body[key].([]any); ok {
+				items = list
+				break
+			}
+		}
+	}
+
+	var entries []logEntry
+	for _, item := range items {
+		fields, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		e := logEntry{
+			Pod:       firstString(fields, "instanceName", "pod", "podName"),
+			Component: firstString(fields, "componentName", "component"),
+			Message:   firstString(fields, "content", "message", "log", "line"),
+		}
+		if e.Component == "" {
+			e.Component = component
+		}
+		if t, err := time.Parse(time.RFC3339Nano, firstString(fields, "timestamp", "time")); err == nil {
+			e.Time = t.UTC()
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// firstString returns the first of the keys of fields holding a string
+func firstString(fields map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if s, ok := fields[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// parseSlowLogEntry returns the statement and latency in seconds of a
+// MySQL or PostgreSQL slow log entry
+func parseSlowLogEntry(family, message string) (string, float64, bool) {
+	switch family {
+	case engineMySQL:
+		m := mysqlQueryTime.FindStringSubmatch(message)
+		if m == nil {
+			return "", 0, false
+		}
+		seconds, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return "", 0, false
+		}
+		// The statement follows the # header lines and the session
+		// statements MySQL logs before it
+		var lines []string
+		for _, line := range strings.Split(message, "\n") {
+			trimmed := strings.TrimSpace(line)
+			lower := strings.ToLower(trimmed)
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(lower, "set timestamp=") || strings.HasPrefix(lower, "use ") {
+				continue
+			}
+			lines = append(lines, trimmed)
+		}
+		statement := strings.Join(lines, " ")
+		return statement, seconds, statement != ""
+	case enginePostgreSQL:
+		m := postgresDuration.FindStringSubmatch(message)
+		if m == nil {
+			return "", 0, false
+		}
+		ms, err := strconv.ParseFloat(m[1], 64)
+		statement := strings.TrimSpace(m[2])
+		return statement, ms / 1000, err == nil && statement != ""
+	default:
+		return "", 0, false
+	}
+}
+
+// sqlStringsOf returns the pattern matching the string literals of an
+// engine family
+func sqlStringsOf(family string) *regexp.Regexp {
+	if family == engineMySQL {
+		return mysqlStrings
+	}
+	return sqlStrings
+}
+
+// maskStatement replaces the string literals of a statement with ?, so
+// examples do not reveal passwords or personal data written in queries
+func maskStatement(family, statement string) string {
+	return sqlStringsOf(family).ReplaceAllString(statement, "?")
+}
+
+// fingerprintStatement normalizes a statement of an engine family so
+// executions differing only in literals, whitespace, comments or the length
+// of value lists group together
+func fingerprintStatement(family, statement string) string {
+	s := sqlComments.ReplaceAllString(statement, " ")
+	s = sqlStringsOf(family).ReplaceAllString(s, "?")
+	s = strings.ToLower(s)
+	s = sqlPlaceholders.ReplaceAllString(s, "?")
+	s = sqlNumbers.ReplaceAllString(s, "?")
+	s = sqlWhitespace.ReplaceAllString(s, " ")
+	s = sqlOperatorSpace.ReplaceAllString(s, "$1$2")
+	s = sqlValueLists.ReplaceAllString(s, "(?+)")
+	s = sqlMultiValues.ReplaceAllString(s, "$1+")
+	s = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(s), ";"))
+	return s
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	return sorted[max(int(math.Ceil(p*float64(len(sorted))))-1, 0)]
+}
+
+// truncateText shortens text to at most size bytes, marking the cut
+func truncateText(text string, size int) string {
+	if len(text) <= size {
+		return text
+	}
+	cut := size
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "…(truncated)"
+}
@@ -0,0 +1,293 @@
+package kbcloud_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud/kbcloudtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// instanceLogs is the JSON result of search_instance_logs
+type instanceLogs struct {
+	Scanned   int  `json:"scanned"`
+	Truncated bool `json:"truncated"`
+	Entries   []struct {
+		Time      string `json:"time"`
+		Pod       string `json:"pod"`
+		Component string `json:"component"`
+		Message   string `json:"message"`
+	} `json:"entries"`
+	Notices []string `json:"notices"`
+}
+
+// slowQueries is the JSON result of top_slow_queries
+type slowQueries struct {
+	Scanned  int `json:"scanned"`
+	Unparsed int `json:"unparsed"`
+	Queries  []struct {
+		Fingerprint  string  `json:"fingerprint"`
+		Count        int     `json:"count"`
+		TotalSeconds float64 `json:"total_seconds"`
+		Example      string  `json:"example"`
+	} `json:"queries"`
+	Notices []string `json:"notices"`
+}
+
+// logArgs returns the arguments of a log query of the hour after the
+// fixture time
+func logArgs(instanceRef string, extra map[string]any) map[string]any {
+	args := map[string]any{"instance_ref": instanceRef, "start": "2024-01-15T08:00:00Z", "end": "2024-01-15T09:00:00Z"}
+	for k, v := range extra {
+		args[k] = v
+	}
+	return args
+}
+
+func TestSearchInstanceLogs(t *testing.T) {
+	runToolTests(t, kbcloud.SearchInstanceLogs, []toolTest{
+		{
+			name: "keyword",
+			args: logArgs("acme/prod/orders-db", map[string]any{"log_type": "error", "keyword": "DEADLOCK"}),
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"instance_ref":"acme/prod/orders-db","log_type":"error",
+					"start":"2024-01-15T08:00:00Z","end":"2024-01-15T09:00:00Z","scanned":4,"truncated":false,
+					"entries":[
+						{"time":"2024-01-15T08:41:00Z","pod":"orders-db-mysql-0","component":"mysql",
+							"message":"[ERROR] [MY-012574] [InnoDB] Deadlock found when trying to get lock; try restarting transaction"},
+						{"time":"2024-01-15T08:20:00Z","pod":"orders-db-mysql-0","component":"mysql",
+							"message":"[ERROR] [MY-012574] [InnoDB] Deadlock found when trying to get lock; try restarting transaction"}
+					]}`, text)
+			},
+		},
+		{
+			name: "pattern",
+			args: logArgs("acme/prod/orders-db", map[string]any{"log_type": "error", "pattern": `(?i)aborted|could not be resolved`}),
+			check: func(t *testing.T, text string) {
+				result := decode[instanceLogs](t, text)
+				require.Len(t, result.Entries, 2)
+				assert.Contains(t, result.Entries[0].Message, "Aborted connection")
+				assert.Contains(t, result.Entries[1].Message, "could not be resolved")
+			},
+		},
+		{
+			name: "pod",
+			args: logArgs("acme/prod/orders-db", map[string]any{"log_type": "error", "pod": "orders-db-mysql-1"}),
+			check: func(t *testing.T, text string) {
+				result := decode[instanceLogs](t, text)
+				require.Len(t, result.Entries, 1)
+				assert.Equal(t, "orders-db-mysql-1", result.Entries[0].Pod)
+			},
+		},
+		{
+			name: "limit",
+			args: logArgs("acme/prod/orders-db", map[string]any{"log_type": "error", "limit": 1.0}),
+			check: func(t *testing.T, text string) {
+				result := decode[instanceLogs](t, text)
+				require.Len(t, result.Entries, 1)
+				assert.Equal(t, "2024-01-15T08:41:00Z", result.Entries[0].Time)
+				assert.True(t, result.Truncated)
+				assert.Equal(t, []string{"only the newest 1 matching entries are returned; narrow the range or raise limit"}, result.Notices)
+			},
+		},
+		{
+			name: "empty audit log",
+			args: logArgs("acme/prod/orders-db", map[string]any{"log_type": "audit"}),
+			check: func(t *testing.T, text string) {
+				result := decode[instanceLogs](t, text)
+				assert.Empty(t, result.Entries)
+				assert.Equal(t, []string{"acme/prod/orders-db has no audit log entries in the range; audit logging may be disabled"}, result.Notices)
+			},
+		},
+		{
+			name: "yaml",
+			args: logArgs("acme/prod/orders-db", map[string]any{"log_type": "error", "keyword": "DEADLOCK", "fields": "entries.time", "format": "yaml"}),
+			check: func(t *testing.T, text string) {
+				assert.Equal(t, "entries:\n    - time: \"2024-01-15T08:41:00Z\"\n    - time: \"2024-01-15T08:20:00Z\"\n", text)
+			},
+		},
+		{
+			name:        "invalid log type",
+			args:        logArgs("acme/prod/orders-db", map[string]any{"log_type": "binlog"}),
+			wantToolErr: `invalid log_type "binlog"`,
+		},
+		{
+			name:        "invalid pattern",
+			args:        logArgs("acme/prod/orders-db", map[string]any{"log_type": "error", "pattern": "dead(lock"}),
+			wantToolErr: `invalid pattern "dead(lock"`,
+		},
+		{
+			name:        "pod of another instance",
+			args:        logArgs("acme/prod/orders-db", map[string]any{"log_type": "error", "pod": "cache-redis-0"}),
+			wantToolErr: `pod "cache-redis-0" is not a pod of component mysql of acme/prod/orders-db`,
+		},
+		{
+			name:        "long range",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "log_type": "error", "start": "8d"},
+			wantToolErr: "longer than 7d",
+		},
+		{
+			name:        "too large limit",
+			args:        logArgs("acme/prod/orders-db", map[string]any{"log_type": "error", "limit": 1000.0}),
+			wantToolErr: "limit must be between 1 and 500",
+		},
+	})
+
+	t.Run("query", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		_, handler := kbcloud.SearchInstanceLogs(s.GetClientFn())
+
+		result, err := callTool(handler, logArgs("acme/prod/orders-db", map[string]any{"log_type": "slow"}))
+		require.NoError(t, err)
+		require.False(t, result.IsError, resultText(t, result))
+
+		var found bool
+		for _, r := range s.Requests() {
+			if r.Path != "/api/v1/organizations/acme/clusters/orders-db/logs/slow" {
+				continue
+			}
+			found = true
+			assert.Equal(t, "1705305600", r.Query.Get("startTime"))
+			assert.Equal(t, "1705309200", r.Query.Get("endTime"))
+			assert.Equal(t, "mysql", r.Query.Get("componentName"))
+			assert.Equal(t, "desc", r.Query.Get("sortType"))
+			assert.Equal(t, "51", r.Query.Get("limit"))
+		}
+		assert.True(t, found, "slow log not queried")
+	})
+
+	t.Run("failed query", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		s.InjectError("GET", "/api/v1/organizations/acme/clusters/orders-db/logs/error", 500, "log store unavailable")
+		_, handler := kbcloud.SearchInstanceLogs(s.GetClientFn())
+
+		_, err := callTool(handler, logArgs("acme/prod/orders-db", map[string]any{"log_type": "error"}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to query error logs")
+	})
+}
+
+func TestTopSlowQueries(t *testing.T) {
+	runToolTests(t, kbcloud.TopSlowQueries, []toolTest{
+		{
+			name: "mysql",
+			args: logArgs("acme/prod/orders-db", nil),
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"instance_ref":"acme/prod/orders-db",
+					"start":"2024-01-15T08:00:00Z","end":"2024-01-15T09:00:00Z",
+					"scanned":5,"unparsed":1,"order_by":"total_time",
+					"queries":[
+						{"fingerprint":"update inventory set stock=stock - ? where sku in(?+)","count":1,
+							"total_seconds":12,"avg_seconds":12,"p50_seconds":12,"p95_seconds":12,"max_seconds":12,
+							"first_seen":"2024-01-15T08:45:00Z","last_seen":"2024-01-15T08:45:00Z",
+							"example":"UPDATE inventory SET stock = stock - 1 WHERE sku IN (?, ?, ?);"},
+						{"fingerprint":"select * from orders where customer_id=?","count":3,
+							"total_seconds":9.6,"avg_seconds":3.2,"p50_seconds":3.1,"p95_seconds":4,"max_seconds":4,
+							"first_seen":"2024-01-15T08:10:00Z","last_seen":"2024-01-15T08:50:00Z",
+							"example":"select * from orders where customer_id=7 /* api */;"}
+					],
+					"notices":["1 entries were not recognized as slow statements and were skipped"]}`, text)
+			},
+		},
+		{
+			name: "order by count",
+			args: logArgs("acme/prod/orders-db", map[string]any{"order_by": "count", "limit": 1.0}),
+			check: func(t *testing.T, text string) {
+				result := decode[slowQueries](t, text)
+				require.Len(t, result.Queries, 1)
+				assert.Equal(t, "select * from orders where customer_id=?", result.Queries[0].Fingerprint)
+			},
+		},
+		{
+			name: "postgresql",
+			args: logArgs("acme/staging/analytics", nil),
+			check: func(t *testing.T, text string) {
+				result := decode[slowQueries](t, text)
+				require.Len(t, result.Queries, 1)
+				assert.Equal(t, "select count(*) from events where kind=? and ts>?", result.Queries[0].Fingerprint)
+				assert.Equal(t, 2, result.Queries[0].Count)
+				assert.Equal(t, 4.0, result.Queries[0].TotalSeconds)
+			},
+		},
+		{
+			name: "no slow queries",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "start": "2024-01-14T08:00:00Z", "end": "2024-01-14T09:00:00Z"},
+			check: func(t *testing.T, text string) {
+				result := decode[slowQueries](t, text)
+				assert.Empty(t, result.Queries)
+				assert.Contains(t, result.Notices[0], "has no slow log entries in the range")
+			},
+		},
+		{
+			name: "yaml summary",
+			args: logArgs("acme/staging/analytics", map[string]any{"view": "summary", "format": "yaml"}),
+			check: func(t *testing.T, text string) {
+				assert.Equal(t, "instance_ref: acme/staging/analytics\norder_by: total_time\nqueries:\n"+
+					"    - avg_seconds: 2\n      count: 2\n      fingerprint: select count(*) from events where kind=? and ts>?\n"+
+					"      max_seconds: 2.5\n      total_seconds: 4\n", text)
+			},
+		},
+		{
+			name:        "unsupported engine",
+			args:        logArgs("acme/prod/cache", nil),
+			wantToolErr: "top_slow_queries supports MySQL and PostgreSQL",
+		},
+		{
+			name:        "invalid order",
+			args:        logArgs("acme/prod/orders-db", map[string]any{"order_by": "rows"}),
+			wantToolErr: `invalid order_by "rows"`,
+		},
+	})
+
+	t.Run("postgresql quoted identifiers", func(t *testing.T) {
+		f := kbcloudtest.DefaultFixtures()
+		at := time.Date(2024, time.January, 15, 8, 40, 0, 0, time.UTC)
+		for _, table := range []string{"Clicks", "Views"} {
+			f.Logs["acme/analytics"]["slow"] = append(f.Logs["acme/analytics"]["slow"], kbcloudtest.LogEntry{
+				Time:      at,
+				Component: "postgresql",
+				Pod:       "analytics-postgresql-0",
+				Content:   `LOG:  duration: 100 ms  statement: SELECT * FROM "` + table + `" WHERE "user id" = 'u-1'`,
+			})
+		}
+		s := kbcloudtest.NewServer(t, kbcloudtest.WithFixtures(f))
+		_, handler := kbcloud.TopSlowQueries(s.GetClientFn())
+
+		result, err := callTool(handler, logArgs("acme/staging/analytics", nil))
+		require.NoError(t, err)
+		text := resultText(t, result)
+		require.False(t, result.IsError, text)
+
+		var fingerprints []string
+		for _, q := range decode[slowQueries](t, text).Queries {
+			fingerprints = append(fingerprints, q.Fingerprint)
+		}
+		assert.Contains(t, fingerprints, `select * from "clicks" where "user id"=?`)
+		assert.Contains(t, fingerprints, `select * from "views" where "user id"=?`)
+	})
+
+	t.Run("examples hide string literals", func(t *testing.T) {
+		f := kbcloudtest.DefaultFixtures()
+		f.Logs["acme/orders-db"]["slow"] = append(f.Logs["acme/orders-db"]["slow"], kbcloudtest.LogEntry{
+			Time:      time.Date(2024, time.January, 15, 8, 30, 0, 0, time.UTC),
+			Component: "mysql",
+			Pod:       "orders-db-mysql-0",
+			Content: "# Query_time: 30.000000  Lock_time: 0.000100 Rows_sent: 0  Rows_examined: 0\n" +
+				`ALTER USER 'app'@'%' IDENTIFIED BY 'Tr0ub4dor&3';`,
+		})
+		s := kbcloudtest.NewServer(t, kbcloudtest.WithFixtures(f))
+		_, handler := kbcloud.TopSlowQueries(s.GetClientFn())
+
+		result, err := callTool(handler, logArgs("acme/prod/orders-db", nil))
+		require.NoError(t, err)
+		text := resultText(t, result)
+		require.False(t, result.IsError, text)
+
+		assert.NotContains(t, text, "Tr0ub4dor")
+		queries := decode[slowQueries](t, text).Queries
+		require.NotEmpty(t, queries)
+		assert.Equal(t, "ALTER USER ?@? IDENTIFIED BY ?;", queries[0].Example)
+	})
+}
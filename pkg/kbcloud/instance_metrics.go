@@ -0,0 +1,395 @@
+package kbcloud
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Limits of get_instance_metrics queries, which keep results small
+const (
+	defaultMetricsRange = time.Hour
+	maxMetricsRange     = 30 * 24 * time.Hour
+	minMetricsStep      = 15 * time.Second
+	defaultMetricPoints = 60
+	maxMetricPoints     = 240
+)
+
+// instanceMetric is a metric get_instance_metrics can query. Queries are
+// PromQL templates taking the label selector of the component, keyed by
+// engine family; the "" key applies to every family.
+type instanceMetric struct {
+	name    string
+	unit    string
+	queries map[string]string
+}
+
+// instanceMetrics are the metrics get_instance_metrics can query, in the
+// order they are returned. Container metrics select pods by name, engine
+// metrics by the labels KubeBlocks sets on the exporters.
+var instanceMetrics = []instanceMetric{
+	{name: "cpu", unit: "cores", queries: map[string]string{
+		"": `sum(rate(container_cpu_usage_seconds_total{%[1]s}[1m]))`,
+	}},
+	{name: "memory", unit: "bytes", queries: map[string]string{
+		"": `sum(container_memory_working_set_bytes{%[1]s})`,
+	}},
+	{name: "disk", unit: "percent", queries: map[string]string{
+		"": `max(kubelet_volume_stats_used_bytes{%[2]s} / kubelet_volume_stats_capacity_bytes{%[2]s}) * 100`,
+	}},
+	{name: "iops", unit: "ops/s", queries: map[string]string{
+		"": `sum(rate(container_fs_reads_total{%[1]s}[1m]) + rate(container_fs_writes_total{%[1]s}[1m]))`,
+	}},
+	{name: "connections", unit: "connections", queries: map[string]string{
+		engineMySQL:      `sum(mysql_global_status_threads_connected{%[3]s})`,
+		enginePostgreSQL: `sum(pg_stat_activity_count{%[3]s})`,
+		engineRedis:      `sum(redis_connected_clients{%[3]s})`,
+		engineMongoDB:    `sum(mongodb_ss_connections{conn_type="current",%[3]s})`,
+	}},
+	{name: "qps", unit: "queries/s", queries: map[string]string{
+		engineMySQL:   `sum(rate(mysql_global_status_queries{%[3]s}[1m]))`,
+		engineRedis:   `sum(rate(redis_commands_processed_total{%[3]s}[1m]))`,
+		engineMongoDB: `sum(rate(mongodb_ss_opcounters{%[3]s}[1m]))`,
+	}},
+	{name: "tps", unit: "transactions/s", queries: map[string]string{
+		engineMySQL:      `sum(rate(mysql_global_status_commands_total{command=~"commit|rollback",%[3]s}[1m]))`,
+		enginePostgreSQL: `sum(rate(pg_stat_database_xact_commit{%[3]s}[1m]) + rate(pg_stat_database_xact_rollback{%[3]s}[1m]))`,
+	}},
+	{name: "replication_lag", unit: "seconds", queries: map[string]string{
+		engineMySQL:      `max(mysql_slave_status_seconds_behind_master{%[3]s})`,
+		enginePostgreSQL: `max(pg_replication_lag{%[3]s})`,
+		engineRedis:      `max(redis_connected_slave_lag_seconds{%[3]s})`,
+		engineMongoDB:    `max(mongodb_mongod_replset_member_replication_lag{%[3]s})`,
+	}},
+}
+
+// metricPoint is a downsampled value of a metric, the average of the
+// samples in the step starting at Time
+type metricPoint struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// metricSummary summarizes every sample of a metric in the queried range
+type metricSummary struct {
+	Samples int     `json:"samples"`
+	Min     float64 `json:"min"`
+	Avg     float64 `json:"avg"`
+	Max     float64 `json:"max"`
+	P95     float64 `json:"p95"`
+	Last    float64 `json:"last"`
+}
+
+// metricSeries is a queried metric of an instance component
+type metricSeries struct {
+	Name    string         `json:"name"`
+	Unit    string         `json:"unit"`
+	Summary *metricSummary `json:"summary,omitempty"`
+	Points  []metricPoint  `json:"points"`
+}
+
+// instanceMetricsResult is the result of get_instance_metrics
+type instanceMetricsResult struct {
+	InstanceRef string         `json:"instance_ref"`
+	Component   string         `json:"component"`
+	Start       time.Time      `json:"start"`
+	End         time.Time      `json:"end"`
+	Step        string         `json:"step"`
+	Metrics     []metricSeries `json:"metrics"`
+	Notices     []string       `json:"notices,omitempty"`
+}
+
+// metricSample is a raw sample of a metric
+type metricSample struct {
+	time  time.Time
+	value float64
+}
+
+// GetInstanceMetrics creates a tool to query the monitoring metrics of an
+// instance
+func GetInstanceMetrics(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	names := make([]string, 0, len(instanceMetrics))
+	for _, m := range instanceMetrics {
+		names = append(names, m.name)
+	}
+	return mcp.NewTool("get_instance_metrics",
+			mcp.WithDescription("Query CPU, memory, disk, IOPS, connections, QPS/TPS and replication lag of an instance over a time range. "+
+				"Returns series downsampled to one point per step with min/avg/max/p95 summaries; use it to tell whether an instance is under pressure"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			WithInstanceRef(),
+			mcp.WithArray("metrics",
+				mcp.Description("Metrics to query; every metric available for the engine when omitted"),
+				mcp.WithStringEnumItems(names),
+			),
+			mcp.WithString("start",
+				mcp.Description("Start of the range as an RFC 3339 time or a duration before end such as 30m, 6h or 7d (default 1h)"),
+			),
+			mcp.WithString("end",
+				mcp.Description("End of the range as an RFC 3339 time or a duration before now (default now)"),
+			),
+			mcp.WithString("step",
+				mcp.Description(fmt.Sprintf("Width of each point, e.g. 5m; by default the range is split into %d points", defaultMetricPoints)),
+			),
+			mcp.WithString("component",
+				mcp.Description("Component to query; defaults to the main one"),
+			),
+			mcp.WithBoolean("summary_only",
+				mcp.Description("Return only the summaries, without points (default false)"),
+			),
+			WithResponseShaping(),
+			WithOutputSchema[instanceMetricsResult](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			ref, err := InstanceRefParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get optional parameters
+			requested, err := OptionalStringArrayParam(request, "metrics")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			for _, name := range requested {
+				if slices.Contains(names, name) {
+					continue
+				}
+				if suggestions := suggest(name, names); len(suggestions) > 0 {
+					return mcp.NewToolResultError(fmt.Sprintf("unknown metric %q; did you mean %s?", name, strings.Join(suggestions, ", "))), nil
+				}
+				return mcp.NewToolResultError(fmt.Sprintf("unknown metric %q: must be one of %s", name, strings.Join(names, ", "))), nil
+			}
+			start, end, step, err := metricsRangeParams(request, time.Now())
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			componentName, err := OptionalParam[string](request, "component")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			summaryOnly, err := OptionalParam[bool](request, "summary_only")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get response shaping parameters
+			shaping, err := ResponseShapingParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			ref, instance, err := getInstance(client, ref)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+			component, err := instanceComponent(ref, instance, componentName)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			result := instanceMetricsResult{
+				InstanceRef: ref.String(),
+				Component:   component,
+				Start:       start,
+				End:         end,
+				Step:        step.String(),
+				Metrics:     []metricSeries{},
+			}
+			if status := instance.GetStatus(); status != "" && status != "Running" {
+				result.Notices = append(result.Notices, fmt.Sprintf("%s is %s, so recent samples may be missing", ref, status))
+			}
+
+			// Call KB Cloud API
+			family := engineFamily(instance.Engine)
+			var firstErr error
+			for _, m := range instanceMetrics {
+				if len(requested) > 0 && !slices.Contains(requested, m.name) {
+					continue
+				}
+				query, ok := m.query(family, ref.Instance, component)
+				if !ok {
+					if len(requested) > 0 {
+						result.Notices = append(result.Notices, fmt.Sprintf("%s is not available for engine %s", m.name, instance.Engine))
+					}
+					continue
+				}
+
+				samples, err := queryMetric(client, ref, query, start, end)
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+					result.Notices = append(result.Notices, fmt.Sprintf("%s: %v", m.name, err))
+					continue
+				}
+				series := metricSeries{Name: m.name, Unit: m.unit, Points: []metricPoint{}}
+				if len(samples) == 0 {
+					result.Notices = append(result.Notices, fmt.Sprintf("%s has no samples in the range", m.name))
+				} else {
+					series.Summary = summarizeSamples(samples)
+					if !summaryOnly {
+						series.Points = downsample(samples, start, end, step)
+					}
+				}
+				result.Metrics = append(result.Metrics, series)
+			}
+			if len(result.Metrics) == 0 && firstErr != nil {
+				return nil, firstErr
+			}
+
+			// Return result
+			return shaping.result(resourceInstanceMetrics, result)
+		}
+}
+
+// query returns the PromQL query of m for an instance component, or false
+// when m is not available for the engine family
+func (m instanceMetric) query(family, instance, component string) (string, bool) {
+	template, ok := m.queries[family]
+	if !ok {
+		if template, ok = m.queries[""]; !ok {
+			return "", false
+		}
+	}
+	pods := fmt.Sprintf(`pod=~"%s-%s-[0-9]+",container!=""`, instance, component)
+	volumes := fmt.Sprintf(`persistentvolumeclaim=~"data-%s-%s-[0-9]+"`, instance, component)
+	labels := fmt.Sprintf(`app_kubernetes_io_instance="%s",apps_kubeblocks_io_component_name="%s"`, instance, component)
+	return fmt.Sprintf(template, pods, volumes, labels), true
+}
+
+// queryMetric returns the samples of a range query, oldest first, skipping
+// samples that are not numbers
+func queryMetric(client *Client, ref InstanceRef, query string, start, end time.Time) ([]metricSample, error) {
+	values, resp, err := client.Cluster.QueryClusterMetrics(client.Context, ref.Org, ref.Instance, query,
+		kbcloud.MetricsQueryTypeRange, start.Unix(), end.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metrics: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	var samples []metricSample
+	for _, v := range values.Values {
+		if len(v) != 2 {
+			continue
+		}
+		ts, ok := sampleNumber(v[0])
+		if !ok {
+			continue
+		}
+		value, ok := sampleNumber(v[1])
+		if !ok || math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		sec, frac := math.Modf(ts)
+		samples = append(samples, metricSample{time: time.Unix(int64(sec), int64(frac*1e9)).UTC(), value: value})
+	}
+	slices.SortStableFunc(samples, func(a, b metricSample) int { return a.time.Compare(b.time) })
+	return samples, nil
+}
+
+// sampleNumber returns a timestamp or value of a Prometheus sample, which
+// values encode as strings
+func sampleNumber(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// summarizeSamples returns the summary of at least one sample
+func summarizeSamples(samples []metricSample) *metricSummary {
+	values := make([]float64, len(samples))
+	sum := 0.0
+	for i, s := range samples {
+		values[i] = s.value
+		sum += s.value
+	}
+	slices.Sort(values)
+	p95 := percentile(values, 0.95)
+	return &metricSummary{
+		Samples: len(values),
+		Min:     roundMetric(values[0]),
+		Avg:     roundMetric(sum / float64(len(values))),
+		Max:     roundMetric(values[len(values)-1]),
+		P95:     roundMetric(p95),
+		Last:    roundMetric(samples[len(samples)-1].value),
+	}
+}
+
+// downsample averages the samples of each step from start, dropping steps
+// without samples. Samples at end belong to the last step.
+func downsample(samples []metricSample, start, end time.Time, step time.Duration) []metricPoint {
+	points := []metricPoint{}
+	sum, n := 0.0, 0
+	var bucket time.Time
+	for _, s := range samples {
+		offset := min(max(s.time.Sub(start), 0), end.Sub(start)-1)
+		b := start.Add(offset.Truncate(step))
+		if n > 0 && !b.Equal(bucket) {
+			points = append(points, metricPoint{Time: bucket, Value: roundMetric(sum / float64(n))})
+			sum, n = 0, 0
+		}
+		bucket = b
+		sum += s.value
+		n++
+	}
+	if n > 0 {
+		points = append(points, metricPoint{Time: bucket, Value: roundMetric(sum / float64(n))})
+	}
+	return points
+}
+
+// roundMetric rounds a metric value to three decimals, which is plenty for
+// judging load and keeps results short
+func roundMetric(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+// metricsRangeParams returns the start, end and step parameters of a
+// metrics query, applying the defaults and limits
+func metricsRangeParams(r mcp.CallToolRequest, now time.Time) (start, end time.Time, step time.Duration, err error) {
+	start, end, err = timeRangeParams(r, now, defaultMetricsRange, maxMetricsRange)
+	if err != nil {
+		return start, end, step, err
+	}
+
+	stepParam, err := OptionalParam[string](r, "step")
+	if err != nil {
+		return start, end, step, err
+	}
+	if stepParam == "" {
+		step = max(end.Sub(start)/defaultMetricPoints, minMetricsStep).Round(time.Second)
+		return start, end, step, nil
+	}
+	if step, err = parseDuration(stepParam); err != nil {
+		return start, end, step, fmt.Errorf("invalid step %q: expected a duration such as 30s, 5m or 1h", stepParam)
+	}
+	if step < minMetricsStep {
+		return start, end, step, fmt.Errorf("step %s is shorter than the minimum of %s", stepParam, minMetricsStep)
+	}
+	if points := int(end.Sub(start) / step); points > maxMetricPoints {
+		least := (end.Sub(start) / maxMetricPoints).Round(time.Second)
+		return start, end, step, fmt.Errorf("step %s gives %d points, more than the maximum of %d; use a step of at least %s", stepParam, points, maxMetricPoints, least)
+	}
+	return start, end, step, nil
+}
@@ -0,0 +1,176 @@
+package kbcloud_test
+
+import (
+	"testing"
+
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud/kbcloudtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// instanceMetrics is the JSON result of get_instance_metrics
+type instanceMetrics struct {
+	Component string `json:"component"`
+	Step      string `json:"step"`
+	Metrics   []struct {
+		Name    string `json:"name"`
+		Unit    string `json:"unit"`
+		Summary *struct {
+			Samples int     `json:"samples"`
+			Min     float64 `json:"min"`
+			Avg     float64 `json:"avg"`
+			Max     float64 `json:"max"`
+			P95     float64 `json:"p95"`
+			Last    float64 `json:"last"`
+		} `json:"summary"`
+		Points []struct {
+			Time  string  `json:"time"`
+			Value float64 `json:"value"`
+		} `json:"points"`
+	} `json:"metrics"`
+	Notices []string `json:"notices"`
+}
+
+func TestGetInstanceMetrics(t *testing.T) {
+	hour := map[string]any{"start": "2024-01-15T08:00:00Z", "end": "2024-01-15T09:00:00Z"}
+	args := func(extra map[string]any) map[string]any {
+		a := map[string]any{"instance_ref": "acme/prod/orders-db"}
+		for k, v := range hour {
+			a[k] = v
+		}
+		for k, v := range extra {
+			a[k] = v
+		}
+		return a
+	}
+
+	runToolTests(t, kbcloud.GetInstanceMetrics, []toolTest{
+		{
+			name: "yaml summary",
+			args: args(map[string]any{"metrics": []any{"cpu"}, "view": "summary", "format": "yaml"}),
+			check: func(t *testing.T, text string) {
+				assert.Equal(t, "component: mysql\nend: \"2024-01-15T09:00:00Z\"\ninstance_ref: acme/prod/orders-db\n"+
+					"metrics:\n    - name: cpu\n      summary:\n        avg: 0.621\n        last: 0.4\n        max: 1.9\n        min: 0.2\n"+
+					"        p95: 1.9\n        samples: 12\n      unit: cores\nstart: \"2024-01-15T08:00:00Z\"\n", text)
+			},
+		},
+		{
+			name: "downsampled cpu",
+			args: args(map[string]any{"metrics": []any{"cpu"}, "step": "15m"}),
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"instance_ref":"acme/prod/orders-db","component":"mysql",
+					"start":"2024-01-15T08:00:00Z","end":"2024-01-15T09:00:00Z","step":"15m0s",
+					"metrics":[{"name":"cpu","unit":"cores",
+						"summary":{"samples":12,"min":0.2,"avg":0.621,"max":1.9,"p95":1.9,"last":0.4},
+						"points":[
+							{"time":"2024-01-15T08:00:00Z","value":0.25},
+							{"time":"2024-01-15T08:15:00Z","value":0.35},
+							{"time":"2024-01-15T08:30:00Z","value":0.517},
+							{"time":"2024-01-15T08:45:00Z","value":1.367}
+						]}]}`, text)
+			},
+		},
+		{
+			name: "every metric of the engine",
+			args: args(map[string]any{"summary_only": true}),
+			check: func(t *testing.T, text string) {
+				result := decode[instanceMetrics](t, text)
+				assert.Equal(t, "1m0s", result.Step)
+				var names []string
+				for _, m := range result.Metrics {
+					names = append(names, m.Name)
+					assert.Empty(t, m.Points)
+				}
+				assert.Equal(t, []string{"cpu", "memory", "disk", "iops", "connections", "qps", "tps", "replication_lag"}, names)
+				require.NotNil(t, result.Metrics[4].Summary)
+				assert.Equal(t, 150.0, result.Metrics[4].Summary.Max)
+				assert.Contains(t, result.Notices, "replication_lag has no samples in the range")
+			},
+		},
+		{
+			name: "metric of another engine",
+			args: map[string]any{"instance_ref": "acme/prod/cache", "metrics": []any{"connections", "tps"}},
+			check: func(t *testing.T, text string) {
+				result := decode[instanceMetrics](t, text)
+				require.Len(t, result.Metrics, 1)
+				assert.Equal(t, "connections", result.Metrics[0].Name)
+				assert.Equal(t, []string{"connections has no samples in the range", "tps is not available for engine redis"}, result.Notices)
+			},
+		},
+		{
+			name: "stopped instance",
+			args: map[string]any{"instance_ref": "acme/staging/analytics", "metrics": []any{"tps"}},
+			check: func(t *testing.T, text string) {
+				assert.Contains(t, decode[instanceMetrics](t, text).Notices, "acme/staging/analytics is Stopped, so recent samples may be missing")
+			},
+		},
+		{
+			name:        "misspelled metric",
+			args:        args(map[string]any{"metrics": []any{"memroy"}}),
+			wantToolErr: `unknown metric "memroy"; did you mean memory?`,
+		},
+		{
+			name:        "too many points",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "start": "7d", "step": "1m"},
+			wantToolErr: "step 1m gives 10080 points, more than the maximum of 240; use a step of at least 42m0s",
+		},
+		{
+			name:        "short step",
+			args:        args(map[string]any{"step": "5s"}),
+			wantToolErr: "step 5s is shorter than the minimum of 15s",
+		},
+		{
+			name:        "long range",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "start": "31d"},
+			wantToolErr: "longer than 30d",
+		},
+		{
+			name:        "start after end",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "start": "2024-01-15T10:00:00Z", "end": "2024-01-15T09:00:00Z"},
+			wantToolErr: "start 2024-01-15T10:00:00Z must be before end 2024-01-15T09:00:00Z",
+		},
+		{
+			name:        "invalid start",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "start": "yesterday"},
+			wantToolErr: `invalid start "yesterday"`,
+		},
+		{
+			name:        "unknown component",
+			args:        args(map[string]any{"component": "proxy"}),
+			wantToolErr: `component "proxy" not found on acme/prod/orders-db`,
+		},
+	})
+
+	t.Run("queries select the component", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		_, handler := kbcloud.GetInstanceMetrics(s.GetClientFn())
+
+		result, err := callTool(handler, args(map[string]any{"metrics": []any{"cpu", "connections"}}))
+		require.NoError(t, err)
+		require.False(t, result.IsError, resultText(t, result))
+
+		var queries []string
+		for _, r := range s.Requests() {
+			if q := r.Query.Get("query"); q != "" {
+				queries = append(queries, q)
+				assert.Equal(t, "1705305600", r.Query.Get("start"))
+				assert.Equal(t, "1705309200", r.Query.Get("end"))
+			}
+		}
+		assert.Equal(t, []string{
+			`sum(rate(container_cpu_usage_seconds_total{pod=~"orders-db-mysql-[0-9]+",container!=""}[1m]))`,
+			`sum(mysql_global_status_threads_connected{app_kubernetes_io_instance="orders-db",apps_kubeblocks_io_component_name="mysql"})`,
+		}, queries)
+	})
+
+	t.Run("failed queries", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		s.InjectError("GET", "/api/v1/organizations/acme/clusters/orders-db/metrics", 500, "monitoring unavailable")
+		_, handler := kbcloud.GetInstanceMetrics(s.GetClientFn())
+
+		_, err := callTool(handler, args(map[string]any{"metrics": []any{"cpu"}}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to query metrics")
+	})
+}
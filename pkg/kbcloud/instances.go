@@ -2,11 +2,13 @@ package kbcloud
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
+	"strings"
 
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
@@ -15,23 +17,24 @@ import (
 func ListInstances(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
 	return mcp.NewTool("list_instances",
 			mcp.WithDescription("List all instances within a KB Cloud environment"),
+			mcp.WithReadOnlyHintAnnotation(true),
 			mcp.WithString("org_name",
-				mcp.Required(),
-				mcp.Description("Organization name"),
+				mcp.Description("Organization name; defaults to the session context"),
 			),
 			mcp.WithString("env_name",
-				mcp.Required(),
-				mcp.Description("Environment name"),
+				mcp.Description("Environment name; defaults to the session context"),
 			),
 			WithPagination(),
+			WithResponseShaping(),
+			WithOutputSchema[kbcloud.ClusterList](),
 		),
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			// Get required parameters
-			orgName, err := RequiredParam[string](request, "org_name")
+			orgName, err := orgParam(ctx, request, "org_name")
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
-			envName, err := RequiredParam[string](request, "env_name")
+			envName, err := envParam(ctx, request, orgName)
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
@@ -42,14 +45,22 @@ func ListInstances(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHan
 				return mcp.NewToolResultError(err.Error()), nil
 			}
 
+			// Get response shaping parameters
+			shaping, err := ResponseShapingParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
 			// Get KB Cloud client
 			client, err := getClient(ctx)
 			if err != nil {
 				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
 			}
 
-			// Call KB Cloud API - using ClusterApi's ListInstance method
-			instances, resp, err := client.Cluster.ListInstance(client.Context, orgName, envName)
+			// Call KB Cloud API - using ClusterApi's ListCluster method filtered by environment
+			// Note: In KB Cloud API, instances are referred to as clusters
+			opts := kbcloud.NewListClusterOptionalParameters().WithEnvironmentName(envName)
+			instances, resp, err := client.Cluster.ListCluster(client.Context, orgName, *opts)
 			if err != nil {
 				return nil, fmt.Errorf("failed to list instances: %w", err)
 			}
@@ -64,13 +75,11 @@ func ListInstances(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHan
 				return mcp.NewToolResultError(fmt.Sprintf("failed to list instances: %s", string(body))), nil
 			}
 
-			// Return result
-			result, err := json.Marshal(instances)
-			if err != nil {
-				return nil, fmt.Errorf("failed to marshal response: %w", err)
-			}
+			// Apply pagination
+			instances.Items = paginate(instances.Items, pagination)
 
-			return mcp.NewToolResultText(string(result)), nil
+			// Return result
+			return shaping.result(resourceInstance, instances)
 		}
 }
 
@@ -78,30 +87,20 @@ func ListInstances(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHan
 func GetInstance(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
 	return mcp.NewTool("get_instance",
 			mcp.WithDescription("Get details of a specific instance in KB Cloud"),
-			mcp.WithString("org_name",
-				mcp.Required(),
-				mcp.Description("Organization name"),
-			),
-			mcp.WithString("env_name",
-				mcp.Required(),
-				mcp.Description("Environment name"),
-			),
-			mcp.WithString("instance_name",
-				mcp.Required(),
-				mcp.Description("Instance name"),
-			),
+			mcp.WithReadOnlyHintAnnotation(true),
+			WithInstanceRef(),
+			WithResponseShaping(),
+			WithOutputSchema[kbcloud.Cluster](),
 		),
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			// Get required parameters
-			orgName, err := RequiredParam[string](request, "org_name")
-			if err != nil {
-				return mcp.NewToolResultError(err.Error()), nil
-			}
-			envName, err := RequiredParam[string](request, "env_name")
+			ref, err := InstanceRefParams(ctx, request)
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
-			instanceName, err := RequiredParam[string](request, "instance_name")
+
+			// Get response shaping parameters
+			shaping, err := ResponseShapingParams(ctx, request)
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
@@ -112,9 +111,15 @@ func GetInstance(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandl
 				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
 			}
 
+			// Resolve instance_ref to exact coordinates
+			ref, err = resolveInstance(client, ref)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+
 			// Call KB Cloud API - using ClusterApi's GetCluster method
 			// Note: In KB Cloud API, instances are referred to as clusters
-			instance, resp, err := client.Cluster.GetCluster(client.Context, orgName, envName, instanceName)
+			instance, resp, err := client.Cluster.GetCluster(client.Context, ref.Org, ref.Instance)
 			if err != nil {
 				return nil, fmt.Errorf("failed to get instance: %w", err)
 			}
@@ -129,12 +134,195 @@ func GetInstance(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandl
 				return mcp.NewToolResultError(fmt.Sprintf("failed to get instance: %s", string(body))), nil
 			}
 
+			// Cluster names are unique within an org, so make sure it lives in the requested environment
+			if instance.EnvironmentName != ref.Env {
+				return mcp.NewToolResultError(fmt.Sprintf("instance %s not found in environment %s", ref.Instance, ref.Env)), nil
+			}
+
 			// Return result
-			result, err := json.Marshal(instance)
+			return shaping.result(resourceInstance, instance)
+		}
+}
+
+// instanceMatch is an instance found by find_instance, with the coordinates
+// other tools accept
+type instanceMatch struct {
+	InstanceRef  string `json:"instance_ref"`
+	OrgName      string `json:"org_name"`
+	EnvName      string `json:"env_name"`
+	InstanceName string `json:"instance_name"`
+	DisplayName  string `json:"display_name,omitempty"`
+	Engine       string `json:"engine"`
+	Version      string `json:"version"`
+	Status       string `json:"status"`
+
+	// score ranks name matches: exact, prefix, then substring
+	score int
+}
+
+// instanceMatches is the result of find_instance
+type instanceMatches struct {
+	Items      []instanceMatch `json:"items"`
+	TotalSize  int             `json:"totalSize"`
+	DidYouMean []string        `json:"didYouMean,omitempty"`
+}
+
+// FindInstance creates a tool to search instances across organizations and environments
+func FindInstance(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("find_instance",
+			mcp.WithDescription("Find instances across all accessible organizations and environments by partial name, engine or label. Returns the org_name, env_name, instance_name and instance_ref to use with other tools"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithString("query",
+				mcp.Description("Part of the instance name or display name, case-insensitive"),
+			),
+			mcp.WithString("engine",
+				mcp.Description("Part of the engine name, e.g. mysql, postgres, redis"),
+			),
+			mcp.WithString("label",
+				mcp.Description("Label as key or key=value, e.g. team=payments"),
+			),
+			mcp.WithString("org_name",
+				mcp.Description("Only search this organization"),
+			),
+			mcp.WithString("env_name",
+				mcp.Description("Only search this environment"),
+			),
+			WithPagination(),
+			WithOutputFormat(),
+			WithOutputSchema[instanceMatches](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get optional parameters
+			query, err := OptionalParam[string](request, "query")
 			if err != nil {
-				return nil, fmt.Errorf("failed to marshal response: %w", err)
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			engine, err := OptionalParam[string](request, "engine")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			label, err := OptionalParam[string](request, "label")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			orgName, err := OptionalParam[string](request, "org_name")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			envName, err := OptionalParam[string](request, "env_name")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get pagination parameters
+			pagination, err := OptionalPaginationParams(request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get output format
+			format, err := OutputFormatParam(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Labels and environments are filtered by KB Cloud
+			opts := kbcloud.NewListClusterOptionalParameters()
+			if envName != "" {
+				opts.WithEnvironmentName(envName)
+			}
+			if label != "" {
+				key, value, hasValue := strings.Cut(label, "=")
+				if key == "" {
+					return mcp.NewToolResultError(fmt.Sprintf("invalid label %q: expected key or key=value", label)), nil
+				}
+				opts.WithTagKey(key)
+				if hasValue {
+					opts.WithTagValue(value)
+				}
 			}
 
-			return mcp.NewToolResultText(string(result)), nil
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			orgs := []string{orgName}
+			if orgName == "" {
+				if orgs, err = listOrgNames(client); err != nil {
+					return nil, err
+				}
+			}
+
+			// Search every organization; names and engines are matched here
+			// because KB Cloud only filters on exact values
+			query, engine = strings.ToLower(query), strings.ToLower(engine)
+			matches := []instanceMatch{}
+			var names []string
+			for _, org := range orgs {
+				items, _, err := listOrgClusters(client, org, opts)
+				if err != nil {
+					return nil, err
+				}
+				for _, item := range items {
+					names = append(names, item.Name)
+					if engine != "" && !strings.Contains(strings.ToLower(item.Engine), engine) {
+						continue
+					}
+					score := matchScore(query, item.Name, item.GetDisplayName())
+					if score == 0 {
+						continue
+					}
+					ref := InstanceRef{Org: org, Env: item.EnvironmentName, Instance: item.Name}
+					matches = append(matches, instanceMatch{
+						InstanceRef:  ref.String(),
+						OrgName:      org,
+						EnvName:      item.EnvironmentName,
+						InstanceName: item.Name,
+						DisplayName:  item.GetDisplayName(),
+						Engine:       item.Engine,
+						Version:      item.Version,
+						Status:       item.Status,
+						score:        score,
+					})
+				}
+			}
+
+			// Best name matches first, then by coordinates
+			sort.SliceStable(matches, func(i, j int) bool {
+				if matches[i].score != matches[j].score {
+					return matches[i].score > matches[j].score
+				}
+				return matches[i].InstanceRef < matches[j].InstanceRef
+			})
+
+			response := instanceMatches{
+				Items:     paginate(matches, pagination),
+				TotalSize: len(matches),
+			}
+			if len(matches) == 0 && query != "" {
+				response.DidYouMean = suggest(query, names)
+			}
+
+			// Return result
+			return renderResult(format, response, nil)
 		}
 }
+
+// matchScore ranks how well an instance name matches a lowercase query: 3
+// for an exact match, 2 for a prefix, 1 for a substring of the name or
+// display name and 0 for no match. Every instance matches an empty query.
+func matchScore(query, name, displayName string) int {
+	name = strings.ToLower(name)
+	switch {
+	case query == "" || name == query:
+		return 3
+	case strings.HasPrefix(name, query):
+		return 2
+	case strings.Contains(name, query), strings.Contains(strings.ToLower(displayName), query):
+		return 1
+	default:
+		return 0
+	}
+}
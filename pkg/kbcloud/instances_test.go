@@ -0,0 +1,233 @@
+package kbcloud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	client "github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud/kbcloudtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListInstances(t *testing.T) {
+	runToolTests(t, kbcloud.ListInstances, []toolTest{
+		{
+			name: "instances of an environment",
+			args: map[string]any{"org_name": "acme", "env_name": "prod"},
+			check: func(t *testing.T, text string) {
+				instances := decode[client.ClusterList](t, text)
+				require.Len(t, instances.Items, 2)
+				assert.Equal(t, "orders-db", instances.Items[0].Name)
+				assert.Equal(t, "cache", instances.Items[1].Name)
+			},
+		},
+		{
+			name: "paginated",
+			args: map[string]any{"org_name": "acme", "env_name": "prod", "perPage": float64(1)},
+			check: func(t *testing.T, text string) {
+				instances := decode[client.ClusterList](t, text)
+				require.Len(t, instances.Items, 1)
+				assert.Equal(t, "orders-db", instances.Items[0].Name)
+			},
+		},
+		{
+			name: "empty environment",
+			args: map[string]any{"org_name": "acme", "env_name": "qa"},
+			check: func(t *testing.T, text string) {
+				assert.Empty(t, decode[client.ClusterList](t, text).Items)
+			},
+		},
+		{
+			name:        "missing environment",
+			args:        map[string]any{"org_name": "acme"},
+			wantToolErr: "env_name",
+		},
+		{
+			name: "server error",
+			args: map[string]any{"org_name": "acme", "env_name": "prod"},
+			setup: func(s *kbcloudtest.Server) {
+				s.InjectError(http.MethodGet, "/api/v1/organizations/acme/clusters", http.StatusInternalServerError, "boom")
+			},
+			wantErr: "failed to list instances",
+		},
+	})
+}
+
+func TestGetInstance(t *testing.T) {
+	runToolTests(t, kbcloud.GetInstance, []toolTest{
+		{
+			name: "existing instance",
+			args: map[string]any{"org_name": "acme", "env_name": "staging", "instance_name": "analytics"},
+			check: func(t *testing.T, text string) {
+				instance := decode[client.Cluster](t, text)
+				assert.Equal(t, "analytics", instance.Name)
+				assert.Equal(t, "postgresql", instance.Engine)
+				assert.Equal(t, "Stopped", instance.GetStatus())
+			},
+		},
+		{
+			name:        "instance in another environment",
+			args:        map[string]any{"org_name": "acme", "env_name": "staging", "instance_name": "orders-db"},
+			wantToolErr: "instance orders-db not found in environment staging",
+		},
+		{
+			name:        "missing instance name",
+			args:        map[string]any{"org_name": "acme", "env_name": "prod"},
+			wantToolErr: "instance_name",
+		},
+		{
+			name:    "unknown instance",
+			args:    map[string]any{"org_name": "acme", "env_name": "prod", "instance_name": "ghost"},
+			wantErr: "404",
+		},
+		{
+			name: "instance ref",
+			args: map[string]any{"instance_ref": "acme/staging/analytics"},
+			check: func(t *testing.T, text string) {
+				assert.Equal(t, "analytics", decode[client.Cluster](t, text).Name)
+			},
+		},
+		{
+			name: "bare instance name",
+			args: map[string]any{"instance_ref": "inventory"},
+			check: func(t *testing.T, text string) {
+				instance := decode[client.Cluster](t, text)
+				assert.Equal(t, "globex", instance.GetOrgName())
+				assert.Equal(t, "dev", instance.EnvironmentName)
+			},
+		},
+		{
+			name:        "misspelled instance ref",
+			args:        map[string]any{"instance_ref": "acme/prod/order-db"},
+			wantToolErr: `instance "order-db" not found in acme/prod; did you mean acme/prod/orders-db?`,
+		},
+		{
+			name:        "instance ref in another environment",
+			args:        map[string]any{"instance_ref": "acme/staging/cache"},
+			wantToolErr: `instance "cache" is in environment "prod", not "staging"; did you mean acme/prod/cache?`,
+		},
+		{
+			name:        "misspelled organization",
+			args:        map[string]any{"instance_ref": "acmee/prod/cache"},
+			wantToolErr: `organization "acmee" not found; did you mean acme?`,
+		},
+		{
+			name:        "misspelled bare instance name",
+			args:        map[string]any{"instance_ref": "inventroy"},
+			wantToolErr: "did you mean globex/dev/inventory?",
+		},
+		{
+			name:        "unrelated instance name",
+			args:        map[string]any{"instance_ref": "zzzzzzzzzz"},
+			wantToolErr: "use find_instance to search for it",
+		},
+		{
+			name: "ambiguous bare instance name",
+			args: map[string]any{"instance_ref": "cache"},
+			setup: func(s *kbcloudtest.Server) {
+				s.AddCluster("globex", "dev", "cache", "redis")
+			},
+			wantToolErr: "instance name \"cache\" is ambiguous; use one of: acme/prod/cache, globex/dev/cache",
+		},
+		{
+			name:        "malformed instance ref",
+			args:        map[string]any{"instance_ref": "acme/cache"},
+			wantToolErr: "expected org/env/instance",
+		},
+	})
+}
+
+func TestFindInstance(t *testing.T) {
+	// refs returns the instance_ref of every match
+	refs := func(t *testing.T, text string) []string {
+		var result struct {
+			Items []struct {
+				InstanceRef string `json:"instance_ref"`
+			} `json:"items"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(text), &result))
+		var refs []string
+		for _, item := range result.Items {
+			refs = append(refs, item.InstanceRef)
+		}
+		return refs
+	}
+
+	runToolTests(t, kbcloud.FindInstance, []toolTest{
+		{
+			name: "partial name",
+			args: map[string]any{"query": "ORDER"},
+			check: func(t *testing.T, text string) {
+				assert.Equal(t, []string{"acme/prod/orders-db"}, refs(t, text))
+				assert.Contains(t, text, `"org_name":"acme","env_name":"prod","instance_name":"orders-db"`)
+			},
+		},
+		{
+			name: "exact and prefix matches rank first",
+			args: map[string]any{"query": "a"},
+			check: func(t *testing.T, text string) {
+				assert.Equal(t, []string{"acme/staging/analytics", "acme/prod/cache"}, refs(t, text))
+			},
+		},
+		{
+			name: "engine",
+			args: map[string]any{"engine": "postgres"},
+			check: func(t *testing.T, text string) {
+				assert.Equal(t, []string{"acme/staging/analytics"}, refs(t, text))
+			},
+		},
+		{
+			name: "label",
+			args: map[string]any{"label": "team=commerce"},
+			check: func(t *testing.T, text string) {
+				assert.Equal(t, []string{"acme/prod/cache", "acme/prod/orders-db"}, refs(t, text))
+			},
+		},
+		{
+			name: "label key",
+			args: map[string]any{"label": "tier"},
+			check: func(t *testing.T, text string) {
+				assert.Equal(t, []string{"acme/prod/orders-db"}, refs(t, text))
+			},
+		},
+		{
+			name: "single organization and environment",
+			args: map[string]any{"org_name": "acme", "env_name": "prod"},
+			check: func(t *testing.T, text string) {
+				assert.Equal(t, []string{"acme/prod/cache", "acme/prod/orders-db"}, refs(t, text))
+			},
+		},
+		{
+			name: "every instance",
+			args: map[string]any{"perPage": float64(2)},
+			check: func(t *testing.T, text string) {
+				assert.Len(t, refs(t, text), 2)
+				assert.Contains(t, text, `"totalSize":4`)
+			},
+		},
+		{
+			name: "no match suggests names",
+			args: map[string]any{"query": "inventroy"},
+			check: func(t *testing.T, text string) {
+				assert.Empty(t, refs(t, text))
+				assert.Contains(t, text, `"didYouMean":["inventory"]`)
+			},
+		},
+		{
+			name:        "invalid label",
+			args:        map[string]any{"label": "=commerce"},
+			wantToolErr: "invalid label",
+		},
+		{
+			name: "server error",
+			args: map[string]any{"query": "orders"},
+			setup: func(s *kbcloudtest.Server) {
+				s.InjectError(http.MethodGet, "/api/v1/organizations/globex/clusters", http.StatusInternalServerError, "boom")
+			},
+			wantErr: "failed to list instances",
+		},
+	})
+}
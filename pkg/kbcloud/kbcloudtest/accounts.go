@@ -0,0 +1,162 @@
+package kbcloudtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+)
+
+// Account returns the current state of a database account
+func (s *Server) Account(orgName, clusterName, accountName string) (kbcloud.Account, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, i := s.findAccount(orgName+"/"+clusterName, accountName); i >= 0 {
+		return s.fixtures.Accounts[orgName+"/"+clusterName][i], true
+	}
+	return kbcloud.Account{}, false
+}
+
+// dataCluster returns the key of the cluster a data API request, such as an
+// account or database request, targets, writing an error when the cluster
+// does not exist or runs another engine; s.mu must be held
+func (s *Server) dataCluster(w http.ResponseWriter, r *http.Request) (string, bool) {
+	orgName, clusterName := r.PathValue("orgName"), r.PathValue("clusterName")
+	cluster := s.findCluster(orgName, clusterName)
+	if cluster == nil {
+		writeNotFound(w, "cluster", clusterName)
+		return "", false
+	}
+	if engine := r.PathValue("engineName"); engine != cluster.Engine {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("cluster %s runs %s, not %s", clusterName, cluster.Engine, engine))
+		return "", false
+	}
+	return orgName + "/" + clusterName, true
+}
+
+// findAccount returns the accounts of a cluster and the index of the named
+// one, or -1; s.mu must be held
+func (s *Server) findAccount(cluster, name string) ([]kbcloud.Account, int) {
+	accounts := s.fixtures.Accounts[cluster]
+	for i := range accounts {
+		if accounts[i].Name == name {
+			return accounts, i
+		}
+	}
+	return accounts, -1
+}
+
+func (s *Server) listAccounts(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cluster, ok := s.dataCluster(w, r)
+	if !ok {
+		return
+	}
+
+	// Like the real API, passwords are included in the list
+	items := []kbcloud.AccountListItem{}
+	for _, a := range s.fixtures.Accounts[cluster] {
+		item := kbcloud.NewAccountListItem(a.Name)
+		item.Password = a.Password
+		item.SetRole(kbcloud.AccountListRoleType(a.Role))
+		item.PrivilegesList = a.PrivilegesList
+		items = append(items, *item)
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (s *Server) createAccount(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cluster, ok := s.dataCluster(w, r)
+	if !ok {
+		return
+	}
+
+	var account kbcloud.Account
+	if err := json.NewDecoder(r.Body).Decode(&account); err != nil || account.Name == "" {
+		writeError(w, http.StatusBadRequest, "invalid account")
+		return
+	}
+	if _, i := s.findAccount(cluster, account.Name); i >= 0 {
+		writeError(w, http.StatusConflict, fmt.Sprintf("account %s already exists", account.Name))
+		return
+	}
+	if account.GetPassword() == "" {
+		account.SetPassword(newNonce())
+	}
+	s.fixtures.Accounts[cluster] = append(s.fixtures.Accounts[cluster], account)
+
+	// The password is never echoed back
+	account.Password = nil
+	writeJSON(w, http.StatusOK, account)
+}
+
+func (s *Server) updateAccount(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cluster, ok := s.dataCluster(w, r)
+	if !ok {
+		return
+	}
+	accounts, i := s.findAccount(cluster, r.PathValue("accountName"))
+	if i < 0 {
+		writeNotFound(w, "account", r.PathValue("accountName"))
+		return
+	}
+
+	var update kbcloud.Account
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid account")
+		return
+	}
+	if update.Password != nil {
+		accounts[i].Password = update.Password
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) updateAccountPrivileges(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cluster, ok := s.dataCluster(w, r)
+	if !ok {
+		return
+	}
+	accounts, i := s.findAccount(cluster, r.PathValue("accountName"))
+	if i < 0 {
+		writeNotFound(w, "account", r.PathValue("accountName"))
+		return
+	}
+
+	var privileges []kbcloud.PrivilegeListItem
+	if err := json.NewDecoder(r.Body).Decode(&privileges); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid privileges")
+		return
+	}
+	accounts[i].PrivilegesList = privileges
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteAccount(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cluster, ok := s.dataCluster(w, r)
+	if !ok {
+		return
+	}
+	accounts, i := s.findAccount(cluster, r.PathValue("accountName"))
+	if i < 0 {
+		writeNotFound(w, "account", r.PathValue("accountName"))
+		return
+	}
+	s.fixtures.Accounts[cluster] = append(accounts[:i:i], accounts[i+1:]...)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,279 @@
+package kbcloudtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	kbmcp "github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	mcplog "github.com/apecloud/kb-cloud-mcp-server/pkg/log"
+)
+
+// RecordEnv is the environment variable that switches cassettes to record mode
+const RecordEnv = "KB_CLOUD_RECORD"
+
+// replaySite is the site used while replaying; requests never leave the process
+const replaySite = "https://kb-cloud.example"
+
+// Mode selects whether a Recorder captures or replays traffic
+type Mode string
+
+// Recorder modes
+const (
+	ModeReplay Mode = "replay"
+	ModeRecord Mode = "record"
+)
+
+// Cassette is a sanitized recording of KB Cloud API traffic
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and its response
+type Interaction struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+}
+
+// CassetteRequest identifies a recorded request. Replayed requests match on
+// all fields.
+type CassetteRequest struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Query  string          `json:"query,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// CassetteResponse is a recorded response. JSON bodies are stored inline,
+// anything else as text.
+type CassetteResponse struct {
+	Status      int             `json:"status"`
+	ContentType string          `json:"contentType,omitempty"`
+	Body        json.RawMessage `json:"body,omitempty"`
+	Text        string          `json:"text,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that records traffic to a cassette or
+// replays it from one. Credentials never reach the cassette: digest
+// challenges are dropped, auth headers are not stored and sensitive JSON
+// fields are redacted.
+type Recorder struct {
+	mode Mode
+	path string
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// NewRecorder creates a Recorder for the cassette at path. In replay mode
+// the cassette is loaded immediately; in record mode requests are sent to
+// next and the cassette is written by Save.
+func NewRecorder(path string, mode Mode, next http.RoundTripper) (*Recorder, error) {
+	r := &Recorder{mode: mode, path: path, next: next}
+	if mode == ModeRecord {
+		if r.next == nil {
+			r.next = http.DefaultTransport
+		}
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette (record it with %s=1): %w", RecordEnv, err)
+	}
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	recorded := CassetteRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.Query().Encode(),
+		Body:   sanitizeJSON(body),
+	}
+
+	if r.mode == ModeRecord {
+		return r.record(req, recorded)
+	}
+	return r.replay(req, recorded)
+}
+
+// record forwards req and appends the exchange to the cassette
+func (r *Recorder) record(req *http.Request, recorded CassetteRequest) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Digest challenges depend on the credentials and a server nonce, and
+	// are never needed on replay
+	if resp.StatusCode == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") != "" {
+		return resp, nil
+	}
+
+	response := CassetteResponse{
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	site := req.URL.Scheme + "://" + req.URL.Host
+	body = bytes.ReplaceAll(body, []byte(site), []byte(replaySite))
+	if sanitized := sanitizeJSON(body); sanitized != nil {
+		response.Body = sanitized
+	} else {
+		response.Text = string(body)
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{Request: recorded, Response: response})
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// replay answers req with the first unused matching interaction
+func (r *Recorder) replay(req *http.Request, recorded CassetteRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || !interaction.Request.matches(recorded) {
+			continue
+		}
+		r.used[i] = true
+
+		body := []byte(interaction.Response.Text)
+		if len(interaction.Response.Body) > 0 {
+			body = interaction.Response.Body
+		}
+		header := http.Header{}
+		if interaction.Response.ContentType != "" {
+			header.Set("Content-Type", interaction.Response.ContentType)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+			StatusCode:    interaction.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	target := recorded.Path
+	if recorded.Query != "" {
+		target += "?" + recorded.Query
+	}
+	return nil, fmt.Errorf("cassette %s has no recorded response for %s %s", r.path, recorded.Method, target)
+}
+
+// Save writes the recorded cassette; it does nothing in replay mode
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+// matches reports whether a recorded request answers req
+func (c CassetteRequest) matches(req CassetteRequest) bool {
+	return c.Method == req.Method &&
+		c.Path == req.Path &&
+		c.Query == req.Query &&
+		bytes.Equal(c.Body, req.Body)
+}
+
+// CassetteClientFn returns a GetClientFn that replays the cassette at path.
+// When KB_CLOUD_RECORD is set the cassette is recorded instead: against KB
+// Cloud when KB_CLOUD_API_KEY_NAME, KB_CLOUD_API_KEY_SECRET and
+// KB_CLOUD_SITE are set, otherwise against a fake server with default
+// fixtures.
+func CassetteClientFn(t testing.TB, path string) kbmcp.GetClientFn {
+	t.Helper()
+
+	if os.Getenv(RecordEnv) == "" {
+		recorder, err := NewRecorder(path, ModeReplay, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return kbmcp.NewStaticClientFnWithTransport(APIKey, APISecret, replaySite, recorder, mcplog.Nop())
+	}
+
+	recorder, err := NewRecorder(path, ModeRecord, http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := recorder.Save(); err != nil {
+			t.Errorf("failed to save cassette: %v", err)
+		}
+	})
+
+	apiKey, apiSecret, site := os.Getenv("KB_CLOUD_API_KEY_NAME"), os.Getenv("KB_CLOUD_API_KEY_SECRET"), os.Getenv("KB_CLOUD_SITE")
+	if apiKey == "" || apiSecret == "" || site == "" {
+		s := NewServer(t)
+		apiKey, apiSecret, site = s.apiKey, s.apiSecret, s.URL
+	}
+	return kbmcp.NewStaticClientFnWithTransport(apiKey, apiSecret, site, recorder, mcplog.Nop())
+}
+
+// readBody reads and restores a request or response body
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*body)
+	_ = (*body).Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// sanitizeJSON redacts and compacts a JSON body, returning nil when the body
+// is empty or not JSON
+func sanitizeJSON(body []byte) json.RawMessage {
+	if len(strings.TrimSpace(string(body))) == 0 || !json.Valid(body) {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, mcplog.RedactJSON(body)); err != nil {
+		return nil
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,73 @@
+package kbcloudtest
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	kbmcp "github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	mcplog "github.com/apecloud/kb-cloud-mcp-server/pkg/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	t.Run("Record", func(t *testing.T) {
+		s := NewServer(t)
+		recorder, err := NewRecorder(path, ModeRecord, http.DefaultTransport)
+		require.NoError(t, err)
+
+		client, err := kbmcp.NewStaticClientFnWithTransport(s.apiKey, s.apiSecret, s.URL, recorder, mcplog.Nop())(context.Background())
+		require.NoError(t, err)
+		_, _, err = client.Organization.ReadOrg(client.Context, "acme")
+		require.NoError(t, err)
+		_, _, err = client.Cluster.GetCluster(client.Context, "acme", "ghost")
+		require.Error(t, err)
+		require.NoError(t, recorder.Save())
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		cassette := string(data)
+		assert.NotContains(t, cassette, APISecret)
+		assert.NotContains(t, cassette, "Digest")
+		assert.NotContains(t, cassette, s.URL)
+	})
+
+	t.Run("Replay", func(t *testing.T) {
+		recorder, err := NewRecorder(path, ModeReplay, nil)
+		require.NoError(t, err)
+		assert.Len(t, recorder.cassette.Interactions, 2, "digest challenges are not recorded")
+
+		client, err := kbmcp.NewStaticClientFnWithTransport("other-key", "other-secret", replaySite, recorder, mcplog.Nop())(context.Background())
+		require.NoError(t, err)
+
+		org, _, err := client.Organization.ReadOrg(client.Context, "acme")
+		require.NoError(t, err)
+		assert.Equal(t, "Acme Corp", org.GetDisplayName())
+
+		_, resp, err := client.Cluster.GetCluster(client.Context, "acme", "ghost")
+		require.Error(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		// Each interaction is replayed once
+		_, _, err = client.Organization.ReadOrg(client.Context, "acme")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no recorded response for GET /api/v1/organizations/acme")
+	})
+
+	t.Run("Missing cassette", func(t *testing.T) {
+		_, err := NewRecorder(filepath.Join(t.TempDir(), "missing.json"), ModeReplay, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), RecordEnv)
+	})
+}
+
+func TestSanitizeJSON(t *testing.T) {
+	assert.JSONEq(t, `{"name":"app","password":"[REDACTED]"}`, string(sanitizeJSON([]byte(`{"name": "app", "password": "hunter2"}`))))
+	assert.Nil(t, sanitizeJSON([]byte("not json")))
+	assert.Nil(t, sanitizeJSON(nil))
+}
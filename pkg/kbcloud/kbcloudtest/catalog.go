@@ -0,0 +1,118 @@
+package kbcloudtest
+
+import (
+	"net/http"
+
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+)
+
+// findEnvironment returns the named environment of any organization; s.mu
+// must be held
+func (s *Server) findEnvironment(orgName, name string) *kbcloud.Environment {
+	for i := range s.fixtures.Environments {
+		env := &s.fixtures.Environments[i]
+		if env.Name == name && (orgName == "" || env.OrgName == orgName) {
+			return env
+		}
+	}
+	return nil
+}
+
+func (s *Server) listEngines(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	envName := r.PathValue("environmentName")
+	if s.findEnvironment("", envName) == nil {
+		writeNotFound(w, "environment", envName)
+		return
+	}
+	engines := append([]kbcloud.Engine{}, s.fixtures.Engines[envName]...)
+	writeJSON(w, http.StatusOK, engines)
+}
+
+func (s *Server) listServiceVersions(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	envName, engineName := r.PathValue("environmentName"), r.PathValue("engineName")
+	if s.findEnvironment("", envName) == nil {
+		writeNotFound(w, "environment", envName)
+		return
+	}
+	if r.URL.Query().Get("engineMode") == "" {
+		writeError(w, http.StatusBadRequest, "engineMode is required")
+		return
+	}
+	versions := kbcloud.NewEngineServiceVersions()
+	versions.SetComponent(engineName)
+	versions.Versions = append([]kbcloud.EngineServiceVersionsVersionsItem{}, s.fixtures.EngineVersions[engineName]...)
+	writeJSON(w, http.StatusOK, versions)
+}
+
+func (s *Server) listClasses(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	engineName := r.URL.Query().Get("engineName")
+	classes := []kbcloud.Class{}
+	for _, c := range s.fixtures.Classes {
+		if engineName == "" || c.GetEngine() == engineName {
+			classes = append(classes, c)
+		}
+	}
+	writeJSON(w, http.StatusOK, classes)
+}
+
+func (s *Server) listStorageClasses(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orgName, envName := r.PathValue("orgName"), r.PathValue("environmentName")
+	if s.findEnvironment(orgName, envName) == nil {
+		writeNotFound(w, "environment", envName)
+		return
+	}
+	items := append([]kbcloud.StorageClassInfo{}, s.fixtures.StorageClasses[orgName+"/"+envName]...)
+	writeJSON(w, http.StatusOK, kbcloud.StorageClassList{Items: items})
+}
+
+// newEngine returns an engine fixture
+func newEngine(name string, typ kbcloud.EngineType, status kbcloud.EngineStatus) kbcloud.Engine {
+	engine := kbcloud.NewEngine()
+	engine.SetName(name)
+	engine.SetType(typ)
+	engine.SetStatus(status)
+	engine.SetVersion("1.0.0")
+	engine.SetProvider("apecloud")
+	engine.SetInstalled(true)
+	return *engine
+}
+
+// newEngineVersion returns a major version fixture; the first minor version
+// is the default
+func newEngineVersion(major string, isDefault bool, minors ...string) kbcloud.EngineServiceVersionsVersionsItem {
+	version := kbcloud.NewEngineServiceVersionsVersionsItem()
+	version.SetMajorVersion(major)
+	version.SetDefault(isDefault)
+	version.SetDefaultMinorVersion(minors[0])
+	version.MinorVersions = minors
+	return *version
+}
+
+// newClass returns an instance class fixture
+func newClass(engine, code string, cpu, memory float64) kbcloud.Class {
+	class := kbcloud.NewClass()
+	class.SetEngine(engine)
+	class.SetCode(code)
+	class.SetComponent(engine)
+	class.SetCpu(cpu)
+	class.SetMemory(memory)
+	return *class
+}
+
+// newStorageClass returns a storage class fixture
+func newStorageClass(name, typ string, isDefault, expandable bool) kbcloud.StorageClassInfo {
+	return *kbcloud.NewStorageClassInfo(name, fixtureTime.Format("2006-01-02T15:04:05Z"), "ebs.csi.aws.com", "Delete",
+		expandable, "WaitForFirstConsumer", "0", false, true, isDefault, typ, "", name, true, "sc-"+name)
+}
@@ -0,0 +1,95 @@
+package kbcloudtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+)
+
+// Database returns the current state of a logical database
+func (s *Server) Database(orgName, clusterName, databaseName string) (kbcloud.Database, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, i := s.findDatabase(orgName+"/"+clusterName, databaseName); i >= 0 {
+		return s.fixtures.Databases[orgName+"/"+clusterName][i], true
+	}
+	return kbcloud.Database{}, false
+}
+
+// findDatabase returns the databases of a cluster and the index of the named
+// one, or -1; s.mu must be held
+func (s *Server) findDatabase(cluster, name string) ([]kbcloud.Database, int) {
+	databases := s.fixtures.Databases[cluster]
+	for i := range databases {
+		if databases[i].Name == name {
+			return databases, i
+		}
+	}
+	return databases, -1
+}
+
+func (s *Server) listDatabases(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cluster, ok := s.dataCluster(w, r)
+	if !ok {
+		return
+	}
+
+	items := []kbcloud.DatabaseItem{}
+	for _, d := range s.fixtures.Databases[cluster] {
+		items = append(items, *kbcloud.NewDatabaseItem(d.Name))
+	}
+	writeJSON(w, http.StatusOK, kbcloud.NewDatabaseList(items))
+}
+
+func (s *Server) createDatabase(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cluster, ok := s.dataCluster(w, r)
+	if !ok {
+		return
+	}
+
+	var database kbcloud.Database
+	if err := json.NewDecoder(r.Body).Decode(&database); err != nil || database.Name == "" {
+		writeError(w, http.StatusBadRequest, "invalid database")
+		return
+	}
+	if _, i := s.findDatabase(cluster, database.Name); i >= 0 {
+		writeError(w, http.StatusConflict, fmt.Sprintf("database %s already exists", database.Name))
+		return
+	}
+	if owner := database.GetAccountName(); owner != "" {
+		if _, i := s.findAccount(cluster, owner); i < 0 {
+			writeNotFound(w, "account", owner)
+			return
+		}
+	}
+	if s.fixtures.Databases == nil {
+		s.fixtures.Databases = map[string][]kbcloud.Database{}
+	}
+	s.fixtures.Databases[cluster] = append(s.fixtures.Databases[cluster], database)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteDatabase(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cluster, ok := s.dataCluster(w, r)
+	if !ok {
+		return
+	}
+	databases, i := s.findDatabase(cluster, r.PathValue("databaseName"))
+	if i < 0 {
+		writeNotFound(w, "database", r.PathValue("databaseName"))
+		return
+	}
+	s.fixtures.Databases[cluster] = append(databases[:i:i], databases[i+1:]...)
+	w.WriteHeader(http.StatusNoContent)
+}
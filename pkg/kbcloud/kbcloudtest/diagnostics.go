@@ -0,0 +1,107 @@
+package kbcloudtest
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+)
+
+// listEvents lists the operation events of an organization created in the
+// queried range, newest first, filtered by resource and status
+func (s *Server) listEvents(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orgName := r.PathValue("orgName")
+	query := r.URL.Query()
+	start, startErr := strconv.ParseInt(query.Get("start"), 10, 64)
+	end, endErr := strconv.ParseInt(query.Get("end"), 10, 64)
+	if startErr != nil || endErr != nil || start >= end {
+		writeError(w, http.StatusBadRequest, "invalid event time range")
+		return
+	}
+
+	var items []kbcloud.Event
+	for _, e := range s.fixtures.Events {
+		if e.GetOrgName() != orgName || e.GetCreatedAt().Unix() < start || e.GetCreatedAt().Unix() > end {
+			continue
+		}
+		if id := query.Get("resourceId"); id != "" && id != e.GetResourceId() {
+			continue
+		}
+		if typ := query.Get("resourceType"); typ != "" && typ != string(e.GetResourceType()) {
+			continue
+		}
+		if status := query.Get("status"); status != "" && status != string(e.GetResultStatus()) {
+			continue
+		}
+		items = append(items, e)
+	}
+	slices.SortStableFunc(items, func(a, b kbcloud.Event) int { return b.GetCreatedAt().Compare(a.GetCreatedAt()) })
+
+	// Events are paginated with 1-based page numbers
+	pageNum, err := queryInt(r, "pageNumber", 1)
+	if err != nil || pageNum < 1 {
+		writeError(w, http.StatusBadRequest, "pageNumber must be a positive integer")
+		return
+	}
+	size, err := queryInt(r, "pageSize", len(items))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	page, _ := window(items, (pageNum-1)*size, size)
+	writeJSON(w, http.StatusOK, kbcloud.EventList{
+		Items:      page,
+		Pagination: kbcloud.NewPaginationResult(int32(pageNum), int32(size), int32(len(items))),
+	})
+}
+
+// listPods lists the pods of a cluster, which KB Cloud calls instances
+func (s *Server) listPods(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orgName, clusterName := r.PathValue("orgName"), r.PathValue("clusterName")
+	if s.findCluster(orgName, clusterName) == nil {
+		writeNotFound(w, "cluster", clusterName)
+		return
+	}
+	items := append([]kbcloud.Instance{}, s.fixtures.Pods[orgName+"/"+clusterName]...)
+	writeJSON(w, http.StatusOK, kbcloud.InstanceList{Items: items})
+}
+
+// newEvent returns an operation event fixture of a cluster
+func newEvent(org, cluster, name string, status kbcloud.EventResultStatus, details string, offset time.Duration) kbcloud.Event {
+	event := kbcloud.Event{}
+	event.SetId("event-" + strconv.FormatInt(fixtureTime.Add(offset).Unix(), 10))
+	event.SetOrgName(org)
+	event.SetResourceId("cluster-" + cluster)
+	event.SetResourceType(kbcloud.EventResourceTypeCluster)
+	event.SetResourceName(cluster)
+	event.SetEventName(name)
+	event.SetResultStatus(status)
+	event.SetSource(kbcloud.EventSourceSystem)
+	event.SetOperator("system")
+	event.SetDetails(details)
+	event.SetCreatedAt(fixtureTime.Add(offset))
+	return event
+}
+
+// newPod returns a pod fixture of the single component of a cluster created
+// by newCluster; reason and message explain a pod that is not ready
+func newPod(cluster, engine, role, node string, ready bool, restarts int, reason, message string) kbcloud.Instance {
+	status := kbcloud.NewInstanceStatus("Running")
+	if reason != "" {
+		status.SetReason(reason)
+		status.SetMessage(message)
+	}
+	pod := kbcloud.NewInstance("ReadWrite", "aws", cluster, engine, "1", fixtureTime.Format(time.RFC3339),
+		"2", cluster+"-"+engine+"-0", node, "us-east-1", role, *status, "us-east-1a")
+	pod.SetComponentName(engine)
+	pod.AdditionalProperties = map[string]any{"ready": ready, "restartCount": restarts}
+	return *pod
+}
@@ -0,0 +1,39 @@
+package kbcloudtest
+
+import (
+	"net/http"
+
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+)
+
+func (s *Server) listEndpoints(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orgName, clusterName := r.PathValue("orgName"), r.PathValue("clusterName")
+	if s.findCluster(orgName, clusterName) == nil {
+		writeNotFound(w, "cluster", clusterName)
+		return
+	}
+	items := append([]kbcloud.Endpoint{}, s.fixtures.Endpoints[orgName+"/"+clusterName]...)
+	writeJSON(w, http.StatusOK, kbcloud.EndpointList{Items: items})
+}
+
+// newEndpoint returns an endpoint fixture; internal endpoints are ClusterIP
+// services and external ones load balancers
+func newEndpoint(component, host string, port int32, portName string, network kbcloud.EndpointNetworkType) kbcloud.Endpoint {
+	typ := kbcloud.EndpointTypeClusterIp
+	if network == kbcloud.EndpointNetworkTypeInternet {
+		typ = kbcloud.EndpointTypeLoadBalancer
+	}
+	return kbcloud.Endpoint{
+		Title:       string(network) + " " + portName,
+		Component:   component,
+		Hosts:       []string{host},
+		Port:        port,
+		Type:        typ,
+		NetworkType: network,
+		ServiceName: component + "-" + portName,
+		PortName:    portName,
+	}
+}
@@ -0,0 +1,427 @@
+package kbcloudtest
+
+import (
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+	"github.com/google/uuid"
+)
+
+// fixtureTime is the creation time of every default fixture, so rendered
+// output is stable across runs
+var fixtureTime = time.Date(2024, time.January, 15, 8, 0, 0, 0, time.UTC)
+
+// Fixtures is the data served by a fake KB Cloud server
+type Fixtures struct {
+	Organizations []kbcloud.Org
+	Environments  []kbcloud.Environment
+	Clusters      []kbcloud.Cluster
+	Backups       []kbcloud.Backup
+	// Tags are the labels of each cluster, keyed by "org/cluster"
+	Tags map[string]map[string]string
+	// Accounts are the database accounts of each cluster, keyed by "org/cluster"
+	Accounts map[string][]kbcloud.Account
+	// Databases are the logical databases of each cluster, keyed by "org/cluster"
+	Databases map[string][]kbcloud.Database
+	// Parameters are the engine parameters of each cluster, keyed by "org/cluster"
+	Parameters map[string][]Parameter
+	// ParameterTemplates are the parameter templates of each organization
+	ParameterTemplates map[string][]ParameterTemplate
+	// Engines are the engines of each environment, keyed by environment name
+	Engines map[string][]kbcloud.Engine
+	// EngineVersions are the versions of each engine, keyed by engine name
+	EngineVersions map[string][]kbcloud.EngineServiceVersionsVersionsItem
+	// Classes are the instance classes of every engine
+	Classes []kbcloud.Class
+	// StorageClasses are the storage classes of each environment, keyed by "org/env"
+	StorageClasses map[string][]kbcloud.StorageClassInfo
+	// Endpoints are the network endpoints of each cluster, keyed by "org/cluster"
+	Endpoints map[string][]kbcloud.Endpoint
+	// IPWhitelists are the IP allowlists of each cluster, keyed by "org/cluster"
+	IPWhitelists map[string][]kbcloud.IpWhitelist
+	// Metrics are the monitoring series of each cluster, keyed by "org/cluster"
+	Metrics map[string][]MetricSeries
+	// Logs are the log entries of each cluster, keyed by "org/cluster" and
+	// then by log type: error, slow, running or audit
+	Logs map[string]map[string][]LogEntry
+	// Events are the operation events of every organization
+	Events []kbcloud.Event
+	// Pods are the pods of each cluster, keyed by "org/cluster"
+	Pods map[string][]kbcloud.Instance
+}
+
+// DefaultFixtures returns two organizations with a handful of environments,
+// clusters and backups:
+//
+//	acme
+//	  prod:    orders-db (mysql, Running), cache (redis, Running)
+//	  staging: analytics (postgresql, Stopped)
+//	globex
+//	  dev:     inventory (mongodb, Running)
+//
+// Every cluster has a team tag, and orders-db is also tagged tier=critical.
+// Every cluster has a root account; orders-db also has app_orders, with
+// read-write access to the orders database. orders-db has an orders
+// database and analytics has analytics and events. orders-db and analytics
+// have engine parameters, a few of them changed from their defaults. acme
+// has a default MySQL 8.0 parameter template and three custom ones. prod
+// offers mysql, postgresql and redis with mongodb disabled, staging mysql and
+// postgresql, and dev mongodb; each engine has a few versions and classes.
+// Every cluster has an internal endpoint; orders-db also has a public one
+// and a metrics port, and allows an office network and a CI runner.
+// orders-db reports CPU, memory, connections and queries, with a CPU spike.
+// orders-db logs errors including two deadlocks and slow queries of two
+// statements after fixtureTime, and analytics logs two slow statements.
+// orders-db and cache were created at fixtureTime; cache then failed to
+// restart three times and its pod is crash looping.
+func DefaultFixtures() *Fixtures {
+	return &Fixtures{
+		Organizations: []kbcloud.Org{
+			newOrg("acme", "Acme Corp"),
+			newOrg("globex", "Globex"),
+		},
+		Environments: []kbcloud.Environment{
+			newEnvironment("acme", "prod", "aws", "us-east-1", "3f1c2a4e-8d6b-4c1e-9a7f-0b2d5e6f7a81"),
+			newEnvironment("acme", "staging", "aws", "us-west-2", "8a9b0c1d-2e3f-4a5b-8c7d-9e0f1a2b3c4d"),
+			newEnvironment("globex", "dev", "gcp", "europe-west1", "c4d5e6f7-a8b9-4c0d-8e1f-2a3b4c5d6e7f"),
+		},
+		Clusters: []kbcloud.Cluster{
+			newCluster("acme", "prod", "orders-db", "mysql", "8.0.33", "Running"),
+			newCluster("acme", "prod", "cache", "redis", "7.0.6", "Running"),
+			newCluster("acme", "staging", "analytics", "postgresql", "15.7.0", "Stopped"),
+			newCluster("globex", "dev", "inventory", "mongodb", "6.0.16", "Running"),
+		},
+		Backups: []kbcloud.Backup{
+			newBackup("acme", "prod", "orders-db", "mysql", "orders-db-backup-1", kbcloud.BackupStatusCompleted),
+			newBackup("acme", "prod", "orders-db", "mysql", "orders-db-backup-2", kbcloud.BackupStatusCompleted),
+			newBackup("acme", "prod", "orders-db", "mysql", "orders-db-backup-3", kbcloud.BackupStatusRunning),
+			newBackup("acme", "staging", "analytics", "postgresql", "analytics-backup-1", kbcloud.BackupStatusFailed),
+			newBackup("globex", "dev", "inventory", "mongodb", "inventory-backup-1", kbcloud.BackupStatusCompleted),
+		},
+		Tags: map[string]map[string]string{
+			"acme/orders-db":   {"team": "commerce", "tier": "critical"},
+			"acme/cache":       {"team": "commerce"},
+			"acme/analytics":   {"team": "data"},
+			"globex/inventory": {"team": "platform"},
+		},
+		Accounts: map[string][]kbcloud.Account{
+			"acme/orders-db": {
+				newAccount("root", kbcloud.AccountRoleTypeRoot),
+				newAccount("app_orders", kbcloud.AccountRoleTypeBasicuser, newPrivilege("orders", kbcloud.PrivilegeTypeReadwrite)),
+			},
+			"acme/cache":       {newAccount("default", kbcloud.AccountRoleTypeRoot)},
+			"acme/analytics":   {newAccount("postgres", kbcloud.AccountRoleTypeRoot)},
+			"globex/inventory": {newAccount("root", kbcloud.AccountRoleTypeRoot)},
+		},
+		Databases: map[string][]kbcloud.Database{
+			"acme/orders-db": {*kbcloud.NewDatabase("orders")},
+			"acme/analytics": {*kbcloud.NewDatabase("analytics"), *kbcloud.NewDatabase("events")},
+		},
+		Endpoints: map[string][]kbcloud.Endpoint{
+			"acme/orders-db": {
+				newEndpoint("mysql", "orders-db-mysql.ns-acme.svc.cluster.local", 3306, "mysql", kbcloud.EndpointNetworkTypeIntranet),
+				newEndpoint("mysql", "orders-db-mysql.ns-acme.svc.cluster.local", 9104, "http-metrics", kbcloud.EndpointNetworkTypeIntranet),
+				newEndpoint("mysql", "orders-db.acme.us-east-1.elb.amazonaws.com", 3306, "mysql", kbcloud.EndpointNetworkTypeInternet),
+			},
+			"acme/cache":       {newEndpoint("redis", "cache-redis.ns-acme.svc.cluster.local", 6379, "redis", kbcloud.EndpointNetworkTypeIntranet)},
+			"acme/analytics":   {newEndpoint("postgresql", "analytics-postgresql.ns-acme.svc.cluster.local", 5432, "tcp-postgresql", kbcloud.EndpointNetworkTypeIntranet)},
+			"globex/inventory": {newEndpoint("mongodb", "inventory-mongodb.ns-globex.svc.cluster.local", 27017, "mongodb", kbcloud.EndpointNetworkTypeIntranet)},
+		},
+		IPWhitelists: map[string][]kbcloud.IpWhitelist{
+			"acme/orders-db": {
+				newIPWhitelist("office", "203.0.113.0/24"),
+				newIPWhitelist("ci", "198.51.100.7/32"),
+			},
+		},
+		Metrics: map[string][]MetricSeries{
+			"acme/orders-db": {
+				{Metric: "container_cpu_usage_seconds_total", Values: []float64{0.2, 0.25, 0.3, 0.3, 0.35, 0.4, 0.45, 0.5, 0.6, 1.8, 1.9, 0.4}},
+				{Metric: "container_memory_working_set_bytes", Values: []float64{1.5e9, 1.5e9, 1.6e9, 1.6e9, 1.6e9, 1.7e9, 1.7e9, 1.7e9, 1.8e9, 1.9e9, 1.9e9, 1.8e9}},
+				{Metric: "mysql_global_status_threads_connected", Values: []float64{40, 42, 41, 45, 50, 52, 55, 60, 80, 140, 150, 70}},
+				{Metric: "mysql_global_status_queries", Values: []float64{300, 310, 305, 320, 340, 350, 360, 380, 450, 900, 950, 400}},
+			},
+		},
+		Logs: map[string]map[string][]LogEntry{
+			"acme/orders-db": {
+				"error": {
+					newLogEntry(5*time.Minute, "mysql", "orders-db-mysql-0", "[Warning] [MY-010055] [Server] IP address '10.0.1.9' could not be resolved: Name or service not known"),
+					newLogEntry(20*time.Minute, "mysql", "orders-db-mysql-0", "[ERROR] [MY-012574] [InnoDB] Deadlock found when trying to get lock; try restarting transaction"),
+					newLogEntry(40*time.Minute, "mysql", "orders-db-mysql-1", "[Note] [MY-010914] [Server] Aborted connection 812 to db: 'orders' user: 'app_orders' (Got timeout reading communication packets)"),
+					newLogEntry(41*time.Minute, "mysql", "orders-db-mysql-0", "[ERROR] [MY-012574] [InnoDB] Deadlock found when trying to get lock; try restarting transaction"),
+				},
+				"slow": {
+					newLogEntry(10*time.Minute, "mysql", "orders-db-mysql-0", mysqlSlowLog(2.5, "SELECT * FROM orders WHERE customer_id = 42;")),
+					newLogEntry(25*time.Minute, "mysql", "orders-db-mysql-0", mysqlSlowLog(3.1, "SELECT * FROM orders\n  WHERE customer_id = 1007;")),
+					newLogEntry(45*time.Minute, "mysql", "orders-db-mysql-0", mysqlSlowLog(12, "UPDATE inventory SET stock = stock - 1 WHERE sku IN ('A-1', 'B-2', 'C-3');")),
+					newLogEntry(50*time.Minute, "mysql", "orders-db-mysql-0", mysqlSlowLog(4, "select * from orders where customer_id=7 /* api */;")),
+					newLogEntry(55*time.Minute, "mysql", "orders-db-mysql-0", "/usr/sbin/mysqld, Version: 8.0.33 (MySQL Community Server - GPL). started with:"),
+				},
+			},
+			"acme/analytics": {
+				"slow": {
+					newLogEntry(12*time.Minute, "postgresql", "analytics-postgresql-0", "LOG:  duration: 1500.250 ms  statement: SELECT count(*) FROM events WHERE kind = 'click' AND ts > $1"),
+					newLogEntry(30*time.Minute, "postgresql", "analytics-postgresql-0", "LOG:  duration: 2500 ms  execute <unnamed>: SELECT count(*) FROM events WHERE kind = 'view' AND ts > $1"),
+				},
+			},
+		},
+		Events: []kbcloud.Event{
+			newEvent("acme", "orders-db", "CreateCluster", kbcloud.EventResultStatusSuccess, "cluster orders-db created", 0),
+			newEvent("acme", "cache", "CreateCluster", kbcloud.EventResultStatusSuccess, "cluster cache created", 0),
+			newEvent("acme", "cache", "RestartCluster", kbcloud.EventResultStatusFailed, "pod cache-redis-0 failed readiness probe: connection refused", 10*time.Minute),
+			newEvent("acme", "cache", "RestartCluster", kbcloud.EventResultStatusFailed, "pod cache-redis-0 failed readiness probe: connection refused", 20*time.Minute),
+			newEvent("acme", "cache", "RestartCluster", kbcloud.EventResultStatusFailed, "pod cache-redis-0 failed readiness probe: connection refused", 30*time.Minute),
+		},
+		Pods: map[string][]kbcloud.Instance{
+			"acme/orders-db": {newPod("orders-db", "mysql", "primary", "ip-10-0-1-12", true, 0, "", "")},
+			"acme/cache": {newPod("cache", "redis", "primary", "ip-10-0-2-31", false, 7,
+				"CrashLoopBackOff", "back-off 5m0s restarting failed container=redis pod=cache-redis-0")},
+			"globex/inventory": {newPod("inventory", "mongodb", "primary", "ip-10-1-0-5", true, 0, "", "")},
+		},
+		Parameters: map[string][]Parameter{
+			"acme/orders-db": {
+				newParameter("mysql", "my.cnf", "innodb_buffer_pool_size", "integer", 1073741824.0, 134217728.0, false).withRange(5242880, 1099511627776),
+				newParameter("mysql", "my.cnf", "innodb_log_file_size", "integer", 50331648.0, 50331648.0, true).withRange(4194304, 549755813888),
+				newParameter("mysql", "my.cnf", "long_query_time", "number", 2.0, 10.0, false).withRange(0, 31536000),
+				newParameter("mysql", "my.cnf", "lower_case_table_names", "integer", 0.0, 0.0, true).withEnum(0.0, 1.0, 2.0).immutable(),
+				newParameter("mysql", "my.cnf", "max_connections", "integer", 151.0, 151.0, false).withRange(1, 100000),
+				newParameter("mysql", "my.cnf", "slow_query_log", "boolean", true, false, false),
+				newParameter("mysql", "my.cnf", "transaction_isolation", "string", "REPEATABLE-READ", "REPEATABLE-READ", false).
+					withEnum("READ-UNCOMMITTED", "READ-COMMITTED", "REPEATABLE-READ", "SERIALIZABLE"),
+			},
+			"acme/analytics": {
+				newParameter("postgresql", "postgresql.conf", "log_min_duration_statement", "integer", -1.0, -1.0, false).withRange(-1, 2147483647),
+				newParameter("postgresql", "postgresql.conf", "max_connections", "integer", 200.0, 100.0, true).withRange(1, 262143),
+				newParameter("postgresql", "postgresql.conf", "shared_buffers", "string", "1GB", "128MB", true),
+				newParameter("postgresql", "postgresql.conf", "work_mem", "string", "4MB", "4MB", false),
+			},
+		},
+		ParameterTemplates: map[string][]ParameterTemplate{
+			"acme": {
+				newParameterTemplate("acme", "mysql8.0-default", "mysql8.0", kbcloud.ParamTplPartitionDefault, "Default parameters of MySQL 8.0",
+					map[string]string{"my.cnf": "[mysqld]\nmax_connections=151\nslow_query_log=OFF\n"}),
+				newParameterTemplate("acme", "mysql-oltp", "mysql8.0", kbcloud.ParamTplPartitionCustom, "Short transactions at high concurrency",
+					map[string]string{"my.cnf": "[mysqld]\n# tuned for checkout traffic\nmax_connections = 2000\nslow_query_log=ON\nlong_query_time=0.5\ntransaction_isolation=READ-COMMITTED\n"}),
+				newParameterTemplate("acme", "mysql-reporting", "mysql8.0", kbcloud.ParamTplPartitionCustom, "Long-running reporting queries",
+					map[string]string{"my.cnf": "[mysqld]\nmax_connections=500\nquery_cache_type=1\n"}),
+				newParameterTemplate("acme", "postgresql-reporting", "postgresql15", kbcloud.ParamTplPartitionCustom, "Large sorts for reporting",
+					map[string]string{"postgresql.conf": "shared_buffers = '2GB'\nwork_mem = '64MB'    # per sort\n"}),
+			},
+		},
+		Engines: map[string][]kbcloud.Engine{
+			"prod": {
+				newEngine("mysql", kbcloud.EngineTypeRdbms, kbcloud.EngineStatusEnabled),
+				newEngine("postgresql", kbcloud.EngineTypeRdbms, kbcloud.EngineStatusEnabled),
+				newEngine("redis", kbcloud.EngineTypeKeyValue, kbcloud.EngineStatusEnabled),
+				newEngine("mongodb", kbcloud.EngineTypeDocument, kbcloud.EngineStatusDisabled),
+			},
+			"staging": {
+				newEngine("postgresql", kbcloud.EngineTypeRdbms, kbcloud.EngineStatusEnabled),
+				newEngine("mysql", kbcloud.EngineTypeRdbms, kbcloud.EngineStatusEnabled),
+			},
+			"dev": {newEngine("mongodb", kbcloud.EngineTypeDocument, kbcloud.EngineStatusEnabled)},
+		},
+		EngineVersions: map[string][]kbcloud.EngineServiceVersionsVersionsItem{
+			"mysql":      {newEngineVersion("8.0", true, "8.0.33", "8.0.30"), newEngineVersion("5.7", false, "5.7.44")},
+			"postgresql": {newEngineVersion("15", true, "15.7.0"), newEngineVersion("14", false, "14.8.0")},
+			"redis":      {newEngineVersion("7.0", true, "7.0.6"), newEngineVersion("7.2", false, "7.2.4")},
+			"mongodb":    {newEngineVersion("6.0", true, "6.0.16")},
+		},
+		Classes: []kbcloud.Class{
+			newClass("mysql", "general-2c4g", 2, 4),
+			newClass("mysql", "general-1c2g", 1, 2),
+			newClass("mysql", "memory-4c32g", 4, 32),
+			newClass("postgresql", "general-1c2g", 1, 2),
+			newClass("postgresql", "general-2c8g", 2, 8),
+			newClass("redis", "general-1c1g", 1, 1),
+			newClass("mongodb", "general-2c4g", 2, 4),
+		},
+		StorageClasses: map[string][]kbcloud.StorageClassInfo{
+			"acme/prod":    {newStorageClass("io2", "io2", false, true), newStorageClass("gp3", "gp3", true, true)},
+			"acme/staging": {newStorageClass("gp3", "gp3", true, true)},
+			"globex/dev":   {newStorageClass("pd-ssd", "pd-ssd", true, false)},
+		},
+	}
+}
+
+// clone returns a copy of f that can be mutated without affecting f
+func (f *Fixtures) clone() *Fixtures {
+	tags := make(map[string]map[string]string, len(f.Tags))
+	for cluster, t := range f.Tags {
+		tags[cluster] = maps.Clone(t)
+	}
+	accounts := make(map[string][]kbcloud.Account, len(f.Accounts))
+	for cluster, a := range f.Accounts {
+		accounts[cluster] = append([]kbcloud.Account(nil), a...)
+	}
+	databases := make(map[string][]kbcloud.Database, len(f.Databases))
+	for cluster, d := range f.Databases {
+		databases[cluster] = append([]kbcloud.Database(nil), d...)
+	}
+	parameters := make(map[string][]Parameter, len(f.Parameters))
+	for cluster, p := range f.Parameters {
+		parameters[cluster] = append([]Parameter(nil), p...)
+	}
+	templates := make(map[string][]ParameterTemplate, len(f.ParameterTemplates))
+	for org, t := range f.ParameterTemplates {
+		templates[org] = append([]ParameterTemplate(nil), t...)
+	}
+	engines := make(map[string][]kbcloud.Engine, len(f.Engines))
+	for env, e := range f.Engines {
+		engines[env] = append([]kbcloud.Engine(nil), e...)
+	}
+	storageClasses := make(map[string][]kbcloud.StorageClassInfo, len(f.StorageClasses))
+	for env, c := range f.StorageClasses {
+		storageClasses[env] = append([]kbcloud.StorageClassInfo(nil), c...)
+	}
+	endpoints := make(map[string][]kbcloud.Endpoint, len(f.Endpoints))
+	for cluster, e := range f.Endpoints {
+		endpoints[cluster] = append([]kbcloud.Endpoint(nil), e...)
+	}
+	whitelists := make(map[string][]kbcloud.IpWhitelist, len(f.IPWhitelists))
+	for cluster, w := range f.IPWhitelists {
+		whitelists[cluster] = make([]kbcloud.IpWhitelist, 0, len(w))
+		for _, whitelist := range w {
+			whitelist.Addresses = append([]string(nil), whitelist.Addresses...)
+			whitelists[cluster] = append(whitelists[cluster], whitelist)
+		}
+	}
+	series := make(map[string][]MetricSeries, len(f.Metrics))
+	for cluster, m := range f.Metrics {
+		series[cluster] = append([]MetricSeries(nil), m...)
+	}
+	logs := make(map[string]map[string][]LogEntry, len(f.Logs))
+	for cluster, l := range f.Logs {
+		logs[cluster] = make(map[string][]LogEntry, len(l))
+		for logType, entries := range l {
+			logs[cluster][logType] = append([]LogEntry(nil), entries...)
+		}
+	}
+	pods := make(map[string][]kbcloud.Instance, len(f.Pods))
+	for cluster, p := range f.Pods {
+		pods[cluster] = make([]kbcloud.Instance, 0, len(p))
+		for _, pod := range p {
+			pod.AdditionalProperties = maps.Clone(pod.AdditionalProperties)
+			pods[cluster] = append(pods[cluster], pod)
+		}
+	}
+	return &Fixtures{
+		Organizations:      append([]kbcloud.Org(nil), f.Organizations...),
+		Environments:       append([]kbcloud.Environment(nil), f.Environments...),
+		Clusters:           append([]kbcloud.Cluster(nil), f.Clusters...),
+		Backups:            append([]kbcloud.Backup(nil), f.Backups...),
+		Tags:               tags,
+		Accounts:           accounts,
+		Databases:          databases,
+		Parameters:         parameters,
+		ParameterTemplates: templates,
+		Engines:            engines,
+		EngineVersions:     maps.Clone(f.EngineVersions),
+		Classes:            append([]kbcloud.Class(nil), f.Classes...),
+		StorageClasses:     storageClasses,
+		Endpoints:          endpoints,
+		IPWhitelists:       whitelists,
+		Metrics:            series,
+		Logs:               logs,
+		Events:             append([]kbcloud.Event(nil), f.Events...),
+		Pods:               pods,
+	}
+}
+
+func newLogEntry(offset time.Duration, component, pod, content string) LogEntry {
+	return LogEntry{Time: fixtureTime.Add(offset), Component: component, Pod: pod, Content: content}
+}
+
+// mysqlSlowLog formats a MySQL slow log entry of statement
+func mysqlSlowLog(seconds float64, statement string) string {
+	return fmt.Sprintf("# User@Host: app_orders[app_orders] @  [10.0.1.5]  Id:    81\n"+
+		"# Query_time: %f  Lock_time: 0.000100 Rows_sent: 1  Rows_examined: 500000\n"+
+		"use orders;\nSET timestamp=1705306200;\n%s", seconds, statement)
+}
+
+func newOrg(name, displayName string) kbcloud.Org {
+	org := kbcloud.NewOrg(fixtureTime, name, fixtureTime, true)
+	org.SetId("org-" + name)
+	org.SetDisplayName(displayName)
+	return *org
+}
+
+func newEnvironment(org, name, provider, region, id string) kbcloud.Environment {
+	env := kbcloud.NewEnvironment(
+		provider,
+		region,
+		[]string{region + "a", region + "b"},
+		fixtureTime,
+		uuid.MustParse(id),
+		name,
+		org,
+		kbcloud.EnvironmentStateReady,
+		kbcloud.EnvironmentTypePublic,
+		fixtureTime,
+		"standard",
+	)
+	env.SetDisplayName(name)
+	return *env
+}
+
+func newCluster(org, env, name, engine, version, status string) kbcloud.Cluster {
+	cluster := kbcloud.NewCluster(env, name, engine)
+	cluster.SetId("cluster-" + name)
+	cluster.SetOrgName(org)
+	cluster.SetCloudProvider("aws")
+	cluster.SetVersion(version)
+	cluster.SetStatus(status)
+	cluster.SetMode("standalone")
+	component := kbcloud.NewComponentItem()
+	component.SetName(engine)
+	component.SetComponent(engine)
+	component.SetReplicas(1)
+	component.SetCpu(1)
+	component.SetMemory(2)
+	cluster.SetComponents([]kbcloud.ComponentItem{*component})
+	cluster.SetTerminationPolicy(kbcloud.ClusterTerminationPolicyDelete)
+	cluster.SetCreatedAt(fixtureTime)
+	cluster.SetUpdatedAt(fixtureTime)
+	return *cluster
+}
+
+func newBackup(org, env, cluster, engine, name string, status kbcloud.BackupStatus) kbcloud.Backup {
+	backup := kbcloud.NewBackup(
+		false,
+		"xtrabackup",
+		cluster+"-backup-policy",
+		kbcloud.BackupTypeFull,
+		fixtureTime,
+		name,
+		org,
+		false,
+		cluster,
+		status,
+		"1Gi",
+		"7d",
+		"aws",
+		"us-east-1",
+		env,
+		engine,
+	)
+	backup.SetId(name)
+	return *backup
+}
+
+func newAccount(name string, role kbcloud.AccountRoleType, privileges ...kbcloud.PrivilegeListItem) kbcloud.Account {
+	account := kbcloud.NewAccount(name, role)
+	account.SetPassword(name + "-password")
+	if len(privileges) > 0 {
+		account.SetPrivilegesList(privileges)
+	}
+	return *account
+}
+
+func newPrivilege(database string, privileges kbcloud.PrivilegeType) kbcloud.PrivilegeListItem {
+	privilege := kbcloud.NewPrivilegeListItem(privileges)
+	if database != "" {
+		privilege.SetDatabaseName(database)
+	}
+	return *privilege
+}
@@ -0,0 +1,36 @@
+package kbcloudtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// AssertGolden compares got with the golden file at path, rewriting the file
+// instead when update is set. JSON output is indented so golden files diff
+// cleanly in review.
+func AssertGolden(t testing.TB, path, got string, update bool) {
+	t.Helper()
+
+	rendered := []byte(got)
+	var buf bytes.Buffer
+	if json.Indent(&buf, rendered, "", "  ") == nil {
+		rendered = buf.Bytes()
+	}
+	rendered = append(bytes.TrimRight(rendered, "\n"), '\n')
+
+	if update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, rendered, 0o644))
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err, "missing golden file, run the test with -update to create it")
+	assert.Equal(t, string(want), string(rendered), "output changed; if this is intended, run the test with -update and review the diff of %s", path)
+}
@@ -0,0 +1,77 @@
+package kbcloudtest
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+)
+
+// LogEntry is a fixture log entry of a cluster pod
+type LogEntry struct {
+	Time      time.Time
+	Component string
+	Pod       string
+	Content   string
+}
+
+// queryLogs returns a handler answering queries of the logType log of a
+// cluster, filtered by time range, component and pod like the real API
+func (s *Server) queryLogs(logType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		orgName, clusterName := r.PathValue("orgName"), r.PathValue("clusterName")
+		if s.findCluster(orgName, clusterName) == nil {
+			writeNotFound(w, "cluster", clusterName)
+			return
+		}
+		query := r.URL.Query()
+		start, startErr := strconv.ParseInt(query.Get("startTime"), 10, 64)
+		end, endErr := strconv.ParseInt(query.Get("endTime"), 10, 64)
+		if startErr != nil || endErr != nil || start >= end {
+			writeError(w, http.StatusBadRequest, "invalid log time range")
+			return
+		}
+		limit := len(s.fixtures.Logs[orgName+"/"+clusterName][logType])
+		if l := query.Get("limit"); l != "" {
+			var err error
+			if limit, err = strconv.Atoi(l); err != nil || limit < 1 {
+				writeError(w, http.StatusBadRequest, "invalid limit")
+				return
+			}
+		}
+
+		var entries []LogEntry
+		for _, e := range s.fixtures.Logs[orgName+"/"+clusterName][logType] {
+			if e.Time.Unix() < start || e.Time.Unix() > end {
+				continue
+			}
+			if c := query.Get("componentName"); c != "" && c != e.Component {
+				continue
+			}
+			if p := query.Get("instanceName"); p != "" && p != e.Pod {
+				continue
+			}
+			entries = append(entries, e)
+		}
+		slices.SortStableFunc(entries, func(a, b LogEntry) int { return a.Time.Compare(b.Time) })
+		if query.Get("sortType") == string(kbcloud.SortTypeDesc) {
+			slices.Reverse(entries)
+		}
+
+		items := []map[string]any{}
+		for _, e := range entries[:min(limit, len(entries))] {
+			items = append(items, map[string]any{
+				"timestamp":     e.Time.Format(time.RFC3339Nano),
+				"componentName": e.Component,
+				"instanceName":  e.Pod,
+				"content":       e.Content,
+			})
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"items": items})
+	}
+}
@@ -0,0 +1,57 @@
+package kbcloudtest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+)
+
+// MetricSeries is a fixture series of a cluster, served for queries naming
+// Metric. Its values are spread evenly over the queried range.
+type MetricSeries struct {
+	Metric string
+	Values []float64
+}
+
+// queryMetrics answers a range query with the first fixture series the
+// query names, or no samples
+func (s *Server) queryMetrics(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orgName, clusterName := r.PathValue("orgName"), r.PathValue("clusterName")
+	if s.findCluster(orgName, clusterName) == nil {
+		writeNotFound(w, "cluster", clusterName)
+		return
+	}
+	query := r.URL.Query()
+	start, startErr := strconv.ParseInt(query.Get("start"), 10, 64)
+	end, endErr := strconv.ParseInt(query.Get("end"), 10, 64)
+	if query.Get("query") == "" || startErr != nil || endErr != nil || start >= end {
+		writeError(w, http.StatusBadRequest, "invalid metrics query")
+		return
+	}
+	if query.Get("queryType") != string(kbcloud.MetricsQueryTypeRange) {
+		writeError(w, http.StatusBadRequest, "only range queries are supported")
+		return
+	}
+
+	result := kbcloud.ClusterMetrics{Values: [][]interface{}{}}
+	for _, series := range s.fixtures.Metrics[orgName+"/"+clusterName] {
+		if !strings.Contains(query.Get("query"), series.Metric+"{") {
+			continue
+		}
+		n := len(series.Values)
+		for i, v := range series.Values {
+			ts := start
+			if n > 1 {
+				ts += int64(i) * (end - start) / int64(n-1)
+			}
+			result.Values = append(result.Values, []interface{}{float64(ts), strconv.FormatFloat(v, 'f', -1, 64)})
+		}
+		break
+	}
+	writeJSON(w, http.StatusOK, result)
+}
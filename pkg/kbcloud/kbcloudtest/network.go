@@ -0,0 +1,186 @@
+package kbcloudtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+)
+
+// opsExpose enables or disables the public endpoint of a running cluster
+var opsExpose = opsKind{name: "expose", from: "Running", transient: "Updating", completion: "Running"}
+
+// ipWhitelistBody is the body of IP whitelist create and update requests
+type ipWhitelistBody struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Addresses   []string `json:"addresses"`
+}
+
+// IPWhitelist returns the addresses of the named IP whitelist of a cluster
+func (s *Server) IPWhitelist(orgName, clusterName, name string) ([]string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, w := range s.fixtures.IPWhitelists[orgName+"/"+clusterName] {
+		if w.Name == name {
+			return append([]string(nil), w.Addresses...), true
+		}
+	}
+	return nil, false
+}
+
+// expose adds or removes the internet endpoints of a cluster component as
+// an operation. Public endpoints mirror the component's internal ones.
+func (s *Server) expose(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orgName, clusterName := r.PathValue("orgName"), r.PathValue("clusterName")
+	var body kbcloud.OpsExpose
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Component == "" {
+		writeError(w, http.StatusBadRequest, "invalid expose request")
+		return
+	}
+	if body.Type != kbcloud.OpsExposeTypeInternet {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unsupported expose type %s", body.Type))
+		return
+	}
+
+	name, ok := s.beginOperation(w, opsExpose, orgName, clusterName)
+	if !ok {
+		return
+	}
+	key := orgName + "/" + clusterName
+	var endpoints, internal []kbcloud.Endpoint
+	for _, e := range s.fixtures.Endpoints[key] {
+		if e.Component == body.Component && e.NetworkType == kbcloud.EndpointNetworkTypeInternet {
+			continue
+		}
+		endpoints = append(endpoints, e)
+		if e.Component == body.Component {
+			internal = append(internal, e)
+		}
+	}
+	if body.Enable {
+		for _, e := range internal {
+			host := fmt.Sprintf("%s-%s.%s.elb.amazonaws.com", clusterName, body.Component, orgName)
+			endpoints = append(endpoints, newEndpoint(e.Component, host, e.Port, e.PortName, kbcloud.EndpointNetworkTypeInternet))
+		}
+	}
+	s.fixtures.Endpoints[key] = endpoints
+	writeJSON(w, http.StatusOK, kbcloud.NewOpsRequestName(name))
+}
+
+func (s *Server) listIPWhitelists(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orgName, clusterName := r.PathValue("orgName"), r.PathValue("clusterName")
+	if s.findCluster(orgName, clusterName) == nil {
+		writeNotFound(w, "cluster", clusterName)
+		return
+	}
+	items := append([]kbcloud.IpWhitelist{}, s.fixtures.IPWhitelists[orgName+"/"+clusterName]...)
+	writeJSON(w, http.StatusOK, kbcloud.IpWhitelistList{Items: items})
+}
+
+func (s *Server) createIPWhitelist(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orgName, clusterName := r.PathValue("orgName"), r.PathValue("clusterName")
+	if s.findCluster(orgName, clusterName) == nil {
+		writeNotFound(w, "cluster", clusterName)
+		return
+	}
+	body, ok := decodeIPWhitelist(w, r)
+	if !ok {
+		return
+	}
+	key := orgName + "/" + clusterName
+	for _, existing := range s.fixtures.IPWhitelists[key] {
+		if existing.Name == body.Name {
+			writeError(w, http.StatusConflict, fmt.Sprintf("ip whitelist %s already exists", body.Name))
+			return
+		}
+	}
+
+	whitelist := newIPWhitelist(body.Name, body.Addresses...)
+	if body.Description != "" {
+		whitelist.SetDescription(body.Description)
+	}
+	s.fixtures.IPWhitelists[key] = append(s.fixtures.IPWhitelists[key], whitelist)
+	writeJSON(w, http.StatusOK, whitelist)
+}
+
+func (s *Server) updateIPWhitelist(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	whitelists, i := s.findIPWhitelist(r)
+	if i < 0 {
+		writeNotFound(w, "ip whitelist", r.PathValue("ipWhitelistId"))
+		return
+	}
+	body, ok := decodeIPWhitelist(w, r)
+	if !ok {
+		return
+	}
+	whitelists[i].Addresses = body.Addresses
+	if body.Description != "" {
+		whitelists[i].SetDescription(body.Description)
+	}
+	writeJSON(w, http.StatusOK, whitelists[i])
+}
+
+func (s *Server) deleteIPWhitelist(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	whitelists, i := s.findIPWhitelist(r)
+	if i < 0 {
+		writeNotFound(w, "ip whitelist", r.PathValue("ipWhitelistId"))
+		return
+	}
+	key := r.PathValue("orgName") + "/" + r.PathValue("clusterName")
+	s.fixtures.IPWhitelists[key] = append(whitelists[:i:i], whitelists[i+1:]...)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findIPWhitelist returns the whitelists of the cluster of a request and the
+// index of the one it targets, or -1; s.mu must be held
+func (s *Server) findIPWhitelist(r *http.Request) ([]kbcloud.IpWhitelist, int) {
+	whitelists := s.fixtures.IPWhitelists[r.PathValue("orgName")+"/"+r.PathValue("clusterName")]
+	for i := range whitelists {
+		if whitelists[i].Id == r.PathValue("ipWhitelistId") {
+			return whitelists, i
+		}
+	}
+	return whitelists, -1
+}
+
+// decodeIPWhitelist reads a whitelist request body, writing an error when it
+// has no name or addresses. Like KB Cloud, addresses must be IPs or CIDRs.
+func decodeIPWhitelist(w http.ResponseWriter, r *http.Request) (ipWhitelistBody, bool) {
+	var body ipWhitelistBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Name == "" || len(body.Addresses) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid ip whitelist request")
+		return body, false
+	}
+	for _, address := range body.Addresses {
+		if strings.TrimSpace(address) == "" || strings.ContainsAny(address, " ,") {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid address %q", address))
+			return body, false
+		}
+	}
+	return body, true
+}
+
+// newIPWhitelist returns an IP whitelist fixture
+func newIPWhitelist(name string, addresses ...string) kbcloud.IpWhitelist {
+	whitelist := kbcloud.NewIpWhitelist("ipw-"+name, name, addresses)
+	whitelist.SetCreatedAt(fixtureTime)
+	return *whitelist
+}
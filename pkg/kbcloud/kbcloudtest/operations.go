@@ -0,0 +1,106 @@
+package kbcloudtest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+)
+
+// Operation phases reported by the ops request status endpoint
+const (
+	OpsPhaseRunning = "Running"
+	OpsPhaseSucceed = "Succeed"
+)
+
+// opsKind describes how an operation moves a cluster between statuses
+type opsKind struct {
+	name       string
+	from       string
+	transient  string
+	completion string
+}
+
+var (
+	opsStart   = opsKind{name: "start", from: "Stopped", transient: "Starting", completion: "Running"}
+	opsStop    = opsKind{name: "stop", from: "Running", transient: "Stopping", completion: "Stopped"}
+	opsRestart = opsKind{name: "restart", from: "Running", transient: "Restarting", completion: "Running"}
+)
+
+// operation is an ops request in flight
+type operation struct {
+	kind    opsKind
+	org     string
+	cluster string
+	polls   int
+	done    bool
+}
+
+// startOperation handles a cluster operation request. The cluster moves to
+// the transient status immediately and completes after opsSteps polls of
+// the ops request status.
+func (s *Server) startOperation(kind opsKind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		name, ok := s.beginOperation(w, kind, r.PathValue("orgName"), r.PathValue("clusterName"))
+		if !ok {
+			return
+		}
+		writeJSON(w, http.StatusOK, kbcloud.NewOpsRequestName(name))
+	}
+}
+
+// beginOperation moves a cluster to the transient status of kind and
+// returns the name of the new ops request, writing an error when the
+// cluster does not exist or is not in the status kind starts from;
+// s.mu must be held
+func (s *Server) beginOperation(w http.ResponseWriter, kind opsKind, orgName, clusterName string) (string, bool) {
+	cluster := s.findCluster(orgName, clusterName)
+	if cluster == nil {
+		writeNotFound(w, "cluster", clusterName)
+		return "", false
+	}
+	if cluster.GetStatus() != kind.from {
+		writeError(w, http.StatusConflict, fmt.Sprintf("cannot %s cluster %s in status %s", kind.name, clusterName, cluster.GetStatus()))
+		return "", false
+	}
+
+	s.opsSeq++
+	name := fmt.Sprintf("%s-%s-%d", clusterName, kind.name, s.opsSeq)
+	s.ops[name] = &operation{kind: kind, org: orgName, cluster: clusterName}
+	cluster.SetStatus(kind.transient)
+	return name, true
+}
+
+// getOperation reports the status of an ops request, advancing it by one step
+func (s *Server) getOperation(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	opsName := r.PathValue("opsName")
+	op, ok := s.ops[opsName]
+	if !ok || op.org != r.PathValue("orgName") || op.cluster != r.PathValue("clusterName") {
+		writeNotFound(w, "ops request", opsName)
+		return
+	}
+
+	op.polls++
+	if !op.done && op.polls >= s.opsSteps {
+		op.done = true
+		if cluster := s.findCluster(op.org, op.cluster); cluster != nil {
+			cluster.SetStatus(op.kind.completion)
+		}
+	}
+
+	status := kbcloud.NewOps_opsStatus()
+	if op.done {
+		status.Status = OpsPhaseSucceed
+		status.SetMessage(fmt.Sprintf("%s of cluster %s succeeded", op.kind.name, op.cluster))
+	} else {
+		status.Status = OpsPhaseRunning
+		status.SetMessage(fmt.Sprintf("%s of cluster %s in progress", op.kind.name, op.cluster))
+	}
+	writeJSON(w, http.StatusOK, status)
+}
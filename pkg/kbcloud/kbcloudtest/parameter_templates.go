@@ -0,0 +1,174 @@
+package kbcloudtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+)
+
+// ParameterTemplate is a reusable set of engine parameters of an organization
+type ParameterTemplate struct {
+	Item kbcloud.ParamTplListItem
+	// Files are the configuration files of the template, keyed by file name
+	Files map[string]string
+}
+
+// ParameterTemplate returns the current state of a custom parameter template
+func (s *Server) ParameterTemplate(orgName, name string) (ParameterTemplate, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t := s.findParameterTemplate(orgName, name, string(kbcloud.ParamTplPartitionCustom)); t != nil {
+		return *t, true
+	}
+	return ParameterTemplate{}, false
+}
+
+// findParameterTemplate returns the named template, from any partition when
+// partition is empty; s.mu must be held
+func (s *Server) findParameterTemplate(orgName, name, partition string) *ParameterTemplate {
+	templates := s.fixtures.ParameterTemplates[orgName]
+	for i := range templates {
+		t := &templates[i]
+		if t.Item.Name == name && (partition == "" || t.Item.Partition == partition) {
+			return t
+		}
+	}
+	return nil
+}
+
+func (s *Server) listParameterTemplates(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orgName := r.PathValue("orgName")
+	if s.findOrg(orgName) == nil {
+		writeNotFound(w, "organization", orgName)
+		return
+	}
+
+	partition := r.URL.Query().Get("partition")
+	items := []kbcloud.ParamTplListItem{}
+	for _, t := range s.fixtures.ParameterTemplates[orgName] {
+		if partition == "" || t.Item.Partition == partition {
+			items = append(items, t.Item)
+		}
+	}
+	writeJSON(w, http.StatusOK, kbcloud.ParamTplList{Items: items, PageResult: pageResult(len(items))})
+}
+
+func (s *Server) readParameterTemplate(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orgName, name := r.PathValue("orgName"), r.PathValue("paramTplName")
+	if s.findOrg(orgName) == nil {
+		writeNotFound(w, "organization", orgName)
+		return
+	}
+	t := s.findParameterTemplate(orgName, name, r.URL.Query().Get("partition"))
+	if t == nil {
+		writeNotFound(w, "parameter template", name)
+		return
+	}
+
+	template := kbcloud.NewParamTplGet()
+	template.SetFamily(t.Item.Family)
+	files := make([]string, 0, len(t.Files))
+	for file := range t.Files {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		spec := kbcloud.NewParameterSpecListItem()
+		spec.SetFileName(file)
+		config := kbcloud.NewConfigurationWithRegex(file, t.Files[file], "")
+		template.Items = append(template.Items, *kbcloud.NewParamTplGetItem(t.Item.Family+"-config", *config, *spec))
+	}
+	writeJSON(w, http.StatusOK, template)
+}
+
+// createParameterTemplateFromCluster saves the current parameters of a
+// cluster component as a custom template
+func (s *Server) createParameterTemplateFromCluster(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orgName, clusterName := r.PathValue("orgName"), r.PathValue("clusterName")
+	cluster := s.findCluster(orgName, clusterName)
+	if cluster == nil {
+		writeNotFound(w, "cluster", clusterName)
+		return
+	}
+	var body kbcloud.ParamTplCreateFromCluster
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Name == "" {
+		writeError(w, http.StatusBadRequest, "invalid parameter template")
+		return
+	}
+	if s.findParameterTemplate(orgName, body.Name, string(kbcloud.ParamTplPartitionCustom)) != nil {
+		writeError(w, http.StatusConflict, fmt.Sprintf("parameter template %s already exists", body.Name))
+		return
+	}
+
+	component := body.GetComponent()
+	if component == "" && len(cluster.GetComponents()) > 0 {
+		component = cluster.GetComponents()[0].GetComponent()
+	}
+	files := map[string]string{}
+	for _, p := range s.fixtures.Parameters[orgName+"/"+clusterName] {
+		if p.Component == component {
+			files[p.File] += fmt.Sprintf("%s=%s\n", p.Prop.Name, formatParameterValue(p.Prop.Value))
+		}
+	}
+	if len(files) == 0 {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("component %s of cluster %s has no parameters", component, clusterName))
+		return
+	}
+
+	version := body.GetEngineVersion()
+	if version == "" {
+		version = cluster.GetVersion()
+	}
+	template := newParameterTemplate(orgName, body.Name, templateFamily(cluster.Engine, version), kbcloud.ParamTplPartitionCustom, body.Description, files)
+	if s.fixtures.ParameterTemplates == nil {
+		s.fixtures.ParameterTemplates = map[string][]ParameterTemplate{}
+	}
+	s.fixtures.ParameterTemplates[orgName] = append(s.fixtures.ParameterTemplates[orgName], template)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// templateFamily returns the template family of an engine version, the
+// engine followed by the major and minor version, e.g. mysql8.0
+func templateFamily(engine, version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	return engine + strings.Join(parts[:min(len(parts), 2)], ".")
+}
+
+// formatParameterValue formats a parameter value as a configuration file
+// holds it
+func formatParameterValue(v any) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "ON"
+		}
+		return "OFF"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// newParameterTemplate returns a parameter template fixture
+func newParameterTemplate(org, name, family string, partition kbcloud.ParamTplPartition, description string, files map[string]string) ParameterTemplate {
+	item := kbcloud.NewParamTplListItem(description, family, name, string(partition), "tpl-"+name)
+	item.SetOrgName(org)
+	item.SetCreatedAt(fixtureTime)
+	item.SetUpdatedAt(fixtureTime)
+	return ParameterTemplate{Item: *item, Files: files}
+}
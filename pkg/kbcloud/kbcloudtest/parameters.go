@@ -0,0 +1,222 @@
+package kbcloudtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+)
+
+// Parameter is an engine parameter of a cluster component
+type Parameter struct {
+	Component string
+	File      string
+	// Prop is the current value of the parameter and its constraints
+	Prop kbcloud.ParameterProp
+	// Default is the engine default, served by the parameter specs endpoint
+	Default any
+}
+
+// opsReconfigure applies parameters to a running cluster
+var opsReconfigure = opsKind{name: "reconfigure", from: "Running", transient: "Updating", completion: "Running"}
+
+// ParameterValue returns the current value of a cluster parameter
+func (s *Server) ParameterValue(orgName, clusterName, name string) (any, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.fixtures.Parameters[orgName+"/"+clusterName] {
+		if p.Prop.Name == name {
+			return p.Prop.Value, true
+		}
+	}
+	return nil, false
+}
+
+// clusterParameters returns the parameters of the cluster and component a
+// request targets, writing an error when the cluster does not exist;
+// s.mu must be held
+func (s *Server) clusterParameters(w http.ResponseWriter, r *http.Request) ([]Parameter, bool) {
+	orgName, clusterName := r.PathValue("orgName"), r.PathValue("clusterName")
+	if s.findCluster(orgName, clusterName) == nil {
+		writeNotFound(w, "cluster", clusterName)
+		return nil, false
+	}
+
+	component := r.URL.Query().Get("component")
+	var params []Parameter
+	for _, p := range s.fixtures.Parameters[orgName+"/"+clusterName] {
+		if component == "" || p.Component == component {
+			params = append(params, p)
+		}
+	}
+	return params, true
+}
+
+func (s *Server) listParameterProps(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	params, ok := s.clusterParameters(w, r)
+	if !ok {
+		return
+	}
+
+	list := kbcloud.ParameterList{Items: []kbcloud.ParameterItem{}}
+	files := map[string]int{}
+	for _, p := range params {
+		i, ok := files[p.File]
+		if !ok {
+			item := kbcloud.NewParameterItem()
+			item.SetFileName(p.File)
+			item.SetSpecName(p.Component + "-config")
+			list.Items = append(list.Items, *item)
+			i = len(list.Items) - 1
+			files[p.File] = i
+		}
+		list.Items[i].Props = append(list.Items[i].Props, p.Prop)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// listParameterSpecs serves parameter specs the way KB Cloud does, with
+// scalar defaults and enum values
+func (s *Server) listParameterSpecs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	params, ok := s.clusterParameters(w, r)
+	if !ok {
+		return
+	}
+
+	type spec struct {
+		Name        string  `json:"name"`
+		Description string  `json:"description"`
+		Type        string  `json:"type"`
+		Default     any     `json:"default"`
+		NeedRestart bool    `json:"needRestart"`
+		Immutable   bool    `json:"immutable"`
+		Maximum     float64 `json:"maximum"`
+		Minimum     float64 `json:"minimum"`
+		Enum        []any   `json:"enum"`
+	}
+	type item struct {
+		FileName string `json:"fileName"`
+		Specs    []spec `json:"specs"`
+	}
+
+	items := []item{}
+	files := map[string]int{}
+	for _, p := range params {
+		i, ok := files[p.File]
+		if !ok {
+			items = append(items, item{FileName: p.File})
+			i = len(items) - 1
+			files[p.File] = i
+		}
+		items[i].Specs = append(items[i].Specs, spec{
+			Name:        p.Prop.Name,
+			Description: p.Prop.GetDescription(),
+			Type:        p.Prop.Type,
+			Default:     p.Default,
+			NeedRestart: p.Prop.NeedRestart,
+			Immutable:   p.Prop.Immutable,
+			Maximum:     p.Prop.GetMaximum(),
+			Minimum:     p.Prop.GetMinimum(),
+			Enum:        append([]any{}, p.Prop.Enum...),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+// reconfigure applies parameters to a cluster component as an operation.
+// Like KB Cloud it rejects unknown and immutable parameters.
+func (s *Server) reconfigure(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orgName, clusterName := r.PathValue("orgName"), r.PathValue("clusterName")
+	var body kbcloud.ReconfigureCreate
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.Parameters) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid reconfigure request")
+		return
+	}
+
+	params := s.fixtures.Parameters[orgName+"/"+clusterName]
+	updates := map[int]any{}
+	for name, value := range body.Parameters {
+		i := findParameter(params, body.Component, body.GetConfigFileName(), name)
+		if i < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown parameter %s of component %s", name, body.Component))
+			return
+		}
+		if params[i].Prop.Immutable {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("parameter %s is immutable", name))
+			return
+		}
+		updates[i] = parseParameterValue(params[i].Prop.Type, value)
+	}
+
+	name, ok := s.beginOperation(w, opsReconfigure, orgName, clusterName)
+	if !ok {
+		return
+	}
+	for i, value := range updates {
+		params[i].Prop.Value = value
+	}
+	writeJSON(w, http.StatusOK, kbcloud.NewOpsRequestName(name))
+}
+
+// findParameter returns the index of a parameter in params, or -1
+func findParameter(params []Parameter, component, file, name string) int {
+	for i, p := range params {
+		if p.Component == component && (file == "" || p.File == file) && p.Prop.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseParameterValue converts a reconfigure value to the JSON type the
+// props endpoint reports for a parameter type
+func parseParameterValue(typ, value string) any {
+	switch typ {
+	case "integer", "number", "float":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// newParameter returns a parameter fixture
+func newParameter(component, file, name, typ string, value, def any, needRestart bool) Parameter {
+	prop := kbcloud.NewParameterProp(name, typ, needRestart, false)
+	prop.Value = value
+	return Parameter{Component: component, File: file, Prop: *prop, Default: def}
+}
+
+// withRange sets the allowed range of a numeric parameter fixture
+func (p Parameter) withRange(minimum, maximum float64) Parameter {
+	p.Prop.SetMinimum(minimum)
+	p.Prop.SetMaximum(maximum)
+	return p
+}
+
+// withEnum sets the allowed values of a parameter fixture
+func (p Parameter) withEnum(values ...any) Parameter {
+	p.Prop.Enum = values
+	return p
+}
+
+// immutable marks a parameter fixture as immutable
+func (p Parameter) immutable() Parameter {
+	p.Prop.Immutable = true
+	return p
+}
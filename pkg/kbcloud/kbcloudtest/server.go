@@ -0,0 +1,533 @@
+// Package kbcloudtest provides an in-process fake of the KB Cloud API for
+// tests. The fake serves organizations, environments, clusters, endpoints,
+// IP allowlists, backups, database accounts, logical databases, engine parameters,
+// parameter templates, the engine catalog, metrics, logs, events and pods from
+// fixtures, requires digest authentication, paginates like the real API,
+// returns KB Cloud error bodies and runs cluster operations asynchronously.
+package kbcloudtest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+	kbmcp "github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	mcplog "github.com/apecloud/kb-cloud-mcp-server/pkg/log"
+	"github.com/icholy/digest"
+)
+
+// Credentials accepted by the fake server unless overridden with WithCredentials
+const (
+	APIKey    = "test-api-key"
+	APISecret = "test-api-secret"
+)
+
+// realm is the digest authentication realm of the fake server
+const realm = "kb-cloud"
+
+// Request is an authenticated request received by the fake server
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+}
+
+// Server is a fake KB Cloud API server
+type Server struct {
+	*httptest.Server
+
+	apiKey    string
+	apiSecret string
+	nonce     string
+	opsSteps  int
+
+	mu       sync.Mutex
+	fixtures *Fixtures
+	errors   map[string]injectedError
+	ops      map[string]*operation
+	opsSeq   int
+	requests []Request
+}
+
+// injectedError is an error response forced on a method and path
+type injectedError struct {
+	status  int
+	message string
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithFixtures replaces the default fixtures
+func WithFixtures(f *Fixtures) Option {
+	return func(s *Server) {
+		s.fixtures = f.clone()
+	}
+}
+
+// WithCredentials changes the API key and secret the server accepts
+func WithCredentials(apiKey, apiSecret string) Option {
+	return func(s *Server) {
+		s.apiKey = apiKey
+		s.apiSecret = apiSecret
+	}
+}
+
+// WithOperationSteps sets how many status polls an operation stays running
+// for before it succeeds
+func WithOperationSteps(n int) Option {
+	return func(s *Server) {
+		s.opsSteps = n
+	}
+}
+
+// NewServer starts a fake KB Cloud API server that is closed when the test ends
+func NewServer(t testing.TB, opts ...Option) *Server {
+	t.Helper()
+
+	s := &Server{
+		apiKey:    APIKey,
+		apiSecret: APISecret,
+		nonce:     newNonce(),
+		opsSteps:  2,
+		fixtures:  DefaultFixtures(),
+		errors:    map[string]injectedError{},
+		ops:       map[string]*operation{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.Server = httptest.NewServer(s.authenticate(s.routes()))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// GetClientFn returns a GetClientFn whose clients talk to the fake server
+func (s *Server) GetClientFn() kbmcp.GetClientFn {
+	return kbmcp.NewStaticClientFn(s.apiKey, s.apiSecret, s.URL, mcplog.Nop())
+}
+
+// InjectError makes every request for method and path fail with status
+// until ClearErrors is called
+func (s *Server) InjectError(method, path string, status int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors[method+" "+path] = injectedError{status: status, message: message}
+}
+
+// ClearErrors removes all injected errors
+func (s *Server) ClearErrors() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors = map[string]injectedError{}
+}
+
+// Requests returns the authenticated requests received so far
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Cluster returns the current state of a cluster
+func (s *Server) Cluster(orgName, clusterName string) (kbcloud.Cluster, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c := s.findCluster(orgName, clusterName); c != nil {
+		return *c, true
+	}
+	return kbcloud.Cluster{}, false
+}
+
+// AddCluster adds a running cluster to the fake server
+func (s *Server) AddCluster(orgName, envName, clusterName, engine string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fixtures.Clusters = append(s.fixtures.Clusters, newCluster(orgName, envName, clusterName, engine, "", "Running"))
+}
+
+// authenticate enforces digest authentication, records requests and
+// applies injected errors
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			chal := &digest.Challenge{
+				Realm:     realm,
+				Nonce:     s.nonce,
+				Algorithm: "MD5",
+				QOP:       []string{"auth"},
+			}
+			w.Header().Set("WWW-Authenticate", chal.String())
+			writeError(w, http.StatusUnauthorized, "invalid API key or secret")
+			return
+		}
+
+		s.mu.Lock()
+		s.requests = append(s.requests, Request{
+			Method: r.Method,
+			Path:   r.URL.Path,
+			Query:  r.URL.Query(),
+		})
+		injected, ok := s.errors[r.Method+" "+r.URL.Path]
+		s.mu.Unlock()
+
+		if ok {
+			writeError(w, injected.status, injected.message)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorized verifies the digest credentials of r
+func (s *Server) authorized(r *http.Request) bool {
+	header := r.Header.Get("Authorization")
+	if !digest.IsDigest(header) {
+		return false
+	}
+	cred, err := digest.ParseCredentials(header)
+	if err != nil || cred.Username != s.apiKey || cred.Nonce != s.nonce || cred.URI != r.URL.RequestURI() {
+		return false
+	}
+	want, err := digest.Digest(&digest.Challenge{
+		Realm:     realm,
+		Nonce:     s.nonce,
+		Algorithm: cred.Algorithm,
+		QOP:       []string{"auth"},
+	}, digest.Options{
+		Method:   r.Method,
+		URI:      cred.URI,
+		Username: s.apiKey,
+		Password: s.apiSecret,
+		Cnonce:   cred.Cnonce,
+		Count:    cred.Nc,
+	})
+	return err == nil && want.Response == cred.Response
+}
+
+// routes registers the emulated KB Cloud endpoints
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/organizations", s.listOrgs)
+	mux.HandleFunc("GET /api/v1/organizations/{orgName}", s.readOrg)
+	mux.HandleFunc("GET /api/v1/organizations/{orgName}/environments", s.listEnvironments)
+	mux.HandleFunc("GET /api/v1/organizations/{orgName}/environments/{environmentName}", s.getEnvironment)
+	mux.HandleFunc("GET /api/v1/organizations/{orgName}/events", s.listEvents)
+	mux.HandleFunc("GET /api/v1/organizations/{orgName}/clusters", s.listClusters)
+	mux.HandleFunc("GET /api/v1/organizations/{orgName}/clusters/{clusterName}", s.getCluster)
+	mux.HandleFunc("POST /api/v1/organizations/{orgName}/clusters/{clusterName}/start", s.startOperation(opsStart))
+	mux.HandleFunc("POST /api/v1/organizations/{orgName}/clusters/{clusterName}/stop", s.startOperation(opsStop))
+	mux.HandleFunc("POST /api/v1/organizations/{orgName}/clusters/{clusterName}/restart", s.startOperation(opsRestart))
+	mux.HandleFunc("GET /api/v1/organizations/{orgName}/clusters/{clusterName}/opsrequests/{opsName}", s.getOperation)
+	mux.HandleFunc("GET /api/v1/organizations/{orgName}/clusters/{clusterName}/endpoints", s.listEndpoints)
+	mux.HandleFunc("POST /api/v1/organizations/{orgName}/clusters/{clusterName}/expose", s.expose)
+	mux.HandleFunc("GET /api/v1/organizations/{orgName}/clusters/{clusterName}/ipWhitelist", s.listIPWhitelists)
+	mux.HandleFunc("POST /api/v1/organizations/{orgName}/clusters/{clusterName}/ipWhitelist", s.createIPWhitelist)
+	mux.HandleFunc("PATCH /api/v1/organizations/{orgName}/clusters/{clusterName}/ipWhitelist/{ipWhitelistId}", s.updateIPWhitelist)
+	mux.HandleFunc("DELETE /api/v1/organizations/{orgName}/clusters/{clusterName}/ipWhitelist/{ipWhitelistId}", s.deleteIPWhitelist)
+	mux.HandleFunc("GET /api/v1/organizations/{orgName}/clusters/{clusterName}/metrics", s.queryMetrics)
+	mux.HandleFunc("GET /api/v1/organizations/{orgName}/clusters/{clusterName}/instances", s.listPods)
+	for _, logType := range []string{"error", "slow", "running", "audit"} {
+		mux.HandleFunc("GET /api/v1/organizations/{orgName}/clusters/{clusterName}/logs/"+logType, s.queryLogs(logType))
+	}
+	mux.HandleFunc("GET /api/v1/organizations/{orgName}/clusters/{clusterName}/parameters", s.listParameterProps)
+	mux.HandleFunc("GET /api/v1/organizations/{orgName}/clusters/{clusterName}/parameterSpecs", s.listParameterSpecs)
+	mux.HandleFunc("POST /api/v1/organizations/{orgName}/clusters/{clusterName}/reconfigure", s.reconfigure)
+	mux.HandleFunc("POST /api/v1/organizations/{orgName}/clusters/{clusterName}/paramTpls", s.createParameterTemplateFromCluster)
+	mux.HandleFunc("GET /api/v1/organizations/{orgName}/paramTpls", s.listParameterTemplates)
+	mux.HandleFunc("GET /api/v1/organizations/{orgName}/paramTpls/{paramTplName}", s.readParameterTemplate)
+	mux.HandleFunc("GET /api/v1/organizations/{orgName}/environments/{environmentName}/storageClasses", s.listStorageClasses)
+	mux.HandleFunc("GET /api/v1/environments/{environmentName}/engines", s.listEngines)
+	mux.HandleFunc("GET /api/v1/environments/{environmentName}/engines/{engineName}/serviceVersion", s.listServiceVersions)
+	mux.HandleFunc("GET /api/v1/classes", s.listClasses)
+	mux.HandleFunc("GET /api/v1/organizations/{orgName}/backups", s.listBackups)
+	mux.HandleFunc("GET /api/v1/organizations/{orgName}/backups/{backupId}", s.getBackup)
+	mux.HandleFunc("GET /api/v1/data/{engineName}/organizations/{orgName}/clusters/{clusterName}/accounts", s.listAccounts)
+	mux.HandleFunc("POST /api/v1/data/{engineName}/organizations/{orgName}/clusters/{clusterName}/accounts", s.createAccount)
+	mux.HandleFunc("PATCH /api/v1/data/{engineName}/organizations/{orgName}/clusters/{clusterName}/accounts/{accountName}", s.updateAccount)
+	mux.HandleFunc("PATCH /api/v1/data/{engineName}/organizations/{orgName}/clusters/{clusterName}/accounts/{accountName}/privileges", s.updateAccountPrivileges)
+	mux.HandleFunc("DELETE /api/v1/data/{engineName}/organizations/{orgName}/clusters/{clusterName}/accounts/{accountName}", s.deleteAccount)
+	mux.HandleFunc("GET /api/v1/data/{engineName}/organizations/{orgName}/clusters/{clusterName}/databases", s.listDatabases)
+	mux.HandleFunc("POST /api/v1/data/{engineName}/organizations/{orgName}/clusters/{clusterName}/databases", s.createDatabase)
+	mux.HandleFunc("DELETE /api/v1/data/{engineName}/organizations/{orgName}/clusters/{clusterName}/databases/{databaseName}", s.deleteDatabase)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no route for %s %s", r.Method, r.URL.Path))
+	})
+	return mux
+}
+
+func (s *Server) listOrgs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := make([]kbcloud.UserOrg, 0, len(s.fixtures.Organizations))
+	for _, org := range s.fixtures.Organizations {
+		item := kbcloud.NewUserOrg(org.GetId(), "admin", org.Name)
+		item.DisplayName = org.DisplayName
+		item.Description = org.Description
+		items = append(items, *item)
+	}
+
+	// Organizations are paginated with an offset token
+	start, err := queryInt(r, "pageToken", 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	size, err := queryInt(r, "pageSize", len(items))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	page, next := window(items, start, size)
+
+	list := kbcloud.NewUserOrgList(page)
+	list.PageResult = pageResult(len(items))
+	if next > 0 {
+		list.PageResult.SetNext(strconv.Itoa(next))
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) readOrg(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	org := s.findOrg(r.PathValue("orgName"))
+	if org == nil {
+		writeNotFound(w, "organization", r.PathValue("orgName"))
+		return
+	}
+	writeJSON(w, http.StatusOK, org)
+}
+
+func (s *Server) listEnvironments(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orgName := r.PathValue("orgName")
+	if s.findOrg(orgName) == nil {
+		writeNotFound(w, "organization", orgName)
+		return
+	}
+
+	items := []kbcloud.Environment{}
+	for _, env := range s.fixtures.Environments {
+		if env.OrgName == orgName {
+			items = append(items, env)
+		}
+	}
+	writeJSON(w, http.StatusOK, kbcloud.EnvironmentList{Items: items, PageResult: pageResult(len(items))})
+}
+
+func (s *Server) getEnvironment(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orgName, envName := r.PathValue("orgName"), r.PathValue("environmentName")
+	for _, env := range s.fixtures.Environments {
+		if env.OrgName == orgName && env.Name == envName {
+			writeJSON(w, http.StatusOK, env)
+			return
+		}
+	}
+	writeNotFound(w, "environment", envName)
+}
+
+func (s *Server) listClusters(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orgName := r.PathValue("orgName")
+	if s.findOrg(orgName) == nil {
+		writeNotFound(w, "organization", orgName)
+		return
+	}
+
+	envName := r.URL.Query().Get("environmentName")
+	tagKey, tagValue := r.URL.Query().Get("tagKey"), r.URL.Query().Get("tagValue")
+	items := []kbcloud.ClusterListItem{}
+	for _, c := range s.fixtures.Clusters {
+		if c.GetOrgName() != orgName || (envName != "" && c.EnvironmentName != envName) {
+			continue
+		}
+		if tagKey != "" {
+			value, ok := s.fixtures.Tags[orgName+"/"+c.Name][tagKey]
+			if !ok || (tagValue != "" && value != tagValue) {
+				continue
+			}
+		}
+		items = append(items, clusterListItem(c))
+	}
+	writeJSON(w, http.StatusOK, kbcloud.ClusterList{Items: items, PageResult: pageResult(len(items))})
+}
+
+func (s *Server) getCluster(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cluster := s.findCluster(r.PathValue("orgName"), r.PathValue("clusterName"))
+	if cluster == nil {
+		writeNotFound(w, "cluster", r.PathValue("clusterName"))
+		return
+	}
+	writeJSON(w, http.StatusOK, cluster)
+}
+
+func (s *Server) listBackups(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orgName := r.PathValue("orgName")
+	if s.findOrg(orgName) == nil {
+		writeNotFound(w, "organization", orgName)
+		return
+	}
+
+	clusterName := r.URL.Query().Get("clusterName")
+	items := []kbcloud.Backup{}
+	for _, b := range s.fixtures.Backups {
+		if b.OrgName != orgName || (clusterName != "" && b.SourceCluster != clusterName) {
+			continue
+		}
+		items = append(items, b)
+	}
+
+	// Backups are paginated with 1-based page numbers
+	pageNum, err := queryInt(r, "page", 1)
+	if err != nil || pageNum < 1 {
+		writeError(w, http.StatusBadRequest, "page must be a positive integer")
+		return
+	}
+	size, err := queryInt(r, "pageSize", len(items))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	page, _ := window(items, (pageNum-1)*size, size)
+	writeJSON(w, http.StatusOK, kbcloud.BackupList{Items: page, PageResult: pageResult(len(items))})
+}
+
+func (s *Server) getBackup(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orgName, backupID := r.PathValue("orgName"), r.PathValue("backupId")
+	for _, b := range s.fixtures.Backups {
+		if b.OrgName == orgName && b.GetId() == backupID {
+			writeJSON(w, http.StatusOK, b)
+			return
+		}
+	}
+	writeNotFound(w, "backup", backupID)
+}
+
+// findOrg returns the named organization; s.mu must be held
+func (s *Server) findOrg(name string) *kbcloud.Org {
+	for i := range s.fixtures.Organizations {
+		if s.fixtures.Organizations[i].Name == name {
+			return &s.fixtures.Organizations[i]
+		}
+	}
+	return nil
+}
+
+// findCluster returns the named cluster; s.mu must be held
+func (s *Server) findCluster(orgName, name string) *kbcloud.Cluster {
+	for i := range s.fixtures.Clusters {
+		c := &s.fixtures.Clusters[i]
+		if c.GetOrgName() == orgName && c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// clusterListItem converts a cluster to its list representation
+func clusterListItem(c kbcloud.Cluster) kbcloud.ClusterListItem {
+	item := kbcloud.NewClusterListItem(
+		c.GetCloudProvider(),
+		c.GetCreatedAt(),
+		c.Engine,
+		c.EnvironmentName,
+		c.GetId(),
+		c.Name,
+		c.GetStatus(),
+		string(c.GetTerminationPolicy()),
+		c.GetUpdatedAt(),
+		c.GetVersion(),
+	)
+	item.Mode = c.Mode
+	item.OrgName = c.OrgName
+	item.DisplayName = c.DisplayName
+	return *item
+}
+
+// window returns items[start:start+size] clamped to the slice bounds, and
+// the offset of the next page or 0 when there is none
+func window[T any](items []T, start, size int) ([]T, int) {
+	if start < 0 || start >= len(items) || size <= 0 {
+		return []T{}, 0
+	}
+	end := start + size
+	if end >= len(items) {
+		return items[start:], 0
+	}
+	return items[start:end], end
+}
+
+// queryInt parses an integer query parameter
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", name, v)
+	}
+	return n, nil
+}
+
+// pageResult returns a page result reporting the total number of items
+func pageResult(total int) *kbcloud.PageResult {
+	result := kbcloud.NewPageResult()
+	result.SetTotalSize(int64(total))
+	return result
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a KB Cloud API error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	body := kbcloud.NewAPIErrorResponse(int32(status))
+	body.SetReason(http.StatusText(status))
+	body.SetMessage(message)
+	writeJSON(w, status, body)
+}
+
+// writeNotFound writes a 404 for a missing resource
+func writeNotFound(w http.ResponseWriter, kind, name string) {
+	writeError(w, http.StatusNotFound, fmt.Sprintf("%s %s not found", kind, name))
+}
+
+// newNonce returns a random digest nonce
+func newNonce() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
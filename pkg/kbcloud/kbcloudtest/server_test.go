@@ -0,0 +1,174 @@
+package kbcloudtest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/apecloud/kb-cloud-client-go/api/common"
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+	kbmcp "github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	mcplog "github.com/apecloud/kb-cloud-mcp-server/pkg/log"
+	"github.com/icholy/digest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newClient returns a KB Cloud client for s
+func newClient(t *testing.T, s *Server) *kbmcp.Client {
+	t.Helper()
+	client, err := s.GetClientFn()(context.Background())
+	require.NoError(t, err)
+	return client
+}
+
+func TestAuthentication(t *testing.T) {
+	s := NewServer(t)
+
+	t.Run("Missing credentials are challenged", func(t *testing.T) {
+		resp, err := http.Get(s.URL + "/api/v1/organizations")
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		chal, err := digest.FindChallenge(resp.Header)
+		require.NoError(t, err)
+		assert.Equal(t, realm, chal.Realm)
+	})
+
+	t.Run("Wrong secret is rejected", func(t *testing.T) {
+		client, err := kbmcp.NewStaticClientFn(APIKey, "wrong", s.URL, mcplog.Nop())(context.Background())
+		require.NoError(t, err)
+
+		_, resp, err := client.Organization.ListOrg(client.Context)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Valid credentials are accepted", func(t *testing.T) {
+		client := newClient(t, s)
+		orgs, _, err := client.Organization.ListOrg(client.Context)
+		require.NoError(t, err)
+		assert.Len(t, orgs.Items, 2)
+	})
+}
+
+func TestPagination(t *testing.T) {
+	s := NewServer(t)
+	client := newClient(t, s)
+
+	orgs, _, err := client.Organization.ListOrg(client.Context, *kbcloud.NewListOrgOptionalParameters().WithPageSize("1"))
+	require.NoError(t, err)
+	require.Len(t, orgs.Items, 1)
+	assert.Equal(t, "acme", orgs.Items[0].Name)
+	assert.Equal(t, int64(2), orgs.PageResult.GetTotalSize())
+
+	next := orgs.PageResult.GetNext()
+	require.NotEmpty(t, next)
+	orgs, _, err = client.Organization.ListOrg(client.Context, *kbcloud.NewListOrgOptionalParameters().WithPageSize("1").WithPageToken(next))
+	require.NoError(t, err)
+	require.Len(t, orgs.Items, 1)
+	assert.Equal(t, "globex", orgs.Items[0].Name)
+	assert.Empty(t, orgs.PageResult.GetNext())
+}
+
+func TestClusterTags(t *testing.T) {
+	s := NewServer(t)
+	client := newClient(t, s)
+
+	tests := []struct {
+		name string
+		opts *kbcloud.ListClusterOptionalParameters
+		want []string
+	}{
+		{name: "tag key", opts: kbcloud.NewListClusterOptionalParameters().WithTagKey("team"), want: []string{"orders-db", "cache", "analytics"}},
+		{name: "tag key and value", opts: kbcloud.NewListClusterOptionalParameters().WithTagKey("team").WithTagValue("commerce"), want: []string{"orders-db", "cache"}},
+		{name: "unknown tag", opts: kbcloud.NewListClusterOptionalParameters().WithTagKey("owner"), want: nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			clusters, _, err := client.Cluster.ListCluster(client.Context, "acme", *tc.opts)
+			require.NoError(t, err)
+
+			var names []string
+			for _, c := range clusters.Items {
+				names = append(names, c.Name)
+			}
+			assert.Equal(t, tc.want, names)
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	s := NewServer(t)
+	client := newClient(t, s)
+
+	t.Run("Missing resources return KB Cloud error bodies", func(t *testing.T) {
+		_, resp, err := client.Cluster.GetCluster(client.Context, "acme", "ghost")
+		require.Error(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		var apiErr common.GenericOpenAPIError
+		if assert.ErrorAs(t, err, &apiErr) {
+			body, ok := apiErr.Model().(kbcloud.APIErrorResponse)
+			require.True(t, ok)
+			assert.Equal(t, "cluster ghost not found", body.GetMessage())
+		}
+	})
+
+	t.Run("Injected errors apply until cleared", func(t *testing.T) {
+		s.InjectError(http.MethodGet, "/api/v1/organizations/acme", http.StatusConflict, "busy")
+
+		_, resp, err := client.Organization.ReadOrg(client.Context, "acme")
+		require.Error(t, err)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		s.ClearErrors()
+		_, _, err = client.Organization.ReadOrg(client.Context, "acme")
+		assert.NoError(t, err)
+	})
+}
+
+func TestOperations(t *testing.T) {
+	s := NewServer(t, WithOperationSteps(2))
+	client := newClient(t, s)
+	ops := kbcloud.NewOpsrequestApi(client.APIClient)
+
+	name, _, err := ops.StopCluster(client.Context, "acme", "orders-db")
+	require.NoError(t, err)
+
+	cluster, ok := s.Cluster("acme", "orders-db")
+	require.True(t, ok)
+	assert.Equal(t, "Stopping", cluster.GetStatus())
+
+	// A second stop conflicts with the one in flight
+	_, resp, err := ops.StopCluster(client.Context, "acme", "orders-db")
+	require.Error(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	status, _, err := ops.GetOpsRequestStatus(client.Context, "acme", "orders-db", name.OpsRequestName)
+	require.NoError(t, err)
+	assert.Equal(t, OpsPhaseRunning, status.Status)
+
+	status, _, err = ops.GetOpsRequestStatus(client.Context, "acme", "orders-db", name.OpsRequestName)
+	require.NoError(t, err)
+	assert.Equal(t, OpsPhaseSucceed, status.Status)
+
+	cluster, _ = s.Cluster("acme", "orders-db")
+	assert.Equal(t, "Stopped", cluster.GetStatus())
+}
+
+func TestRequests(t *testing.T) {
+	s := NewServer(t, WithFixtures(&Fixtures{}))
+	client := newClient(t, s)
+
+	_, _, err := client.Cluster.ListCluster(client.Context, "acme", *kbcloud.NewListClusterOptionalParameters().WithEnvironmentName("prod"))
+	require.Error(t, err)
+
+	requests := s.Requests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, http.MethodGet, requests[0].Method)
+	assert.Equal(t, "/api/v1/organizations/acme/clusters", requests[0].Path)
+	assert.Equal(t, "prod", requests[0].Query.Get("environmentName"))
+}
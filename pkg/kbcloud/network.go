@@ -0,0 +1,591 @@
+package kbcloud
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultAllowlistGroup is the allowlist group entries are added to when the
+// call names none
+const defaultAllowlistGroup = "default"
+
+// publicEndpointChange is the result of enable_public_endpoint and
+// disable_public_endpoint
+type publicEndpointChange struct {
+	InstanceRef string `json:"instance_ref"`
+	Component   string `json:"component"`
+	Enabled     bool   `json:"enabled"`
+	Changed     bool   `json:"changed"`
+	OpsRequest  string `json:"ops_request,omitempty"`
+	Message     string `json:"message"`
+}
+
+// allowlistGroup is a named group of allowlist entries
+type allowlistGroup struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Addresses   []string `json:"addresses"`
+}
+
+// allowlist is the result of list_allowlist
+type allowlist struct {
+	InstanceRef string           `json:"instance_ref"`
+	Entries     []string         `json:"entries"`
+	Groups      []allowlistGroup `json:"groups"`
+}
+
+// allowlistChange is the result of the tools that change an allowlist
+type allowlistChange struct {
+	InstanceRef string   `json:"instance_ref"`
+	Group       string   `json:"group,omitempty"`
+	Added       []string `json:"added,omitempty"`
+	Removed     []string `json:"removed,omitempty"`
+	Skipped     []string `json:"skipped,omitempty"`
+	Overlaps    []string `json:"overlaps,omitempty"`
+	Entries     []string `json:"entries"`
+}
+
+// ipWhitelistBody is the body of IP whitelist create and update requests
+type ipWhitelistBody struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Addresses   []string `json:"addresses"`
+}
+
+// allowlistEntry is a parsed entry of an allowlist group
+type allowlistEntry struct {
+	prefix netip.Prefix
+	group  string
+}
+
+// EnablePublicEndpoint creates a tool to expose an instance to the internet
+func EnablePublicEndpoint(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("enable_public_endpoint",
+			mcp.WithDescription("Expose an instance component on a public endpoint reachable from the internet. "+
+				"Restrict who can connect with add_allowlist_entries"),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			WithInstanceRef(),
+			mcp.WithString("component",
+				mcp.Description("Component to expose; defaults to the main one"),
+			),
+			WithOutputSchema[publicEndpointChange](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return setPublicEndpoint(ctx, getClient, request, true)
+		}
+}
+
+// DisablePublicEndpoint creates a tool to remove the public endpoint of an
+// instance
+func DisablePublicEndpoint(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("disable_public_endpoint",
+			mcp.WithDescription("Remove the public endpoint of an instance component. Clients outside the environment "+
+				"lose access; re-enabling it may assign a new address"),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithIdempotentHintAnnotation(true),
+			WithInstanceRef(),
+			mcp.WithString("component",
+				mcp.Description("Component whose public endpoint to remove; defaults to the main one"),
+			),
+			WithInstanceConfirmation("the public endpoint"),
+			WithOutputSchema[publicEndpointChange](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return setPublicEndpoint(ctx, getClient, request, false)
+		}
+}
+
+// setPublicEndpoint enables or disables the public endpoint of an instance
+// component, doing nothing when it already is in the requested state
+func setPublicEndpoint(ctx context.Context, getClient GetClientFn, request mcp.CallToolRequest, enable bool) (*mcp.CallToolResult, error) {
+	// Get required parameters
+	ref, err := InstanceRefParams(ctx, request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// Get optional parameters
+	componentName, err := OptionalParam[string](request, "component")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if !enable {
+		if err := ConfirmInstanceParam(request, ref, "the public endpoint"); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+
+	// Get KB Cloud client
+	client, err := getClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+	}
+
+	ref, instance, err := getInstance(client, ref)
+	if err != nil {
+		return lookupErrorResult(err)
+	}
+	component, err := instanceComponent(ref, instance, componentName)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	endpoints, resp, err := client.Cluster.ListEndpoints(client.Context, ref.Org, ref.Instance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list endpoints: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	state := "disabled"
+	if enable {
+		state = "enabled"
+	}
+	result := publicEndpointChange{InstanceRef: ref.String(), Component: component, Enabled: enable}
+	public := slices.ContainsFunc(endpoints.Items, func(e kbcloud.Endpoint) bool {
+		return e.Component == component && e.NetworkType == kbcloud.EndpointNetworkTypeInternet
+	})
+	if public == enable {
+		result.Message = fmt.Sprintf("the public endpoint of %s (%s) is already %s", ref, component, state)
+		return renderResult(FormatJSON, result, nil)
+	}
+
+	// Call KB Cloud API
+	body := kbcloud.NewOpsExpose(component, enable, kbcloud.OpsExposeTypeInternet)
+	ops, resp, err := client.Opsrequest.ExposeCluster(client.Context, ref.Org, ref.Instance, *body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to change public endpoint: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	// Return result
+	result.Changed, result.OpsRequest = true, ops.OpsRequestName
+	if enable {
+		result.Message = fmt.Sprintf("the public endpoint of %s (%s) is being enabled; get_connection_info returns its address "+
+			"once the operation completes, and add_allowlist_entries restricts who can connect", ref, component)
+	} else {
+		result.Message = fmt.Sprintf("the public endpoint of %s (%s) is being disabled; clients outside environment %s lose access", ref, component, ref.Env)
+	}
+	return renderResult(FormatJSON, result, nil)
+}
+
+// ListAllowlist creates a tool to list the IP allowlist of an instance
+func ListAllowlist(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("list_allowlist",
+			mcp.WithDescription("List the IP addresses and CIDR blocks allowed to connect to an instance, by group"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			WithInstanceRef(),
+			WithResponseShaping(),
+			WithOutputSchema[allowlist](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			ref, err := InstanceRefParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get response shaping parameters
+			shaping, err := ResponseShapingParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			ref, _, err = getInstance(client, ref)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+
+			// Call KB Cloud API
+			whitelists, err := listAllowlist(client, ref)
+			if err != nil {
+				return nil, err
+			}
+
+			result := allowlist{InstanceRef: ref.String(), Entries: allowlistAddresses(whitelists), Groups: []allowlistGroup{}}
+			for _, w := range whitelists {
+				result.Groups = append(result.Groups, allowlistGroup{
+					ID:          w.Id,
+					Name:        w.Name,
+					Description: w.GetDescription(),
+					Addresses:   append([]string{}, w.Addresses...),
+				})
+			}
+
+			// Return result
+			return shaping.result(resourceAllowlist, result)
+		}
+}
+
+// AddAllowlistEntries creates a tool to allow addresses to connect to an
+// instance. Entries allowing every address are refused unless allowOpen.
+func AddAllowlistEntries(getClient GetClientFn, allowOpen bool) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	description := "Allow IP addresses or CIDR blocks to connect to an instance. Entries already covered by the allowlist are skipped " +
+		"and entries covering existing ones are reported"
+	if !allowOpen {
+		description += ". Entries allowing every address, 0.0.0.0/0 and ::/0, are refused by this server"
+	}
+	return mcp.NewTool("add_allowlist_entries",
+			mcp.WithDescription(description),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			WithInstanceRef(),
+			mcp.WithArray("entries",
+				mcp.Required(),
+				mcp.Description("IP addresses or CIDR blocks, e.g. 203.0.113.7 or 203.0.113.0/24"),
+				mcp.WithStringItems(),
+			),
+			mcp.WithString("group",
+				mcp.Description("Allowlist group to add the entries to; created when missing (default \""+defaultAllowlistGroup+"\")"),
+			),
+			mcp.WithString("description",
+				mcp.Description("Description of a new group"),
+			),
+			WithOutputSchema[allowlistChange](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			ref, err := InstanceRefParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			entries, err := allowlistEntriesParam(request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get optional parameters
+			groupName, err := OptionalParam[string](request, "group")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if groupName == "" {
+				groupName = defaultAllowlistGroup
+			}
+			description, err := OptionalParam[string](request, "description")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			prefixes, problems := parseAllowlistEntries(entries)
+			for _, p := range prefixes {
+				if allowOpen {
+					break
+				}
+				if p.Bits() == 0 {
+					problems = append(problems, fmt.Sprintf("%s allows every address and is refused unless the server is started with --allow-open-allowlist", p))
+				}
+			}
+			if len(problems) > 0 {
+				return mcp.NewToolResultError("no entries were added:\n- " + strings.Join(problems, "\n- ")), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			ref, _, err = getInstance(client, ref)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+			whitelists, err := listAllowlist(client, ref)
+			if err != nil {
+				return nil, err
+			}
+
+			// Broader entries first, so entries covered by another in the
+			// same call are skipped
+			result := allowlistChange{InstanceRef: ref.String(), Group: groupName}
+			existing := parsedAllowlist(whitelists)
+			var added []netip.Prefix
+			slices.SortStableFunc(prefixes, func(a, b netip.Prefix) int { return a.Bits() - b.Bits() })
+			for _, p := range prefixes {
+				if i := slices.IndexFunc(existing, func(e allowlistEntry) bool { return prefixCovers(e.prefix, p) }); i >= 0 {
+					result.Skipped = append(result.Skipped, fmt.Sprintf("%s is already allowed by %s (group %s)", p, existing[i].prefix, existing[i].group))
+					continue
+				}
+				if i := slices.IndexFunc(added, func(a netip.Prefix) bool { return prefixCovers(a, p) }); i >= 0 {
+					result.Skipped = append(result.Skipped, fmt.Sprintf("%s is covered by %s in this call", p, added[i]))
+					continue
+				}
+				for _, e := range existing {
+					if prefixCovers(p, e.prefix) {
+						result.Overlaps = append(result.Overlaps, fmt.Sprintf("%s covers the existing entry %s (group %s)", p, e.prefix, e.group))
+					}
+				}
+				added = append(added, p)
+				result.Added = append(result.Added, p.String())
+			}
+			if len(added) == 0 {
+				result.Entries = allowlistAddresses(whitelists)
+				return renderResult(FormatJSON, result, nil)
+			}
+
+			// Call KB Cloud API
+			i := slices.IndexFunc(whitelists, func(w kbcloud.IpWhitelist) bool { return w.Name == groupName })
+			if i >= 0 {
+				w := &whitelists[i]
+				w.Addresses = append(w.Addresses, result.Added...)
+				body := ipWhitelistBody{Name: w.Name, Description: w.GetDescription(), Addresses: w.Addresses}
+				_, resp, err := client.IpWhitelist.UpdateIPWhitelist(client.Context, ref.Org, ref.Instance, w.Id, body)
+				if err != nil {
+					return nil, fmt.Errorf("failed to update allowlist: %w", err)
+				}
+				_ = resp.Body.Close()
+			} else {
+				body := ipWhitelistBody{Name: groupName, Description: description, Addresses: result.Added}
+				created, resp, err := client.IpWhitelist.CreateIPWhitelist(client.Context, ref.Org, ref.Instance, body)
+				if err != nil {
+					return nil, fmt.Errorf("failed to update allowlist: %w", err)
+				}
+				_ = resp.Body.Close()
+				whitelists = append(whitelists, created)
+			}
+
+			// Return result
+			result.Entries = allowlistAddresses(whitelists)
+			return renderResult(FormatJSON, result, nil)
+		}
+}
+
+// RemoveAllowlistEntries creates a tool to stop addresses from connecting to
+// an instance
+func RemoveAllowlistEntries(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("remove_allowlist_entries",
+			mcp.WithDescription("Remove IP addresses or CIDR blocks from the allowlist of an instance, from every group listing them. "+
+				"Clients connecting from them lose access; groups left empty are deleted"),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithIdempotentHintAnnotation(false),
+			WithInstanceRef(),
+			mcp.WithArray("entries",
+				mcp.Required(),
+				mcp.Description("Entries to remove exactly as listed by list_allowlist, e.g. 203.0.113.0/24"),
+				mcp.WithStringItems(),
+			),
+			WithInstanceConfirmation("allowlist entries"),
+			WithOutputSchema[allowlistChange](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			ref, err := InstanceRefParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			entries, err := allowlistEntriesParam(request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			prefixes, problems := parseAllowlistEntries(entries)
+			if len(problems) > 0 {
+				return mcp.NewToolResultError("no entries were removed:\n- " + strings.Join(problems, "\n- ")), nil
+			}
+			if err := ConfirmInstanceParam(request, ref, "allowlist entries"); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			ref, _, err = getInstance(client, ref)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+			whitelists, err := listAllowlist(client, ref)
+			if err != nil {
+				return nil, err
+			}
+
+			// Every entry must be listed as is; an entry covered by a broader
+			// one would stay allowed after its removal
+			existing := parsedAllowlist(whitelists)
+			for _, p := range prefixes {
+				if slices.ContainsFunc(existing, func(e allowlistEntry) bool { return e.prefix == p }) {
+					continue
+				}
+				if i := slices.IndexFunc(existing, func(e allowlistEntry) bool { return prefixCovers(e.prefix, p) }); i >= 0 {
+					problems = append(problems, fmt.Sprintf("%s is not an entry itself but is allowed by %s (group %s); remove that entry instead", p, existing[i].prefix, existing[i].group))
+					continue
+				}
+				problems = append(problems, fmt.Sprintf("%s is not in the allowlist of %s", p, ref))
+			}
+			if len(problems) > 0 {
+				message := "no entries were removed:\n- " + strings.Join(problems, "\n- ")
+				if entries := allowlistAddresses(whitelists); len(entries) > 0 {
+					message += "\ncurrent entries: " + strings.Join(entries, ", ")
+				}
+				return mcp.NewToolResultError(message), nil
+			}
+
+			// Call KB Cloud API
+			result := allowlistChange{InstanceRef: ref.String()}
+			var remaining []kbcloud.IpWhitelist
+			for _, w := range whitelists {
+				var kept, removed []string
+				for _, address := range w.Addresses {
+					p, err := parseAllowlistEntry(address)
+					if err == nil && slices.Contains(prefixes, p) {
+						removed = append(removed, address)
+						continue
+					}
+					kept = append(kept, address)
+				}
+				if len(removed) == 0 {
+					remaining = append(remaining, w)
+					continue
+				}
+
+				if len(kept) == 0 {
+					resp, err := client.IpWhitelist.DeleteIPWhiteList(client.Context, ref.Org, ref.Instance, w.Id)
+					if err != nil {
+						return nil, fmt.Errorf("failed to update allowlist: %w%s", err, allowlistProgress(result))
+					}
+					_ = resp.Body.Close()
+				} else {
+					body := ipWhitelistBody{Name: w.Name, Description: w.GetDescription(), Addresses: kept}
+					_, resp, err := client.IpWhitelist.UpdateIPWhitelist(client.Context, ref.Org, ref.Instance, w.Id, body)
+					if err != nil {
+						return nil, fmt.Errorf("failed to update allowlist: %w%s", err, allowlistProgress(result))
+					}
+					_ = resp.Body.Close()
+					w.Addresses = kept
+					remaining = append(remaining, w)
+				}
+				result.Removed = append(result.Removed, removed...)
+			}
+
+			// Return result
+			result.Entries = allowlistAddresses(remaining)
+			return renderResult(FormatJSON, result, nil)
+		}
+}
+
+// allowlistProgress lists the entries already removed from other groups
+// when removing entries fails
+func allowlistProgress(result allowlistChange) string {
+	if len(result.Removed) == 0 {
+		return ""
+	}
+	return " (already removed: " + strings.Join(result.Removed, ", ") + ")"
+}
+
+// listAllowlist returns the allowlist groups of an instance
+func listAllowlist(client *Client, ref InstanceRef) ([]kbcloud.IpWhitelist, error) {
+	whitelists, resp, err := client.IpWhitelist.ListIPWhitelist(client.Context, ref.Org, ref.Instance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list allowlist: %w", err)
+	}
+	_ = resp.Body.Close()
+	return whitelists.Items, nil
+}
+
+// allowlistEntriesParam returns the entries parameter, which must not be empty
+func allowlistEntriesParam(r mcp.CallToolRequest) ([]string, error) {
+	entries, err := OptionalStringArrayParam(r, "entries")
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("missing required parameter: entries")
+	}
+	return entries, nil
+}
+
+// parseAllowlistEntries parses entries, dropping duplicates, and describes
+// the invalid ones
+func parseAllowlistEntries(entries []string) (prefixes []netip.Prefix, problems []string) {
+	for _, entry := range entries {
+		p, err := parseAllowlistEntry(entry)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if !slices.Contains(prefixes, p) {
+			prefixes = append(prefixes, p)
+		}
+	}
+	return prefixes, problems
+}
+
+// parseAllowlistEntry parses an IP address or CIDR block. Addresses become
+// single-address blocks and IPv4-mapped IPv6 blocks become IPv4 blocks;
+// blocks with host bits set are refused, as they usually mean a mistyped
+// prefix length.
+func parseAllowlistEntry(entry string) (netip.Prefix, error) {
+	entry = strings.TrimSpace(entry)
+	if !strings.Contains(entry, "/") {
+		addr, err := netip.ParseAddr(entry)
+		if err != nil || addr.Zone() != "" {
+			return netip.Prefix{}, fmt.Errorf("invalid entry %q: expected an IP address or CIDR block such as 203.0.113.0/24", entry)
+		}
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+
+	p, err := netip.ParsePrefix(entry)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid entry %q: expected an IP address or CIDR block such as 203.0.113.0/24", entry)
+	}
+	if masked := p.Masked(); masked != p {
+		return netip.Prefix{}, fmt.Errorf("invalid entry %q: host bits are set; did you mean %s?", entry, masked)
+	}
+	if p.Addr().Is4In6() && p.Bits() >= 96 {
+		p = netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96)
+	}
+	return p, nil
+}
+
+// parsedAllowlist returns the entries of allowlist groups that parse as
+// addresses or CIDR blocks
+func parsedAllowlist(whitelists []kbcloud.IpWhitelist) []allowlistEntry {
+	var entries []allowlistEntry
+	for _, w := range whitelists {
+		for _, address := range w.Addresses {
+			if p, err := parseAllowlistEntry(address); err == nil {
+				entries = append(entries, allowlistEntry{prefix: p, group: w.Name})
+			}
+		}
+	}
+	return entries
+}
+
+// prefixCovers reports whether every address of b is in a
+func prefixCovers(a, b netip.Prefix) bool {
+	return a.Addr().Is4() == b.Addr().Is4() && a.Bits() <= b.Bits() && a.Contains(b.Addr())
+}
+
+// allowlistAddresses returns the distinct entries of allowlist groups, sorted
+func allowlistAddresses(whitelists []kbcloud.IpWhitelist) []string {
+	addresses := []string{}
+	for _, w := range whitelists {
+		for _, address := range w.Addresses {
+			if !slices.Contains(addresses, address) {
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	slices.Sort(addresses)
+	return addresses
+}
@@ -0,0 +1,341 @@
+package kbcloud_test
+
+import (
+	"testing"
+
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud/kbcloudtest"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// allowlistChange is the JSON result of the tools that change an allowlist
+type allowlistChange struct {
+	Group    string   `json:"group"`
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Skipped  []string `json:"skipped"`
+	Overlaps []string `json:"overlaps"`
+	Entries  []string `json:"entries"`
+}
+
+// addAllowlistEntries returns an add_allowlist_entries factory
+func addAllowlistEntries(allowOpen bool) toolFactory {
+	return func(getClient kbcloud.GetClientFn) (mcp.Tool, server.ToolHandlerFunc) {
+		return kbcloud.AddAllowlistEntries(getClient, allowOpen)
+	}
+}
+
+func TestEnablePublicEndpoint(t *testing.T) {
+	runToolTests(t, kbcloud.EnablePublicEndpoint, []toolTest{
+		{
+			name: "private instance",
+			args: map[string]any{"instance_ref": "acme/prod/cache"},
+			check: func(t *testing.T, text string) {
+				change := decode[struct {
+					Component  string `json:"component"`
+					Changed    bool   `json:"changed"`
+					OpsRequest string `json:"ops_request"`
+				}](t, text)
+				assert.Equal(t, "redis", change.Component)
+				assert.True(t, change.Changed)
+				assert.NotEmpty(t, change.OpsRequest)
+			},
+		},
+		{
+			name: "already public",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"instance_ref":"acme/prod/orders-db","component":"mysql","enabled":true,"changed":false,
+					"message":"the public endpoint of acme/prod/orders-db (mysql) is already enabled"}`, text)
+			},
+		},
+		{
+			name:    "stopped instance",
+			args:    map[string]any{"instance_ref": "acme/staging/analytics"},
+			wantErr: "failed to change public endpoint",
+		},
+		{
+			name:        "unknown component",
+			args:        map[string]any{"instance_ref": "acme/prod/cache", "component": "proxy"},
+			wantToolErr: `component "proxy" not found on acme/prod/cache`,
+		},
+	})
+
+	t.Run("adds public endpoints", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		_, enable := kbcloud.EnablePublicEndpoint(s.GetClientFn())
+		_, info := kbcloud.GetConnectionInfo(s.GetClientFn(), false)
+
+		result, err := callTool(enable, map[string]any{"instance_ref": "acme/prod/cache"})
+		require.NoError(t, err)
+		require.False(t, result.IsError, resultText(t, result))
+		cluster, _ := s.Cluster("acme", "cache")
+		assert.Equal(t, "Updating", cluster.GetStatus())
+
+		result, err = callTool(info, map[string]any{"instance_ref": "acme/prod/cache", "network": "external"})
+		require.NoError(t, err)
+		assert.Contains(t, resultText(t, result), "cache-redis.acme.elb.amazonaws.com")
+	})
+}
+
+func TestDisablePublicEndpoint(t *testing.T) {
+	runToolTests(t, kbcloud.DisablePublicEndpoint, []toolTest{
+		{
+			name: "public instance",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "confirm": "orders-db"},
+			check: func(t *testing.T, text string) {
+				assert.Contains(t, text, `"changed":true`)
+				assert.Contains(t, text, "clients outside environment prod lose access")
+			},
+		},
+		{
+			name: "already private",
+			args: map[string]any{"instance_ref": "acme/prod/cache", "confirm": "cache"},
+			check: func(t *testing.T, text string) {
+				assert.Contains(t, text, `"changed":false`)
+				assert.Contains(t, text, "is already disabled")
+			},
+		},
+		{
+			name:        "unconfirmed",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db"},
+			wantToolErr: `removing the public endpoint of acme/prod/orders-db can cut off its clients; call again with confirm set to "orders-db" to proceed`,
+		},
+		{
+			name:        "confirmed with another name",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "confirm": "cache"},
+			wantToolErr: `confirm "cache" does not match instance "orders-db"; nothing was removed`,
+		},
+	})
+
+	t.Run("already private calls send no operation", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		_, handler := kbcloud.DisablePublicEndpoint(s.GetClientFn())
+
+		result, err := callTool(handler, map[string]any{"instance_ref": "acme/prod/cache", "confirm": "cache"})
+		require.NoError(t, err)
+		require.False(t, result.IsError, resultText(t, result))
+		for _, r := range s.Requests() {
+			assert.Equal(t, "GET", r.Method, r.Path)
+		}
+	})
+
+	t.Run("unconfirmed calls never reach KB Cloud", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		_, handler := kbcloud.DisablePublicEndpoint(s.GetClientFn())
+
+		result, err := callTool(handler, map[string]any{"instance_ref": "acme/prod/orders-db"})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Empty(t, s.Requests())
+	})
+}
+
+func TestListAllowlist(t *testing.T) {
+	runToolTests(t, kbcloud.ListAllowlist, []toolTest{
+		{
+			name: "groups",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"instance_ref":"acme/prod/orders-db","entries":["198.51.100.7/32","203.0.113.0/24"],"groups":[
+					{"id":"ipw-office","name":"office","addresses":["203.0.113.0/24"]},
+					{"id":"ipw-ci","name":"ci","addresses":["198.51.100.7/32"]}
+				]}`, text)
+			},
+		},
+		{
+			name: "empty",
+			args: map[string]any{"instance_ref": "acme/prod/cache"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"instance_ref":"acme/prod/cache","entries":[],"groups":[]}`, text)
+			},
+		},
+		{
+			name: "yaml",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "view": "summary", "format": "yaml"},
+			check: func(t *testing.T, text string) {
+				assert.Equal(t, "entries:\n    - 198.51.100.7/32\n    - 203.0.113.0/24\ninstance_ref: acme/prod/orders-db\n", text)
+			},
+		},
+		{
+			name:        "misspelled instance",
+			args:        map[string]any{"instance_ref": "acme/prod/orders"},
+			wantToolErr: "did you mean acme/prod/orders-db?",
+		},
+	})
+}
+
+func TestAddAllowlistEntries(t *testing.T) {
+	runToolTests(t, addAllowlistEntries(false), []toolTest{
+		{
+			name: "new group",
+			args: map[string]any{"instance_ref": "acme/prod/cache", "entries": []any{"192.0.2.10", "2001:db8::/32"}},
+			check: func(t *testing.T, text string) {
+				change := decode[allowlistChange](t, text)
+				assert.Equal(t, "default", change.Group)
+				assert.Equal(t, []string{"192.0.2.10/32", "2001:db8::/32"}, change.Added)
+				assert.Equal(t, []string{"192.0.2.10/32", "2001:db8::/32"}, change.Entries)
+			},
+		},
+		{
+			name: "covered entries are skipped",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "entries": []any{"203.0.113.9", "192.0.2.0/24", "192.0.2.128/25"}, "group": "office"},
+			check: func(t *testing.T, text string) {
+				change := decode[allowlistChange](t, text)
+				assert.Equal(t, []string{"192.0.2.0/24"}, change.Added)
+				assert.Equal(t, []string{
+					"192.0.2.128/25 is covered by 192.0.2.0/24 in this call",
+					"203.0.113.9/32 is already allowed by 203.0.113.0/24 (group office)",
+				}, change.Skipped)
+				assert.Equal(t, []string{"192.0.2.0/24", "198.51.100.7/32", "203.0.113.0/24"}, change.Entries)
+			},
+		},
+		{
+			name: "broader entries report overlaps",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "entries": []any{"198.51.100.0/24"}},
+			check: func(t *testing.T, text string) {
+				change := decode[allowlistChange](t, text)
+				assert.Equal(t, []string{"198.51.100.0/24"}, change.Added)
+				assert.Equal(t, []string{"198.51.100.0/24 covers the existing entry 198.51.100.7/32 (group ci)"}, change.Overlaps)
+			},
+		},
+		{
+			name: "nothing to add",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "entries": []any{"198.51.100.7/32"}},
+			check: func(t *testing.T, text string) {
+				change := decode[allowlistChange](t, text)
+				assert.Empty(t, change.Added)
+				assert.Len(t, change.Skipped, 1)
+			},
+		},
+		{
+			name:        "open entry",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "entries": []any{"10.0.0.0/8", "0.0.0.0/0"}},
+			wantToolErr: "0.0.0.0/0 allows every address and is refused unless the server is started with --allow-open-allowlist",
+		},
+		{
+			name: "broad entries",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "entries": []any{"0.0.0.0/1", "2001:db8::/29"}},
+			check: func(t *testing.T, text string) {
+				assert.Equal(t, []string{"0.0.0.0/1", "2001:db8::/29"}, decode[allowlistChange](t, text).Added)
+			},
+		},
+		{
+			name:        "ipv4-mapped open entry",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "entries": []any{"::ffff:0.0.0.0/96"}},
+			wantToolErr: "0.0.0.0/0 allows every address",
+		},
+		{
+			name: "ipv4-mapped entry",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "entries": []any{"::ffff:192.0.2.0/120"}},
+			check: func(t *testing.T, text string) {
+				assert.Equal(t, []string{"192.0.2.0/24"}, decode[allowlistChange](t, text).Added)
+			},
+		},
+		{
+			name:        "host bits set",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "entries": []any{"192.0.2.1/24"}},
+			wantToolErr: `invalid entry "192.0.2.1/24": host bits are set; did you mean 192.0.2.0/24?`,
+		},
+		{
+			name:        "invalid entry",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "entries": []any{"office"}},
+			wantToolErr: `invalid entry "office"`,
+		},
+		{
+			name:        "no entries",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "entries": []any{}},
+			wantToolErr: "missing required parameter: entries",
+		},
+	})
+
+	runToolTests(t, addAllowlistEntries(true), []toolTest{
+		{
+			name: "open entry allowed by the server",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "entries": []any{"::/0"}, "group": "anywhere"},
+			check: func(t *testing.T, text string) {
+				assert.Equal(t, []string{"::/0"}, decode[allowlistChange](t, text).Added)
+			},
+		},
+	})
+
+	t.Run("updates the named group", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		_, handler := kbcloud.AddAllowlistEntries(s.GetClientFn(), false)
+
+		result, err := callTool(handler, map[string]any{"instance_ref": "acme/prod/orders-db", "entries": []any{"192.0.2.0/24"}, "group": "ci"})
+		require.NoError(t, err)
+		require.False(t, result.IsError, resultText(t, result))
+
+		addresses, ok := s.IPWhitelist("acme", "orders-db", "ci")
+		require.True(t, ok)
+		assert.Equal(t, []string{"198.51.100.7/32", "192.0.2.0/24"}, addresses)
+		_, ok = s.IPWhitelist("acme", "orders-db", "default")
+		assert.False(t, ok)
+	})
+
+	t.Run("refused entries never reach KB Cloud", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		_, handler := kbcloud.AddAllowlistEntries(s.GetClientFn(), false)
+
+		result, err := callTool(handler, map[string]any{"instance_ref": "acme/prod/orders-db", "entries": []any{"192.0.2.0/24", "0.0.0.0/0"}})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Empty(t, s.Requests())
+	})
+}
+
+func TestRemoveAllowlistEntries(t *testing.T) {
+	runToolTests(t, kbcloud.RemoveAllowlistEntries, []toolTest{
+		{
+			name: "last entry of a group",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "entries": []any{"198.51.100.7"}, "confirm": "orders-db"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"instance_ref":"acme/prod/orders-db","removed":["198.51.100.7/32"],"entries":["203.0.113.0/24"]}`, text)
+			},
+		},
+		{
+			name:        "entry covered by a broader one",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "entries": []any{"203.0.113.9"}, "confirm": "orders-db"},
+			wantToolErr: "203.0.113.9/32 is not an entry itself but is allowed by 203.0.113.0/24 (group office); remove that entry instead",
+		},
+		{
+			name:        "unknown entry",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "entries": []any{"198.51.100.7", "192.0.2.0/24"}, "confirm": "orders-db"},
+			wantToolErr: "192.0.2.0/24 is not in the allowlist of acme/prod/orders-db\ncurrent entries: 198.51.100.7/32, 203.0.113.0/24",
+		},
+		{
+			name:        "invalid entry",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "entries": []any{"203.0.113.0/33"}},
+			wantToolErr: "no entries were removed",
+		},
+		{
+			name:        "unconfirmed",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "entries": []any{"198.51.100.7"}},
+			wantToolErr: `removing allowlist entries of acme/prod/orders-db can cut off its clients; call again with confirm set to "orders-db" to proceed`,
+		},
+	})
+
+	t.Run("deletes emptied groups", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		_, add := kbcloud.AddAllowlistEntries(s.GetClientFn(), false)
+		_, remove := kbcloud.RemoveAllowlistEntries(s.GetClientFn())
+
+		result, err := callTool(add, map[string]any{"instance_ref": "acme/prod/orders-db", "entries": []any{"192.0.2.0/24"}, "group": "office"})
+		require.NoError(t, err)
+		require.False(t, result.IsError, resultText(t, result))
+
+		result, err = callTool(remove, map[string]any{"instance_ref": "acme/prod/orders-db", "entries": []any{"203.0.113.0/24", "198.51.100.7/32"}, "confirm": "orders-db"})
+		require.NoError(t, err)
+		require.False(t, result.IsError, resultText(t, result))
+
+		addresses, ok := s.IPWhitelist("acme", "orders-db", "office")
+		require.True(t, ok)
+		assert.Equal(t, []string{"192.0.2.0/24"}, addresses)
+		_, ok = s.IPWhitelist("acme", "orders-db", "ci")
+		assert.False(t, ok)
+	})
+}
@@ -2,11 +2,12 @@ package kbcloud
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
@@ -15,7 +16,10 @@ import (
 func ListOrganizations(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
 	return mcp.NewTool("list_organizations",
 			mcp.WithDescription("List all organizations you have access to in KB Cloud"),
+			mcp.WithReadOnlyHintAnnotation(true),
 			WithPagination(),
+			WithResponseShaping(),
+			WithOutputSchema[kbcloud.UserOrgList](),
 		),
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			// Get pagination parameters
@@ -24,6 +28,12 @@ func ListOrganizations(getClient GetClientFn) (tool mcp.Tool, handler server.Too
 				return mcp.NewToolResultError(err.Error()), nil
 			}
 
+			// Get response shaping parameters
+			shaping, err := ResponseShapingParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
 			// Get KB Cloud client
 			client, err := getClient(ctx)
 			if err != nil {
@@ -31,8 +41,7 @@ func ListOrganizations(getClient GetClientFn) (tool mcp.Tool, handler server.Too
 			}
 
 			// Call KB Cloud API
-			opts := make([]interface{}, 0)
-			orgs, resp, err := client.Organization.ListOrg(client.Context, opts...)
+			orgs, resp, err := client.Organization.ListOrg(client.Context)
 			if err != nil {
 				return nil, fmt.Errorf("failed to list organizations: %w", err)
 			}
@@ -47,13 +56,11 @@ func ListOrganizations(getClient GetClientFn) (tool mcp.Tool, handler server.Too
 				return mcp.NewToolResultError(fmt.Sprintf("failed to list organizations: %s", string(body))), nil
 			}
 
-			// Return result
-			result, err := json.Marshal(orgs)
-			if err != nil {
-				return nil, fmt.Errorf("failed to marshal response: %w", err)
-			}
+			// Apply pagination
+			orgs.Items = paginate(orgs.Items, pagination)
 
-			return mcp.NewToolResultText(string(result)), nil
+			// Return result
+			return shaping.result(resourceOrganization, orgs)
 		}
 }
 
@@ -61,14 +68,22 @@ func ListOrganizations(getClient GetClientFn) (tool mcp.Tool, handler server.Too
 func GetOrganization(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
 	return mcp.NewTool("get_organization",
 			mcp.WithDescription("Get details of a specific organization in KB Cloud"),
+			mcp.WithReadOnlyHintAnnotation(true),
 			mcp.WithString("name",
-				mcp.Required(),
-				mcp.Description("Organization name"),
+				mcp.Description("Organization name; defaults to the session context"),
 			),
+			WithResponseShaping(),
+			WithOutputSchema[kbcloud.Org](),
 		),
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			// Get required parameters
-			orgName, err := RequiredParam[string](request, "name")
+			orgName, err := orgParam(ctx, request, "name")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get response shaping parameters
+			shaping, err := ResponseShapingParams(ctx, request)
 			if err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
@@ -96,11 +111,6 @@ func GetOrganization(getClient GetClientFn) (tool mcp.Tool, handler server.ToolH
 			}
 
 			// Return result
-			result, err := json.Marshal(org)
-			if err != nil {
-				return nil, fmt.Errorf("failed to marshal response: %w", err)
-			}
-
-			return mcp.NewToolResultText(string(result)), nil
+			return shaping.result(resourceOrganization, org)
 		}
 }
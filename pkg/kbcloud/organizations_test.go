@@ -0,0 +1,86 @@
+package kbcloud_test
+
+import (
+	"net/http"
+	"testing"
+
+	client "github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud/kbcloudtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListOrganizations(t *testing.T) {
+	runToolTests(t, kbcloud.ListOrganizations, []toolTest{
+		{
+			name: "all organizations",
+			check: func(t *testing.T, text string) {
+				orgs := decode[client.UserOrgList](t, text)
+				require.Len(t, orgs.Items, 2)
+				assert.Equal(t, "acme", orgs.Items[0].Name)
+				assert.Equal(t, "globex", orgs.Items[1].Name)
+			},
+		},
+		{
+			name: "second page",
+			args: map[string]any{"page": float64(2), "perPage": float64(1)},
+			check: func(t *testing.T, text string) {
+				orgs := decode[client.UserOrgList](t, text)
+				require.Len(t, orgs.Items, 1)
+				assert.Equal(t, "globex", orgs.Items[0].Name)
+			},
+		},
+		{
+			name: "page past the end",
+			args: map[string]any{"page": float64(5)},
+			check: func(t *testing.T, text string) {
+				assert.Empty(t, decode[client.UserOrgList](t, text).Items)
+			},
+		},
+		{
+			name:        "invalid pagination",
+			args:        map[string]any{"page": "first"},
+			wantToolErr: "page",
+		},
+		{
+			name: "server error",
+			setup: func(s *kbcloudtest.Server) {
+				s.InjectError(http.MethodGet, "/api/v1/organizations", http.StatusInternalServerError, "database unavailable")
+			},
+			wantErr: "failed to list organizations",
+		},
+	})
+}
+
+func TestGetOrganization(t *testing.T) {
+	runToolTests(t, kbcloud.GetOrganization, []toolTest{
+		{
+			name: "existing organization",
+			args: map[string]any{"name": "acme"},
+			check: func(t *testing.T, text string) {
+				org := decode[client.Org](t, text)
+				assert.Equal(t, "acme", org.Name)
+				assert.Equal(t, "Acme Corp", org.GetDisplayName())
+			},
+		},
+		{
+			name:        "missing name",
+			args:        map[string]any{},
+			wantToolErr: "name",
+		},
+		{
+			name:    "unknown organization",
+			args:    map[string]any{"name": "initech"},
+			wantErr: "404",
+		},
+		{
+			name: "forbidden",
+			args: map[string]any{"name": "acme"},
+			setup: func(s *kbcloudtest.Server) {
+				s.InjectError(http.MethodGet, "/api/v1/organizations/acme", http.StatusForbidden, "not a member")
+			},
+			wantErr: "403",
+		},
+	})
+}
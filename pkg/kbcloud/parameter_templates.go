@@ -0,0 +1,552 @@
+package kbcloud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// templateNamePattern matches the parameter template names KB Cloud accepts
+var templateNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9.-]{0,61}[a-z0-9])?$`)
+
+// parameterTemplateList is the result of list_parameter_templates
+type parameterTemplateList struct {
+	Items     []kbcloud.ParamTplListItem `json:"items"`
+	TotalSize int                        `json:"totalSize"`
+}
+
+// templateParameter is a parameter value set by a template
+type templateParameter struct {
+	Name  string `json:"name"`
+	File  string `json:"file,omitempty"`
+	Value string `json:"value"`
+}
+
+// parameterTemplate is a parameter template with the values it sets
+type parameterTemplate struct {
+	Name       string              `json:"name"`
+	Family     string              `json:"family"`
+	Partition  string              `json:"partition,omitempty"`
+	Parameters []templateParameter `json:"parameters"`
+}
+
+// templateApplication is the result of apply_parameter_template. When an
+// instance fails, its update carries the error and the instances after it
+// are listed as pending.
+type templateApplication struct {
+	TemplateName     string            `json:"template_name"`
+	Family           string            `json:"family"`
+	Instances        []parameterUpdate `json:"instances"`
+	PendingInstances []string          `json:"pending_instances,omitempty"`
+	RestartRequired  bool              `json:"restart_required"`
+}
+
+// withTemplatePartition adds the partition parameter to a tool
+func withTemplatePartition() mcp.ToolOption {
+	return mcp.WithString("partition",
+		mcp.Description("custom for the organization's own templates, default for the templates KB Cloud provides"),
+		mcp.Enum(string(kbcloud.ParamTplPartitionCustom), string(kbcloud.ParamTplPartitionDefault)),
+	)
+}
+
+// ListParameterTemplates creates a tool to list the parameter templates of an organization
+func ListParameterTemplates(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("list_parameter_templates",
+			mcp.WithDescription("List the reusable engine parameter templates of an organization"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithString("org_name",
+				mcp.Description("Organization name; defaults to the session context"),
+			),
+			mcp.WithString("engine",
+				mcp.Description("Only templates for this engine, e.g. mysql"),
+			),
+			withTemplatePartition(),
+			WithPagination(),
+			WithResponseShaping(),
+			WithOutputSchema[parameterTemplateList](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			orgName, err := orgParam(ctx, request, "org_name")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get optional parameters
+			engine, err := OptionalParam[string](request, "engine")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			partition, err := OptionalParam[string](request, "partition")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			pagination, err := OptionalPaginationParams(request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			shaping, err := ResponseShapingParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			// Call KB Cloud API
+			templates, err := listParameterTemplates(client, orgName, partition)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+
+			items := []kbcloud.ParamTplListItem{}
+			for _, t := range templates {
+				if engine == "" || strings.HasPrefix(strings.ToLower(t.Family), strings.ToLower(engine)) {
+					items = append(items, t)
+				}
+			}
+
+			// Return result
+			return shaping.result(resourceParameterTemplate, parameterTemplateList{
+				Items:     paginate(items, pagination),
+				TotalSize: len(items),
+			})
+		}
+}
+
+// GetParameterTemplate creates a tool to get the parameter values of a template
+func GetParameterTemplate(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("get_parameter_template",
+			mcp.WithDescription("Get the engine family of a parameter template and the parameter values it sets"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithString("org_name",
+				mcp.Description("Organization name; defaults to the session context"),
+			),
+			mcp.WithString("template_name",
+				mcp.Required(),
+				mcp.Description("Parameter template name"),
+			),
+			withTemplatePartition(),
+			WithResponseShaping(),
+			WithOutputSchema[parameterTemplate](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			orgName, err := orgParam(ctx, request, "org_name")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			name, err := RequiredParam[string](request, "template_name")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get optional parameters
+			partition, err := OptionalParam[string](request, "partition")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			shaping, err := ResponseShapingParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			// Call KB Cloud API
+			template, err := getParameterTemplate(client, orgName, name, partition)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+
+			// Return result
+			return shaping.result(resourceParameterTemplate, template)
+		}
+}
+
+// CreateParameterTemplate creates a tool to save the effective parameters of
+// an instance as a template
+func CreateParameterTemplate(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("create_parameter_template",
+			mcp.WithDescription("Save the current engine parameters of an instance as a custom parameter template of its organization, "+
+				"so they can be applied to other instances of the same engine version"),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(false),
+			WithInstanceRef(),
+			mcp.WithString("template_name",
+				mcp.Required(),
+				mcp.Description("Name of the new template: lowercase letters, digits, dots and dashes"),
+			),
+			mcp.WithString("description",
+				mcp.Description("What the template is tuned for"),
+			),
+			mcp.WithString("component",
+				mcp.Description("Component whose parameters to save; defaults to the first one"),
+			),
+			WithOutputSchema[parameterTemplate](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			ref, err := InstanceRefParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			name, err := RequiredParam[string](request, "template_name")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if !templateNamePattern.MatchString(name) {
+				return mcp.NewToolResultError(fmt.Sprintf("invalid template_name %q: use up to 63 lowercase letters, digits, dots and dashes, starting and ending with a letter or digit", name)), nil
+			}
+
+			// Get optional parameters
+			description, err := OptionalParam[string](request, "description")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			component, err := OptionalParam[string](request, "component")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			ref, instance, err := getInstance(client, ref)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+			if component, err = instanceComponent(ref, instance, component); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			templates, err := listParameterTemplates(client, ref.Org, string(kbcloud.ParamTplPartitionCustom))
+			if err != nil {
+				return nil, err
+			}
+			for _, t := range templates {
+				if t.Name == name {
+					return mcp.NewToolResultError(fmt.Sprintf("parameter template %q already exists in %s; choose another name", name, ref.Org)), nil
+				}
+			}
+
+			// Call KB Cloud API
+			body := kbcloud.NewParamTplCreateFromCluster(description, name)
+			body.SetComponent(component)
+			if version := instance.GetVersion(); version != "" {
+				body.SetEngineVersion(version)
+			}
+			resp, err := client.ParamTpl.CreateParamTplFromCluster(client.Context, ref.Org, ref.Instance, *body)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create parameter template: %w", err)
+			}
+			_ = resp.Body.Close()
+
+			template, err := getParameterTemplate(client, ref.Org, name, string(kbcloud.ParamTplPartitionCustom))
+			if err != nil {
+				return nil, err
+			}
+
+			// Return result
+			return renderResult(FormatJSON, template, nil)
+		}
+}
+
+// ApplyParameterTemplate creates a tool to apply a parameter template to instances
+func ApplyParameterTemplate(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("apply_parameter_template",
+			mcp.WithDescription("Apply the parameter values of a template to a set of instances of its engine version. "+
+				"Every instance is checked before any of them is changed. Templates that change parameters requiring a restart are only applied with allow_restart"),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithString("template_name",
+				mcp.Required(),
+				mcp.Description("Parameter template name"),
+			),
+			mcp.WithArray("instances",
+				mcp.Required(),
+				mcp.Description("Instances to apply the template to, as org/env/instance or bare instance names, all in one organization"),
+				mcp.WithStringItems(),
+			),
+			withTemplatePartition(),
+			mcp.WithString("component",
+				mcp.Description("Component to change; defaults to the first one of each instance"),
+			),
+			mcp.WithBoolean("allow_restart",
+				mcp.Description("Allow changes that restart the instances to take effect (default false)"),
+			),
+			WithOutputSchema[templateApplication](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			name, err := RequiredParam[string](request, "template_name")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			names, err := OptionalStringArrayParam(request, "instances")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if len(names) == 0 {
+				return mcp.NewToolResultError("instances must name at least one instance"), nil
+			}
+			var refs []InstanceRef
+			for _, n := range names {
+				ref, err := ParseInstanceRef(n)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error()), nil
+				}
+				refs = append(refs, ref)
+			}
+
+			// Get optional parameters
+			partition, err := OptionalParam[string](request, "partition")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			component, err := OptionalParam[string](request, "component")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			allowRestart, err := OptionalParam[bool](request, "allow_restart")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			// Templates belong to an organization, so every instance must be in the same one
+			var targets []templateTarget
+			seen := map[string]bool{}
+			for _, ref := range refs {
+				ref, instance, err := getInstance(client, ref)
+				if err != nil {
+					return lookupErrorResult(err)
+				}
+				if seen[ref.String()] {
+					continue
+				}
+				seen[ref.String()] = true
+				if len(targets) > 0 && ref.Org != targets[0].ref.Org {
+					return mcp.NewToolResultError(fmt.Sprintf("instances must belong to one organization: %s is not in %s", ref, targets[0].ref.Org)), nil
+				}
+				targets = append(targets, templateTarget{ref: ref, instance: instance})
+			}
+
+			template, err := getParameterTemplate(client, targets[0].ref.Org, name, partition)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+			values := map[string]any{}
+			for _, p := range template.Parameters {
+				values[p.Name] = p.Value
+			}
+
+			// Validate every instance before applying the template to any of them
+			var problems, restarts []string
+			for i := range targets {
+				t := &targets[i]
+				if !templateFits(template.Family, t.instance) {
+					problems = append(problems, fmt.Sprintf("%s runs %s %s, but %s is a %s template", t.ref, t.instance.Engine, t.instance.GetVersion(), name, template.Family))
+					continue
+				}
+				c, err := instanceComponent(t.ref, t.instance, component)
+				if err != nil {
+					problems = append(problems, err.Error())
+					continue
+				}
+				params, err := listParameters(client, t.ref, c)
+				if err != nil {
+					return nil, err
+				}
+				var instanceProblems, instanceRestarts []string
+				t.update, t.byFile, instanceProblems, instanceRestarts = planParameterUpdate(t.ref, c, params, values)
+				for _, problem := range instanceProblems {
+					problems = append(problems, fmt.Sprintf("%s: %s", t.ref, problem))
+				}
+				if len(instanceRestarts) > 0 {
+					restarts = append(restarts, fmt.Sprintf("%s (%s)", t.ref, strings.Join(instanceRestarts, ", ")))
+				}
+			}
+			if len(problems) > 0 {
+				return mcp.NewToolResultError("no instances were changed:\n- " + strings.Join(problems, "\n- ")), nil
+			}
+			if len(restarts) > 0 && !allowRestart {
+				return mcp.NewToolResultError(fmt.Sprintf("applying %s requires a restart of %s; call again with allow_restart set to true to apply the template", name, strings.Join(restarts, ", "))), nil
+			}
+
+			// Call KB Cloud API. Once an operation was submitted the template
+			// cannot be unapplied, so a later failure returns what was applied.
+			result := templateApplication{TemplateName: name, Family: template.Family, Instances: []parameterUpdate{}, RestartRequired: len(restarts) > 0}
+			for i, t := range targets {
+				if err := applyParameterUpdate(client, t.ref, &t.update, t.byFile); err != nil {
+					if len(result.Instances) == 0 && len(t.update.OpsRequests) == 0 {
+						return nil, fmt.Errorf("%s: %w", t.ref, err)
+					}
+					t.update.Error = err.Error()
+					result.Instances = append(result.Instances, t.update)
+					for _, pending := range targets[i+1:] {
+						result.PendingInstances = append(result.PendingInstances, pending.ref.String())
+					}
+					partial, rerr := renderResult(FormatJSON, result, nil)
+					if rerr != nil {
+						return nil, rerr
+					}
+					partial.IsError = true
+					return partial, nil
+				}
+				result.Instances = append(result.Instances, t.update)
+			}
+
+			// Return result
+			return renderResult(FormatJSON, result, nil)
+		}
+}
+
+// templateTarget is an instance a template is applied to, with the update
+// planned for it
+type templateTarget struct {
+	ref      InstanceRef
+	instance kbcloud.Cluster
+	update   parameterUpdate
+	byFile   map[string]map[string]string
+}
+
+// listParameterTemplates returns the parameter templates of an organization,
+// reporting a missing organization as a lookupError
+func listParameterTemplates(client *Client, orgName, partition string) ([]kbcloud.ParamTplListItem, error) {
+	opts := kbcloud.NewListParamTplOptionalParameters()
+	if partition != "" {
+		opts.WithPartition(kbcloud.ParamTplPartition(partition))
+	}
+	templates, resp, err := client.ParamTpl.ListParamTpl(client.Context, orgName, *opts)
+	if resp != nil {
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, unknownOrgError(client, orgName)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list parameter templates: %w", err)
+	}
+	return templates.Items, nil
+}
+
+// getParameterTemplate returns a parameter template with the values it sets.
+// A missing template is reported as a lookupError suggesting similar names.
+func getParameterTemplate(client *Client, orgName, name, partition string) (parameterTemplate, error) {
+	opts := kbcloud.NewReadParamTplOptionalParameters()
+	if partition != "" {
+		opts.WithPartition(kbcloud.ParamTplPartition(partition))
+	}
+	tpl, resp, err := client.ParamTpl.ReadParamTpl(client.Context, orgName, name, *opts)
+	if resp != nil {
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode == http.StatusNotFound {
+			return parameterTemplate{}, templateNotFoundError(client, orgName, name, partition)
+		}
+	}
+	if err != nil {
+		return parameterTemplate{}, fmt.Errorf("failed to get parameter template: %w", err)
+	}
+
+	template := parameterTemplate{Name: name, Family: tpl.GetFamily(), Partition: partition, Parameters: []templateParameter{}}
+	for _, item := range tpl.Items {
+		for _, p := range parseTemplateConfig(item.Config.Content) {
+			p.File = item.Config.FileName
+			template.Parameters = append(template.Parameters, p)
+		}
+	}
+	return template, nil
+}
+
+// templateNotFoundError reports a template that does not exist, suggesting
+// similarly named ones
+func templateNotFoundError(client *Client, orgName, name, partition string) error {
+	templates, err := listParameterTemplates(client, orgName, partition)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, t := range templates {
+		names = append(names, t.Name)
+	}
+	message := fmt.Sprintf("parameter template %q not found in %s", name, orgName)
+	if suggestions := suggest(name, names); len(suggestions) > 0 {
+		return &lookupError{fmt.Sprintf("%s; did you mean %s?", message, strings.Join(suggestions, ", "))}
+	}
+	return &lookupError{message + "; use list_parameter_templates to see the templates"}
+}
+
+// templateFits reports whether a template family, an engine followed by an
+// optional version such as mysql8.0, matches the engine and version of an
+// instance
+func templateFits(family string, instance kbcloud.Cluster) bool {
+	family, engine := strings.ToLower(family), strings.ToLower(instance.Engine)
+	if !strings.HasPrefix(family, engine) {
+		return false
+	}
+	version := strings.TrimPrefix(family, engine)
+	return version == "" || instance.GetVersion() == version || strings.HasPrefix(instance.GetVersion(), version+".")
+}
+
+// parseTemplateConfig returns the parameters a configuration file sets, in
+// file order. It reads "name = value" lines as in my.cnf and postgresql.conf
+// and "name value" lines as in redis.conf, skipping comments and sections.
+// Options without a value, such as skip-name-resolve in my.cnf, are on.
+func parseTemplateConfig(content string) []templateParameter {
+	var params []templateParameter
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == ';' || line[0] == '[' {
+			continue
+		}
+
+		var name, value string
+		if i := strings.Index(line, "="); i >= 0 {
+			name, value = line[:i], line[i+1:]
+		} else if fields := strings.Fields(line); len(fields) > 1 {
+			name, value = fields[0], strings.Join(fields[1:], " ")
+		} else {
+			name, value = line, "ON"
+		}
+		params = append(params, templateParameter{Name: strings.TrimSpace(name), Value: configValue(value)})
+	}
+	return params
+}
+
+// configValue returns a configuration file value without quotes or a
+// trailing comment
+func configValue(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) > 0 && (value[0] == '\'' || value[0] == '"') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			return value[1 : end+1]
+		}
+	}
+	if i := strings.IndexByte(value, '#'); i >= 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(value)
+}
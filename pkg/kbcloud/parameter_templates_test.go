@@ -0,0 +1,349 @@
+package kbcloud_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud/kbcloudtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parameterTemplate is the JSON result of get_parameter_template
+type parameterTemplate struct {
+	Name       string `json:"name"`
+	Family     string `json:"family"`
+	Partition  string `json:"partition"`
+	Parameters []struct {
+		Name  string `json:"name"`
+		File  string `json:"file"`
+		Value string `json:"value"`
+	} `json:"parameters"`
+}
+
+// templateApplication is the JSON result of apply_parameter_template
+type templateApplication struct {
+	TemplateName     string            `json:"template_name"`
+	Family           string            `json:"family"`
+	Instances        []parameterUpdate `json:"instances"`
+	PendingInstances []string          `json:"pending_instances"`
+	RestartRequired  bool              `json:"restart_required"`
+}
+
+func TestListParameterTemplates(t *testing.T) {
+	runToolTests(t, kbcloud.ListParameterTemplates, []toolTest{
+		{
+			name: "templates of an organization",
+			args: map[string]any{"org_name": "acme", "fields": "name,family,partition"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"items":[
+					{"name":"mysql8.0-default","family":"mysql8.0","partition":"default"},
+					{"name":"mysql-oltp","family":"mysql8.0","partition":"custom"},
+					{"name":"mysql-reporting","family":"mysql8.0","partition":"custom"},
+					{"name":"postgresql-reporting","family":"postgresql15","partition":"custom"}
+				],"totalSize":4}`, text)
+			},
+		},
+		{
+			name: "custom mysql templates",
+			args: map[string]any{"org_name": "acme", "engine": "MySQL", "partition": "custom", "fields": "name"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"items":[{"name":"mysql-oltp"},{"name":"mysql-reporting"}],"totalSize":2}`, text)
+			},
+		},
+		{
+			name: "pagination",
+			args: map[string]any{"org_name": "acme", "page": float64(2), "perPage": float64(3), "fields": "name"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"items":[{"name":"postgresql-reporting"}],"totalSize":4}`, text)
+			},
+		},
+		{
+			name: "no templates",
+			args: map[string]any{"org_name": "globex"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"items":[],"totalSize":0}`, text)
+			},
+		},
+		{
+			name:        "misspelled organization",
+			args:        map[string]any{"org_name": "acm"},
+			wantToolErr: "did you mean acme?",
+		},
+		{
+			name:        "missing organization",
+			args:        map[string]any{},
+			wantToolErr: "missing required parameter: org_name",
+		},
+	})
+}
+
+func TestGetParameterTemplate(t *testing.T) {
+	runToolTests(t, kbcloud.GetParameterTemplate, []toolTest{
+		{
+			name: "my.cnf template",
+			args: map[string]any{"org_name": "acme", "template_name": "mysql-oltp"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"name":"mysql-oltp","family":"mysql8.0","parameters":[
+					{"name":"max_connections","file":"my.cnf","value":"2000"},
+					{"name":"slow_query_log","file":"my.cnf","value":"ON"},
+					{"name":"long_query_time","file":"my.cnf","value":"0.5"},
+					{"name":"transaction_isolation","file":"my.cnf","value":"READ-COMMITTED"}
+				]}`, text)
+			},
+		},
+		{
+			name: "quoted values and comments",
+			args: map[string]any{"org_name": "acme", "template_name": "postgresql-reporting", "fields": "parameters.value"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"parameters":[{"value":"2GB"},{"value":"64MB"}]}`, text)
+			},
+		},
+		{
+			name: "default partition",
+			args: map[string]any{"org_name": "acme", "template_name": "mysql8.0-default", "partition": "default"},
+			check: func(t *testing.T, text string) {
+				template := decode[parameterTemplate](t, text)
+				assert.Equal(t, "default", template.Partition)
+				assert.Len(t, template.Parameters, 2)
+			},
+		},
+		{
+			name:        "misspelled template",
+			args:        map[string]any{"org_name": "acme", "template_name": "mysql-oltq"},
+			wantToolErr: `parameter template "mysql-oltq" not found in acme; did you mean mysql-oltp?`,
+		},
+		{
+			name:        "template of another partition",
+			args:        map[string]any{"org_name": "acme", "template_name": "mysql-oltp", "partition": "default"},
+			wantToolErr: "not found in acme",
+		},
+		{
+			name: "KB Cloud error",
+			args: map[string]any{"org_name": "acme", "template_name": "mysql-oltp"},
+			setup: func(s *kbcloudtest.Server) {
+				s.InjectError(http.MethodGet, "/api/v1/organizations/acme/paramTpls/mysql-oltp", http.StatusInternalServerError, "boom")
+			},
+			wantErr: "failed to get parameter template",
+		},
+	})
+}
+
+func TestCreateParameterTemplate(t *testing.T) {
+	runToolTests(t, kbcloud.CreateParameterTemplate, []toolTest{
+		{
+			name: "from an instance",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "template_name": "orders-baseline", "description": "Checkout defaults"},
+			check: func(t *testing.T, text string) {
+				template := decode[parameterTemplate](t, text)
+				assert.Equal(t, "orders-baseline", template.Name)
+				assert.Equal(t, "mysql8.0", template.Family)
+				assert.Equal(t, "custom", template.Partition)
+				require.Len(t, template.Parameters, 7)
+				assert.Equal(t, "max_connections", template.Parameters[4].Name)
+				assert.Equal(t, "151", template.Parameters[4].Value)
+			},
+		},
+		{
+			name:        "existing template",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "template_name": "mysql-oltp"},
+			wantToolErr: `parameter template "mysql-oltp" already exists in acme`,
+		},
+		{
+			name:        "invalid name",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "template_name": "Orders_Baseline"},
+			wantToolErr: "invalid template_name",
+		},
+		{
+			name:        "unknown component",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "template_name": "orders-baseline", "component": "proxy"},
+			wantToolErr: `component "proxy" not found`,
+		},
+		{
+			name:    "instance without parameters",
+			args:    map[string]any{"instance_ref": "acme/prod/cache", "template_name": "cache-baseline"},
+			wantErr: "failed to create parameter template",
+		},
+	})
+
+	t.Run("saves the template", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		_, handler := kbcloud.CreateParameterTemplate(s.GetClientFn())
+
+		result, err := callTool(handler, map[string]any{"instance_ref": "acme/staging/analytics", "template_name": "analytics-baseline", "description": "Reporting"})
+		require.NoError(t, err)
+		require.False(t, result.IsError, resultText(t, result))
+
+		template, ok := s.ParameterTemplate("acme", "analytics-baseline")
+		require.True(t, ok)
+		assert.Equal(t, "postgresql15.7", template.Item.Family)
+		assert.Equal(t, "Reporting", template.Item.Description)
+		assert.Contains(t, template.Files["postgresql.conf"], "shared_buffers=1GB")
+	})
+
+	t.Run("applying it back changes nothing", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		_, create := kbcloud.CreateParameterTemplate(s.GetClientFn())
+		_, apply := kbcloud.ApplyParameterTemplate(s.GetClientFn())
+
+		result, err := callTool(create, map[string]any{"instance_ref": "acme/prod/orders-db", "template_name": "orders-baseline"})
+		require.NoError(t, err)
+		require.False(t, result.IsError, resultText(t, result))
+
+		result, err = callTool(apply, map[string]any{"template_name": "orders-baseline", "instances": []any{"acme/prod/orders-db"}})
+		require.NoError(t, err)
+		require.False(t, result.IsError, resultText(t, result))
+		application := decode[templateApplication](t, resultText(t, result))
+		require.Len(t, application.Instances, 1)
+		assert.Empty(t, application.Instances[0].Changes)
+		assert.Len(t, application.Instances[0].Unchanged, 7)
+	})
+}
+
+func TestApplyParameterTemplate(t *testing.T) {
+	runToolTests(t, kbcloud.ApplyParameterTemplate, []toolTest{
+		{
+			name: "template values",
+			args: map[string]any{"template_name": "mysql-oltp", "instances": []any{"acme/prod/orders-db"}},
+			check: func(t *testing.T, text string) {
+				application := decode[templateApplication](t, text)
+				assert.Equal(t, "mysql-oltp", application.TemplateName)
+				assert.Equal(t, "mysql8.0", application.Family)
+				require.Len(t, application.Instances, 1)
+
+				update := application.Instances[0]
+				assert.Equal(t, "acme/prod/orders-db", update.InstanceRef)
+				require.Len(t, update.Changes, 3)
+				assert.Equal(t, "long_query_time", update.Changes[0].Name)
+				assert.Equal(t, "0.5", update.Changes[0].To)
+				assert.Equal(t, "2000", update.Changes[1].To)
+				assert.Equal(t, "READ-COMMITTED", update.Changes[2].To)
+				assert.Equal(t, []string{"slow_query_log"}, update.Unchanged)
+				assert.Len(t, update.OpsRequests, 1)
+				assert.False(t, application.RestartRequired)
+			},
+		},
+		{
+			name: "bare instance names",
+			args: map[string]any{"template_name": "mysql-oltp", "instances": []any{"orders-db", "acme/prod/orders-db"}},
+			check: func(t *testing.T, text string) {
+				application := decode[templateApplication](t, text)
+				require.Len(t, application.Instances, 1)
+				assert.Equal(t, "acme/prod/orders-db", application.Instances[0].InstanceRef)
+			},
+		},
+		{
+			name:        "instance of another engine",
+			args:        map[string]any{"template_name": "mysql-oltp", "instances": []any{"acme/prod/orders-db", "acme/prod/cache"}},
+			wantToolErr: "no instances were changed:\n- acme/prod/cache runs redis 7.0.6, but mysql-oltp is a mysql8.0 template",
+		},
+		{
+			name:        "parameter the instance does not have",
+			args:        map[string]any{"template_name": "mysql-reporting", "instances": []any{"acme/prod/orders-db"}},
+			wantToolErr: `acme/prod/orders-db: unknown parameter "query_cache_type"`,
+		},
+		{
+			name:        "restart without allow_restart",
+			args:        map[string]any{"template_name": "postgresql-reporting", "instances": []any{"acme/staging/analytics"}},
+			wantToolErr: "applying postgresql-reporting requires a restart of acme/staging/analytics (shared_buffers)",
+		},
+		{
+			name:    "stopped instance",
+			args:    map[string]any{"template_name": "postgresql-reporting", "instances": []any{"acme/staging/analytics"}, "allow_restart": true},
+			wantErr: "acme/staging/analytics: failed to update parameters",
+		},
+		{
+			name:        "instances of several organizations",
+			args:        map[string]any{"template_name": "mysql-oltp", "instances": []any{"acme/prod/orders-db", "globex/dev/inventory"}},
+			wantToolErr: "instances must belong to one organization: globex/dev/inventory is not in acme",
+		},
+		{
+			name:        "misspelled template",
+			args:        map[string]any{"template_name": "mysql-oltq", "instances": []any{"acme/prod/orders-db"}},
+			wantToolErr: "did you mean mysql-oltp?",
+		},
+		{
+			name:        "misspelled instance",
+			args:        map[string]any{"template_name": "mysql-oltp", "instances": []any{"acme/prod/orders"}},
+			wantToolErr: "did you mean acme/prod/orders-db?",
+		},
+		{
+			name:        "no instances",
+			args:        map[string]any{"template_name": "mysql-oltp", "instances": []any{}},
+			wantToolErr: "at least one instance",
+		},
+	})
+
+	t.Run("applies the values", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		_, handler := kbcloud.ApplyParameterTemplate(s.GetClientFn())
+
+		result, err := callTool(handler, map[string]any{"template_name": "mysql-oltp", "instances": []any{"acme/prod/orders-db"}})
+		require.NoError(t, err)
+		require.False(t, result.IsError, resultText(t, result))
+
+		value, _ := s.ParameterValue("acme", "orders-db", "max_connections")
+		assert.Equal(t, 2000.0, value)
+		value, _ = s.ParameterValue("acme", "orders-db", "transaction_isolation")
+		assert.Equal(t, "READ-COMMITTED", value)
+	})
+
+	t.Run("reports a partial application", func(t *testing.T) {
+		// billing-db and reports-db are copies of orders-db
+		f := kbcloudtest.DefaultFixtures()
+		for _, c := range f.Clusters {
+			if c.Name != "orders-db" {
+				continue
+			}
+			for _, name := range []string{"billing-db", "reports-db"} {
+				copied := c
+				copied.Name = name
+				copied.SetId("cluster-" + name)
+				f.Clusters = append(f.Clusters, copied)
+				f.Parameters["acme/"+name] = append([]kbcloudtest.Parameter(nil), f.Parameters["acme/orders-db"]...)
+			}
+			break
+		}
+		s := kbcloudtest.NewServer(t, kbcloudtest.WithFixtures(f))
+		s.InjectError(http.MethodPost, "/api/v1/organizations/acme/clusters/billing-db/reconfigure", http.StatusConflict, "busy")
+		_, handler := kbcloud.ApplyParameterTemplate(s.GetClientFn())
+
+		result, err := callTool(handler, map[string]any{"template_name": "mysql-oltp", "instances": []any{"acme/prod/orders-db", "acme/prod/billing-db", "acme/prod/reports-db"}})
+		require.NoError(t, err)
+		text := resultText(t, result)
+		require.True(t, result.IsError, text)
+
+		application := decode[templateApplication](t, text)
+		require.Len(t, application.Instances, 2)
+		assert.Len(t, application.Instances[0].OpsRequests, 1)
+		assert.Empty(t, application.Instances[0].Error)
+
+		failed := application.Instances[1]
+		assert.Equal(t, "acme/prod/billing-db", failed.InstanceRef)
+		assert.Empty(t, failed.OpsRequests)
+		assert.NotEmpty(t, failed.PendingFiles)
+		assert.Contains(t, failed.Error, "failed to update parameters")
+		assert.Equal(t, []string{"acme/prod/reports-db"}, application.PendingInstances)
+
+		value, _ := s.ParameterValue("acme", "orders-db", "max_connections")
+		assert.Equal(t, 2000.0, value)
+		value, _ = s.ParameterValue("acme", "reports-db", "max_connections")
+		assert.Equal(t, 151.0, value)
+	})
+
+	t.Run("a mismatched instance stops the whole set", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		_, handler := kbcloud.ApplyParameterTemplate(s.GetClientFn())
+
+		result, err := callTool(handler, map[string]any{"template_name": "mysql-oltp", "instances": []any{"acme/prod/orders-db", "acme/prod/cache"}})
+		require.NoError(t, err)
+		require.True(t, result.IsError)
+
+		value, _ := s.ParameterValue("acme", "orders-db", "max_connections")
+		assert.Equal(t, 151.0, value)
+		for _, r := range s.Requests() {
+			assert.Equal(t, http.MethodGet, r.Method)
+		}
+	})
+}
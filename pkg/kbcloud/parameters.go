@@ -0,0 +1,561 @@
+package kbcloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/apecloud/kb-cloud-client-go/api/common"
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// instanceParameter is an engine parameter of an instance with its default
+// and the constraints a new value must meet
+type instanceParameter struct {
+	Name            string   `json:"name"`
+	File            string   `json:"file,omitempty"`
+	Type            string   `json:"type"`
+	Value           any      `json:"value"`
+	Default         any      `json:"default,omitempty"`
+	Modified        bool     `json:"modified"`
+	Minimum         *float64 `json:"minimum,omitempty"`
+	Maximum         *float64 `json:"maximum,omitempty"`
+	Enum            []any    `json:"enum,omitempty"`
+	RestartRequired bool     `json:"restart_required"`
+	Immutable       bool     `json:"immutable,omitempty"`
+	Description     string   `json:"description,omitempty"`
+}
+
+// parameterList is the result of list_instance_parameters and
+// diff_parameters_from_default
+type parameterList struct {
+	Component string              `json:"component"`
+	Items     []instanceParameter `json:"items"`
+	TotalSize int                 `json:"totalSize"`
+}
+
+// parameterChange is a parameter set by update_instance_parameters
+type parameterChange struct {
+	Name            string `json:"name"`
+	File            string `json:"file,omitempty"`
+	From            string `json:"from"`
+	To              string `json:"to"`
+	RestartRequired bool   `json:"restart_required"`
+}
+
+// parameterUpdate is the result of update_instance_parameters. When a
+// reconfigure operation fails after others were submitted, Error and
+// PendingFiles describe what was not applied.
+type parameterUpdate struct {
+	InstanceRef     string            `json:"instance_ref"`
+	Component       string            `json:"component"`
+	Changes         []parameterChange `json:"changes"`
+	Unchanged       []string          `json:"unchanged,omitempty"`
+	OpsRequests     []string          `json:"ops_requests,omitempty"`
+	PendingFiles    []string          `json:"pending_files,omitempty"`
+	Error           string            `json:"error,omitempty"`
+	RestartRequired bool              `json:"restart_required"`
+}
+
+// parameterSpecList is the parameter specs response. The client models
+// defaults and enum values as objects while KB Cloud returns scalars, so
+// specs are decoded here when the client rejects them.
+type parameterSpecList struct {
+	Items []struct {
+		FileName string `json:"fileName"`
+		Specs    []struct {
+			Name    string `json:"name"`
+			Default any    `json:"default"`
+		} `json:"specs"`
+	} `json:"items"`
+}
+
+// ListInstanceParameters creates a tool to list the engine parameters of an instance
+func ListInstanceParameters(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("list_instance_parameters",
+			mcp.WithDescription("List the engine parameters of an instance with their current value, default, allowed range or values, and whether changing them requires a restart"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			WithInstanceRef(),
+			mcp.WithString("component",
+				mcp.Description("Component whose parameters to list; defaults to the first one"),
+			),
+			mcp.WithString("query",
+				mcp.Description("Part of the parameter name, e.g. buffer"),
+			),
+			WithPagination(),
+			WithResponseShaping(),
+			WithOutputSchema[parameterList](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return parameterListHandler(ctx, getClient, request, false)
+		}
+}
+
+// DiffParametersFromDefault creates a tool to list the engine parameters of
+// an instance that differ from their defaults
+func DiffParametersFromDefault(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("diff_parameters_from_default",
+			mcp.WithDescription("List the engine parameters of an instance whose current value differs from the engine default"),
+			mcp.WithReadOnlyHintAnnotation(true),
+			WithInstanceRef(),
+			mcp.WithString("component",
+				mcp.Description("Component whose parameters to compare; defaults to the first one"),
+			),
+			mcp.WithString("query",
+				mcp.Description("Part of the parameter name, e.g. buffer"),
+			),
+			WithPagination(),
+			WithResponseShaping(),
+			WithOutputSchema[parameterList](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return parameterListHandler(ctx, getClient, request, true)
+		}
+}
+
+// parameterListHandler lists the parameters of an instance, only the
+// modified ones when modifiedOnly is set
+func parameterListHandler(ctx context.Context, getClient GetClientFn, request mcp.CallToolRequest, modifiedOnly bool) (*mcp.CallToolResult, error) {
+	// Get required parameters
+	ref, err := InstanceRefParams(ctx, request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// Get optional parameters
+	component, err := OptionalParam[string](request, "component")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	query, err := OptionalParam[string](request, "query")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	pagination, err := OptionalPaginationParams(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	shaping, err := ResponseShapingParams(ctx, request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// Get KB Cloud client
+	client, err := getClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+	}
+
+	ref, instance, err := getInstance(client, ref)
+	if err != nil {
+		return lookupErrorResult(err)
+	}
+	if component, err = instanceComponent(ref, instance, component); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// Call KB Cloud API
+	params, err := listParameters(client, ref, component)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []instanceParameter{}
+	for _, p := range params {
+		if modifiedOnly && !p.Modified {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(p.Name), strings.ToLower(query)) {
+			continue
+		}
+		items = append(items, p)
+	}
+
+	// Return result
+	return shaping.result(resourceParameter, parameterList{
+		Component: component,
+		Items:     paginate(items, pagination),
+		TotalSize: len(items),
+	})
+}
+
+// UpdateInstanceParameters creates a tool to change engine parameters of an instance
+func UpdateInstanceParameters(getClient GetClientFn) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("update_instance_parameters",
+			mcp.WithDescription("Change engine parameters of an instance in one batch. Every value is checked against the parameter's type, range and allowed values before anything is applied. "+
+				"Parameters that require a restart are only applied with allow_restart"),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			WithInstanceRef(),
+			mcp.WithObject("parameters",
+				mcp.Required(),
+				mcp.Description("New values by parameter name, e.g. {\"max_connections\": 500}"),
+				mcp.AdditionalProperties(map[string]any{"type": []string{"string", "number", "boolean"}}),
+			),
+			mcp.WithString("component",
+				mcp.Description("Component to change; defaults to the first one"),
+			),
+			mcp.WithBoolean("allow_restart",
+				mcp.Description("Allow changes that restart the instance to take effect (default false)"),
+			),
+			WithOutputSchema[parameterUpdate](),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			// Get required parameters
+			ref, err := InstanceRefParams(ctx, request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			values, err := parameterValuesParam(request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get optional parameters
+			component, err := OptionalParam[string](request, "component")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			allowRestart, err := OptionalParam[bool](request, "allow_restart")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			// Get KB Cloud client
+			client, err := getClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get KB Cloud client: %w", err)
+			}
+
+			ref, instance, err := getInstance(client, ref)
+			if err != nil {
+				return lookupErrorResult(err)
+			}
+			if component, err = instanceComponent(ref, instance, component); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			params, err := listParameters(client, ref, component)
+			if err != nil {
+				return nil, err
+			}
+
+			// Validate the whole batch before applying any of it
+			update, byFile, problems, restarts := planParameterUpdate(ref, component, params, values)
+			if len(problems) > 0 {
+				return mcp.NewToolResultError("no parameters were changed:\n- " + strings.Join(problems, "\n- ")), nil
+			}
+			if len(restarts) > 0 && !allowRestart {
+				return mcp.NewToolResultError(fmt.Sprintf("changing %s requires a restart of %s; call again with allow_restart set to true to apply the changes", strings.Join(restarts, ", "), ref)), nil
+			}
+
+			// Call KB Cloud API. Once an operation was submitted the update
+			// cannot be undone, so a later failure returns what was applied.
+			if err := applyParameterUpdate(client, ref, &update, byFile); err != nil {
+				if len(update.OpsRequests) == 0 {
+					return nil, err
+				}
+				update.Error = err.Error()
+				result, rerr := renderResult(FormatJSON, update, nil)
+				if rerr != nil {
+					return nil, rerr
+				}
+				result.IsError = true
+				return result, nil
+			}
+
+			// Return result
+			return renderResult(FormatJSON, update, nil)
+		}
+}
+
+// planParameterUpdate checks new values against the parameters of an
+// instance component. It returns the changes, grouped by configuration file
+// in byFile, the problems that prevent applying them and the names of the
+// changed parameters that need a restart.
+func planParameterUpdate(ref InstanceRef, component string, params []instanceParameter, values map[string]any) (update parameterUpdate, byFile map[string]map[string]string, problems, restarts []string) {
+	update = parameterUpdate{InstanceRef: ref.String(), Component: component, Changes: []parameterChange{}}
+	byFile = map[string]map[string]string{}
+	for _, name := range sortedKeys(values) {
+		param, ok := findParameter(params, name)
+		if !ok {
+			problems = append(problems, unknownParameterMessage(name, params))
+			continue
+		}
+		current := formatParameterValue(param.Value)
+		if param.Immutable && formatParameterValue(values[name]) == current {
+			update.Unchanged = append(update.Unchanged, name)
+			continue
+		}
+		value, err := validateParameterValue(param, values[name])
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if value == normalizeParameterValue(param, current) {
+			update.Unchanged = append(update.Unchanged, name)
+			continue
+		}
+		if byFile[param.File] == nil {
+			byFile[param.File] = map[string]string{}
+		}
+		byFile[param.File][name] = value
+		update.Changes = append(update.Changes, parameterChange{
+			Name: name, File: param.File, From: current, To: value, RestartRequired: param.RestartRequired,
+		})
+		if param.RestartRequired {
+			restarts = append(restarts, name)
+		}
+	}
+	update.RestartRequired = len(restarts) > 0
+	return update, byFile, problems, restarts
+}
+
+// applyParameterUpdate applies a planned update with one reconfigure
+// operation per configuration file, recording the ops requests in update.
+// On failure the files not applied are recorded as pending.
+func applyParameterUpdate(client *Client, ref InstanceRef, update *parameterUpdate, byFile map[string]map[string]string) error {
+	files := sortedKeys(byFile)
+	for i, file := range files {
+		body := kbcloud.NewReconfigureCreate(update.Component, byFile[file])
+		if file != "" {
+			body.SetConfigFileName(file)
+		}
+		ops, resp, err := client.Opsrequest.ReconfigureCluster(client.Context, ref.Org, ref.Instance, *body)
+		if err != nil {
+			update.PendingFiles = files[i:]
+			return fmt.Errorf("failed to update parameters: %w", err)
+		}
+		_ = resp.Body.Close()
+		update.OpsRequests = append(update.OpsRequests, ops.OpsRequestName)
+	}
+	return nil
+}
+
+// instanceComponent returns the named component of an instance, or its
+// first component when name is empty
+func instanceComponent(ref InstanceRef, instance kbcloud.Cluster, name string) (string, error) {
+	var components []string
+	for _, c := range instance.GetComponents() {
+		components = append(components, c.GetComponent())
+	}
+	if len(components) == 0 {
+		return "", fmt.Errorf("%s has no components", ref)
+	}
+	if name == "" {
+		return components[0], nil
+	}
+	if !contains(components, name) {
+		return "", fmt.Errorf("component %q not found on %s: must be one of %s", name, ref, strings.Join(components, ", "))
+	}
+	return name, nil
+}
+
+// listParameters returns the parameters of an instance component with their
+// defaults, sorted by name
+func listParameters(client *Client, ref InstanceRef, component string) ([]instanceParameter, error) {
+	props, resp, err := client.Parameter.ListParameterProps(client.Context, ref.Org, ref.Instance,
+		*kbcloud.NewListParameterPropsOptionalParameters().WithComponent(component))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list parameters: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	defaults, err := listParameterDefaults(client, ref, component)
+	if err != nil {
+		return nil, err
+	}
+
+	var params []instanceParameter
+	for _, item := range props.Items {
+		for _, prop := range item.Props {
+			p := instanceParameter{
+				Name:            prop.Name,
+				File:            item.GetFileName(),
+				Type:            prop.Type,
+				Value:           prop.Value,
+				Enum:            prop.Enum,
+				RestartRequired: prop.NeedRestart,
+				Immutable:       prop.Immutable,
+				Description:     prop.GetDescription(),
+				Minimum:         prop.Minimum.Get(),
+				Maximum:         prop.Maximum.Get(),
+			}
+			if def, ok := defaults[p.File+"/"+p.Name]; ok {
+				p.Default = def
+				p.Modified = normalizeParameterValue(p, formatParameterValue(def)) != normalizeParameterValue(p, formatParameterValue(p.Value))
+			}
+			params = append(params, p)
+		}
+	}
+	sort.SliceStable(params, func(i, j int) bool { return params[i].Name < params[j].Name })
+	return params, nil
+}
+
+// listParameterDefaults returns the default of each parameter of an
+// instance component, keyed by "file/name"
+func listParameterDefaults(client *Client, ref InstanceRef, component string) (map[string]any, error) {
+	var specs parameterSpecList
+	typed, resp, err := client.Parameter.ListParameterSpecs(client.Context, ref.Org, ref.Instance,
+		*kbcloud.NewListParameterSpecsOptionalParameters().WithComponent(component))
+	var apiErr common.GenericOpenAPIError
+	switch {
+	case err == nil:
+		_ = resp.Body.Close()
+		data, err := json.Marshal(typed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list parameter defaults: %w", err)
+		}
+		if err := json.Unmarshal(data, &specs); err != nil {
+			return nil, fmt.Errorf("failed to list parameter defaults: %w", err)
+		}
+	case resp != nil && resp.StatusCode == http.StatusOK && errors.As(err, &apiErr):
+		if err := json.Unmarshal(apiErr.ErrorBody, &specs); err != nil {
+			return nil, fmt.Errorf("failed to list parameter defaults: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("failed to list parameter defaults: %w", err)
+	}
+
+	defaults := map[string]any{}
+	for _, item := range specs.Items {
+		for _, spec := range item.Specs {
+			defaults[item.FileName+"/"+spec.Name] = spec.Default
+		}
+	}
+	return defaults, nil
+}
+
+// findParameter returns the named parameter
+func findParameter(params []instanceParameter, name string) (instanceParameter, bool) {
+	for _, p := range params {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return instanceParameter{}, false
+}
+
+// unknownParameterMessage reports a parameter the instance does not have,
+// suggesting similarly named ones
+func unknownParameterMessage(name string, params []instanceParameter) string {
+	var names []string
+	for _, p := range params {
+		names = append(names, p.Name)
+	}
+	message := fmt.Sprintf("unknown parameter %q", name)
+	if suggestions := suggest(name, names); len(suggestions) > 0 {
+		return fmt.Sprintf("%s; did you mean %s?", message, strings.Join(suggestions, ", "))
+	}
+	return message + "; use list_instance_parameters to see the parameters"
+}
+
+// parameterValuesParam returns the parameters argument as values by name
+func parameterValuesParam(r mcp.CallToolRequest) (map[string]any, error) {
+	v, ok := r.GetArguments()["parameters"]
+	if !ok || v == nil {
+		return nil, errors.New("missing required parameter: parameters")
+	}
+	values, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("parameter parameters must be an object of values by name, is %T", v)
+	}
+	if len(values) == 0 {
+		return nil, errors.New("parameters must name at least one parameter")
+	}
+	return values, nil
+}
+
+// validateParameterValue checks a new value against the constraints of a
+// parameter, returning it as the string KB Cloud applies
+func validateParameterValue(p instanceParameter, v any) (string, error) {
+	if p.Immutable {
+		return "", fmt.Errorf("%s is immutable and cannot be changed", p.Name)
+	}
+	value := formatParameterValue(v)
+	if value == "" {
+		return "", fmt.Errorf("%s: value must not be empty", p.Name)
+	}
+
+	if len(p.Enum) > 0 {
+		var allowed []string
+		for _, e := range p.Enum {
+			option := formatParameterValue(e)
+			if strings.EqualFold(option, value) {
+				return option, nil
+			}
+			allowed = append(allowed, option)
+		}
+		return "", fmt.Errorf("%s: %q is not one of %s", p.Name, value, strings.Join(allowed, ", "))
+	}
+
+	switch p.Type {
+	case "integer", "int":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("%s: %q is not an integer", p.Name, value)
+		}
+		return value, checkParameterRange(p, float64(n))
+	case "number", "float", "real":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", fmt.Errorf("%s: %q is not a number", p.Name, value)
+		}
+		return value, checkParameterRange(p, f)
+	case "boolean", "bool":
+		switch strings.ToLower(value) {
+		case "true", "on", "1", "yes":
+			return "true", nil
+		case "false", "off", "0", "no":
+			return "false", nil
+		}
+		return "", fmt.Errorf("%s: %q is not a boolean", p.Name, value)
+	}
+	return value, nil
+}
+
+// normalizeParameterValue normalizes a current value with the rules new
+// values are validated with, so that ON compares equal to true. Values those
+// rules reject are returned unchanged.
+func normalizeParameterValue(p instanceParameter, value string) string {
+	p.Immutable = false
+	if normalized, err := validateParameterValue(p, value); err == nil {
+		return normalized
+	}
+	return value
+}
+
+// checkParameterRange checks a numeric value against the range of a parameter
+func checkParameterRange(p instanceParameter, v float64) error {
+	if p.Minimum != nil && v < *p.Minimum {
+		return fmt.Errorf("%s: %s is below the minimum of %s", p.Name, formatParameterValue(v), formatParameterValue(*p.Minimum))
+	}
+	if p.Maximum != nil && v > *p.Maximum {
+		return fmt.Errorf("%s: %s is above the maximum of %s", p.Name, formatParameterValue(v), formatParameterValue(*p.Maximum))
+	}
+	return nil
+}
+
+// formatParameterValue formats a parameter value as KB Cloud applies it:
+// whole numbers without a fraction, booleans as true or false
+func formatParameterValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,332 @@
+package kbcloud_test
+
+import (
+	"net/http"
+	"testing"
+
+	client "github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud/kbcloudtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parameterList is the JSON result of the parameter listing tools
+type parameterList struct {
+	Component string `json:"component"`
+	Items     []struct {
+		Name            string   `json:"name"`
+		File            string   `json:"file"`
+		Type            string   `json:"type"`
+		Value           any      `json:"value"`
+		Default         any      `json:"default"`
+		Modified        bool     `json:"modified"`
+		Minimum         *float64 `json:"minimum"`
+		Maximum         *float64 `json:"maximum"`
+		Enum            []any    `json:"enum"`
+		RestartRequired bool     `json:"restart_required"`
+		Immutable       bool     `json:"immutable"`
+	} `json:"items"`
+	TotalSize int `json:"totalSize"`
+}
+
+// parameterUpdate is the JSON result of update_instance_parameters
+type parameterUpdate struct {
+	InstanceRef string `json:"instance_ref"`
+	Component   string `json:"component"`
+	Changes     []struct {
+		Name            string `json:"name"`
+		File            string `json:"file"`
+		From            string `json:"from"`
+		To              string `json:"to"`
+		RestartRequired bool   `json:"restart_required"`
+	} `json:"changes"`
+	Unchanged       []string `json:"unchanged"`
+	OpsRequests     []string `json:"ops_requests"`
+	PendingFiles    []string `json:"pending_files"`
+	Error           string   `json:"error"`
+	RestartRequired bool     `json:"restart_required"`
+}
+
+func TestListInstanceParameters(t *testing.T) {
+	runToolTests(t, kbcloud.ListInstanceParameters, []toolTest{
+		{
+			name: "parameters with defaults and constraints",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db"},
+			check: func(t *testing.T, text string) {
+				params := decode[parameterList](t, text)
+				assert.Equal(t, "mysql", params.Component)
+				require.Len(t, params.Items, 7)
+				assert.Equal(t, 7, params.TotalSize)
+
+				pool := params.Items[0]
+				assert.Equal(t, "innodb_buffer_pool_size", pool.Name)
+				assert.Equal(t, "my.cnf", pool.File)
+				assert.Equal(t, 1073741824.0, pool.Value)
+				assert.Equal(t, 134217728.0, pool.Default)
+				assert.True(t, pool.Modified)
+				require.NotNil(t, pool.Minimum)
+				assert.Equal(t, 5242880.0, *pool.Minimum)
+				assert.False(t, pool.RestartRequired)
+
+				assert.Equal(t, "innodb_log_file_size", params.Items[1].Name)
+				assert.True(t, params.Items[1].RestartRequired)
+				assert.False(t, params.Items[1].Modified)
+				assert.True(t, params.Items[3].Immutable)
+			},
+		},
+		{
+			name: "query",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "query": "INNODB"},
+			check: func(t *testing.T, text string) {
+				params := decode[parameterList](t, text)
+				require.Len(t, params.Items, 2)
+				assert.Equal(t, "innodb_log_file_size", params.Items[1].Name)
+			},
+		},
+		{
+			name: "pagination",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "page": float64(2), "perPage": float64(5)},
+			check: func(t *testing.T, text string) {
+				params := decode[parameterList](t, text)
+				require.Len(t, params.Items, 2)
+				assert.Equal(t, 7, params.TotalSize)
+				assert.Equal(t, "slow_query_log", params.Items[0].Name)
+			},
+		},
+		{
+			name: "summary view",
+			args: map[string]any{"instance_ref": "acme/staging/analytics", "query": "shared", "view": "summary"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"component":"postgresql","items":[{"name":"shared_buffers","value":"1GB","default":"128MB","modified":true,"restart_required":true}],"totalSize":1}`, text)
+			},
+		},
+		{
+			name:        "unknown component",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "component": "proxy"},
+			wantToolErr: `component "proxy" not found on acme/prod/orders-db: must be one of mysql`,
+		},
+		{
+			name: "KB Cloud error",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db"},
+			setup: func(s *kbcloudtest.Server) {
+				s.InjectError(http.MethodGet, "/api/v1/organizations/acme/clusters/orders-db/parameterSpecs", http.StatusInternalServerError, "boom")
+			},
+			wantErr: "failed to list parameter defaults",
+		},
+	})
+}
+
+func TestDiffParametersFromDefault(t *testing.T) {
+	runToolTests(t, kbcloud.DiffParametersFromDefault, []toolTest{
+		{
+			name: "modified parameters",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "fields": "name,value,default"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"component":"mysql","items":[
+					{"name":"innodb_buffer_pool_size","value":1073741824,"default":134217728},
+					{"name":"long_query_time","value":2,"default":10},
+					{"name":"slow_query_log","value":true,"default":false}
+				],"totalSize":3}`, text)
+			},
+		},
+		{
+			name: "string values",
+			args: map[string]any{"instance_ref": "acme/staging/analytics", "fields": "name"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"component":"postgresql","items":[{"name":"max_connections"},{"name":"shared_buffers"}],"totalSize":2}`, text)
+			},
+		},
+		{
+			name: "no parameters",
+			args: map[string]any{"instance_ref": "acme/prod/cache"},
+			check: func(t *testing.T, text string) {
+				assert.JSONEq(t, `{"component":"redis","items":[],"totalSize":0}`, text)
+			},
+		},
+	})
+
+	t.Run("booleans as applied", func(t *testing.T) {
+		f := kbcloudtest.DefaultFixtures()
+		params := f.Parameters["acme/orders-db"]
+		for i := range params {
+			if params[i].Prop.Name == "slow_query_log" {
+				params[i].Prop.Value = "OFF"
+			}
+		}
+		s := kbcloudtest.NewServer(t, kbcloudtest.WithFixtures(f))
+		_, handler := kbcloud.DiffParametersFromDefault(s.GetClientFn())
+
+		result, err := callTool(handler, map[string]any{"instance_ref": "acme/prod/orders-db", "fields": "name"})
+		require.NoError(t, err)
+		assert.NotContains(t, resultText(t, result), "slow_query_log")
+	})
+}
+
+func TestUpdateInstanceParameters(t *testing.T) {
+	runToolTests(t, kbcloud.UpdateInstanceParameters, []toolTest{
+		{
+			name: "batch",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "parameters": map[string]any{
+				"max_connections":       500,
+				"slow_query_log":        "ON",
+				"transaction_isolation": "read-committed",
+				"long_query_time":       "2",
+			}},
+			check: func(t *testing.T, text string) {
+				update := decode[parameterUpdate](t, text)
+				assert.Equal(t, "mysql", update.Component)
+				require.Len(t, update.Changes, 2)
+				assert.Equal(t, "max_connections", update.Changes[0].Name)
+				assert.Equal(t, "151", update.Changes[0].From)
+				assert.Equal(t, "500", update.Changes[0].To)
+				assert.Equal(t, "READ-COMMITTED", update.Changes[1].To)
+				assert.Equal(t, []string{"long_query_time", "slow_query_log"}, update.Unchanged)
+				assert.Len(t, update.OpsRequests, 1)
+				assert.False(t, update.RestartRequired)
+			},
+		},
+		{
+			name: "nothing to change",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "parameters": map[string]any{"max_connections": "151"}},
+			check: func(t *testing.T, text string) {
+				update := decode[parameterUpdate](t, text)
+				assert.Empty(t, update.Changes)
+				assert.Empty(t, update.OpsRequests)
+			},
+		},
+		{
+			name:        "restart without allow_restart",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "parameters": map[string]any{"innodb_log_file_size": 100663296, "max_connections": 500}},
+			wantToolErr: "changing innodb_log_file_size requires a restart of acme/prod/orders-db",
+		},
+		{
+			name: "restart allowed",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "parameters": map[string]any{"innodb_log_file_size": 100663296}, "allow_restart": true},
+			check: func(t *testing.T, text string) {
+				update := decode[parameterUpdate](t, text)
+				require.Len(t, update.Changes, 1)
+				assert.True(t, update.RestartRequired)
+			},
+		},
+		{
+			name: "every problem is reported",
+			args: map[string]any{"instance_ref": "acme/prod/orders-db", "parameters": map[string]any{
+				"max_connection":         500,
+				"max_connections":        0,
+				"long_query_time":        "fast",
+				"lower_case_table_names": 1,
+				"transaction_isolation":  "CHAOS",
+				"slow_query_log":         "maybe",
+			}},
+			wantToolErr: "no parameters were changed:\n" +
+				"- long_query_time: \"fast\" is not a number\n" +
+				"- lower_case_table_names is immutable and cannot be changed\n" +
+				"- unknown parameter \"max_connection\"; did you mean max_connections?\n" +
+				"- max_connections: 0 is below the minimum of 1\n" +
+				"- slow_query_log: \"maybe\" is not a boolean\n" +
+				"- transaction_isolation: \"CHAOS\" is not one of READ-UNCOMMITTED, READ-COMMITTED, REPEATABLE-READ, SERIALIZABLE",
+		},
+		{
+			name:        "above the maximum",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "parameters": map[string]any{"max_connections": 1e6}},
+			wantToolErr: "max_connections: 1000000 is above the maximum of 100000",
+		},
+		{
+			name:        "not an integer",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "parameters": map[string]any{"max_connections": 10.5}},
+			wantToolErr: `"10.5" is not an integer`,
+		},
+		{
+			name:        "empty batch",
+			args:        map[string]any{"instance_ref": "acme/prod/orders-db", "parameters": map[string]any{}},
+			wantToolErr: "at least one parameter",
+		},
+		{
+			name:    "stopped instance",
+			args:    map[string]any{"instance_ref": "acme/staging/analytics", "parameters": map[string]any{"work_mem": "8MB"}},
+			wantErr: "failed to update parameters",
+		},
+	})
+
+	t.Run("applies the values", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		_, handler := kbcloud.UpdateInstanceParameters(s.GetClientFn())
+
+		result, err := callTool(handler, map[string]any{"instance_ref": "acme/prod/orders-db", "parameters": map[string]any{"max_connections": 500, "slow_query_log": false}})
+		require.NoError(t, err)
+		require.False(t, result.IsError, resultText(t, result))
+
+		value, ok := s.ParameterValue("acme", "orders-db", "max_connections")
+		require.True(t, ok)
+		assert.Equal(t, 500.0, value)
+		value, _ = s.ParameterValue("acme", "orders-db", "slow_query_log")
+		assert.Equal(t, false, value)
+	})
+
+	t.Run("reports a partial update", func(t *testing.T) {
+		f := kbcloudtest.DefaultFixtures()
+		prop := client.NewParameterProp("auto_increment_increment", "integer", false, false)
+		prop.Value = 1.0
+		f.Parameters["acme/orders-db"] = append(f.Parameters["acme/orders-db"],
+			kbcloudtest.Parameter{Component: "mysql", File: "mysqld-auto.cnf", Prop: *prop, Default: 1.0})
+		s := kbcloudtest.NewServer(t, kbcloudtest.WithFixtures(f))
+		_, handler := kbcloud.UpdateInstanceParameters(s.GetClientFn())
+
+		// The first operation leaves the instance Updating, so the fake
+		// server rejects the second
+		result, err := callTool(handler, map[string]any{"instance_ref": "acme/prod/orders-db", "parameters": map[string]any{"max_connections": 500, "auto_increment_increment": 2}})
+		require.NoError(t, err)
+		text := resultText(t, result)
+		require.True(t, result.IsError, text)
+
+		update := decode[parameterUpdate](t, text)
+		assert.Equal(t, []string{"orders-db-reconfigure-1"}, update.OpsRequests)
+		assert.Equal(t, []string{"mysqld-auto.cnf"}, update.PendingFiles)
+		assert.Contains(t, update.Error, "failed to update parameters")
+		value, _ := s.ParameterValue("acme", "orders-db", "max_connections")
+		assert.Equal(t, 500.0, value)
+	})
+
+	t.Run("compares booleans as applied", func(t *testing.T) {
+		f := kbcloudtest.DefaultFixtures()
+		params := f.Parameters["acme/orders-db"]
+		for i := range params {
+			if params[i].Prop.Name == "slow_query_log" {
+				params[i].Prop.Value = "ON"
+			}
+		}
+		s := kbcloudtest.NewServer(t, kbcloudtest.WithFixtures(f))
+		_, handler := kbcloud.UpdateInstanceParameters(s.GetClientFn())
+
+		result, err := callTool(handler, map[string]any{"instance_ref": "acme/prod/orders-db", "parameters": map[string]any{"slow_query_log": "on"}})
+		require.NoError(t, err)
+		require.False(t, result.IsError, resultText(t, result))
+		update := decode[parameterUpdate](t, resultText(t, result))
+		assert.Empty(t, update.Changes)
+		assert.Equal(t, []string{"slow_query_log"}, update.Unchanged)
+
+		result, err = callTool(handler, map[string]any{"instance_ref": "acme/prod/orders-db", "parameters": map[string]any{"slow_query_log": "OFF"}})
+		require.NoError(t, err)
+		require.False(t, result.IsError, resultText(t, result))
+		update = decode[parameterUpdate](t, resultText(t, result))
+		require.Len(t, update.Changes, 1)
+		assert.Equal(t, "ON", update.Changes[0].From)
+		assert.Equal(t, "false", update.Changes[0].To)
+	})
+
+	t.Run("invalid batches never reach KB Cloud", func(t *testing.T) {
+		s := kbcloudtest.NewServer(t)
+		_, handler := kbcloud.UpdateInstanceParameters(s.GetClientFn())
+
+		result, err := callTool(handler, map[string]any{"instance_ref": "acme/prod/orders-db", "parameters": map[string]any{"max_connections": 500, "max_connection": 1}})
+		require.NoError(t, err)
+		require.True(t, result.IsError)
+
+		value, _ := s.ParameterValue("acme", "orders-db", "max_connections")
+		assert.Equal(t, 151.0, value)
+		for _, r := range s.Requests() {
+			assert.Equal(t, http.MethodGet, r.Method)
+		}
+	})
+}
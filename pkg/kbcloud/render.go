@@ -0,0 +1,286 @@
+package kbcloud
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats
+const (
+	FormatJSON     = "json"
+	FormatYAML     = "yaml"
+	FormatMarkdown = "markdown"
+	FormatCSV      = "csv"
+)
+
+// formats are the supported output formats
+var formats = []string{FormatJSON, FormatYAML, FormatMarkdown, FormatCSV}
+
+// ValidateFormat checks that format is a supported output format
+func ValidateFormat(format string) error {
+	if !contains(formats, format) {
+		return fmt.Errorf("invalid format %q: must be one of %s", format, strings.Join(formats, ", "))
+	}
+	return nil
+}
+
+// defaultFormatKey is the context key of the deployment's default format
+type defaultFormatKey struct{}
+
+// withDefaultFormat makes format the default output format of a tool handler
+func withDefaultFormat(format string, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return next(context.WithValue(ctx, defaultFormatKey{}, format), request)
+	}
+}
+
+// defaultFormat returns the default output format for a tool call
+func defaultFormat(ctx context.Context) string {
+	if format, ok := ctx.Value(defaultFormatKey{}).(string); ok && format != "" {
+		return format
+	}
+	return FormatJSON
+}
+
+// WithOutputFormat adds the format parameter to a tool
+func WithOutputFormat() mcp.ToolOption {
+	return mcp.WithString("format",
+		mcp.Description("Output format: json, yaml, markdown (tables for lists) or csv (default set by the server, usually json)"),
+		mcp.Enum(formats...),
+	)
+}
+
+// OutputFormatParam returns the format parameter of a request, falling back
+// to the server's default format
+func OutputFormatParam(ctx context.Context, r mcp.CallToolRequest) (string, error) {
+	format, err := OptionalParam[string](r, "format")
+	if err != nil {
+		return "", err
+	}
+	if format == "" {
+		return defaultFormat(ctx), nil
+	}
+	return format, ValidateFormat(format)
+}
+
+// render formats doc, a value decoded from JSON. Lists, objects with an
+// items array, render as tables in markdown and CSV; columns follow the
+// order of paths, then the remaining fields alphabetically.
+func render(format string, doc any, paths []string) ([]byte, error) {
+	switch format {
+	case FormatYAML:
+		return yaml.Marshal(doc)
+	case FormatMarkdown:
+		return renderMarkdown(doc, paths), nil
+	case FormatCSV:
+		return renderCSV(doc, paths)
+	default:
+		return json.Marshal(doc)
+	}
+}
+
+// renderMarkdown renders lists as a table followed by the list metadata,
+// and objects as a definition list
+func renderMarkdown(doc any, paths []string) []byte {
+	var buf bytes.Buffer
+	items, meta, isList := splitList(doc)
+	if !isList {
+		writeDefinitions(&buf, flatten(doc))
+		return buf.Bytes()
+	}
+
+	if len(items) == 0 {
+		buf.WriteString("_No results._\n")
+	} else {
+		rows, columns := tableRows(items, paths)
+		buf.WriteString("| " + strings.Join(escapeCells(columns), " | ") + " |\n")
+		buf.WriteString("|" + strings.Repeat(" --- |", len(columns)) + "\n")
+		for _, row := range rows {
+			cells := make([]string, len(columns))
+			for i, column := range columns {
+				cells[i] = row[column]
+			}
+			buf.WriteString("| " + strings.Join(escapeCells(cells), " | ") + " |\n")
+		}
+	}
+
+	if len(meta) > 0 {
+		buf.WriteString("\n")
+		writeDefinitions(&buf, flatten(meta))
+	}
+	return buf.Bytes()
+}
+
+// writeDefinitions writes fields as a markdown definition list
+func writeDefinitions(buf *bytes.Buffer, fields map[string]string) {
+	for _, key := range sortedKeys(fields) {
+		fmt.Fprintf(buf, "- **%s**: %s\n", key, fields[key])
+	}
+}
+
+// escapeCells escapes markdown table delimiters
+func escapeCells(cells []string) []string {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = strings.ReplaceAll(strings.ReplaceAll(cell, "|", `\|`), "\n", " ")
+	}
+	return escaped
+}
+
+// renderCSV renders lists with one row per item, and objects as field,value
+// rows. List metadata is not included.
+func renderCSV(doc any, paths []string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	items, _, isList := splitList(doc)
+	if isList {
+		rows, columns := tableRows(items, paths)
+		if err := w.Write(columns); err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			record := make([]string, len(columns))
+			for i, column := range columns {
+				record[i] = row[column]
+			}
+			if err := w.Write(record); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		fields := flatten(doc)
+		if err := w.Write([]string{"field", "value"}); err != nil {
+			return nil, err
+		}
+		for _, key := range sortedKeys(fields) {
+			if err := w.Write([]string{key, fields[key]}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// splitList separates the items of a list from its other top-level fields
+func splitList(doc any) (items []any, meta map[string]any, ok bool) {
+	object, isObject := doc.(map[string]any)
+	if !isObject {
+		if array, isArray := doc.([]any); isArray {
+			return array, nil, true
+		}
+		return nil, nil, false
+	}
+	items, ok = object["items"].([]any)
+	if !ok {
+		return nil, nil, false
+	}
+	meta = map[string]any{}
+	for key, value := range object {
+		if key != "items" {
+			meta[key] = value
+		}
+	}
+	return items, meta, true
+}
+
+// tableRows flattens items into rows and returns the columns in display order
+func tableRows(items []any, paths []string) ([]map[string]string, []string) {
+	rows := make([]map[string]string, 0, len(items))
+	seen := map[string]bool{}
+	for _, item := range items {
+		row := flatten(item)
+		for column := range row {
+			seen[column] = true
+		}
+		rows = append(rows, row)
+	}
+
+	var columns []string
+	for _, path := range paths {
+		for _, column := range sortedKeys(seen) {
+			// Arrays are a single column, so components.replicas selects components
+			if column == path || strings.HasPrefix(column, path+".") || strings.HasPrefix(path, column+".") {
+				columns = append(columns, column)
+				delete(seen, column)
+			}
+		}
+	}
+	return rows, append(columns, sortedKeys(seen)...)
+}
+
+// flatten turns nested objects into dot-path keys. Arrays of scalars are
+// joined with commas; other arrays are kept as JSON.
+func flatten(doc any) map[string]string {
+	fields := map[string]string{}
+	var walk func(prefix string, value any)
+	walk = func(prefix string, value any) {
+		switch v := value.(type) {
+		case map[string]any:
+			for key, child := range v {
+				if prefix != "" {
+					key = prefix + "." + key
+				}
+				walk(key, child)
+			}
+		case []any:
+			fields[prefix] = formatArray(v)
+		default:
+			fields[prefix] = formatScalar(v)
+		}
+	}
+	if object, ok := doc.(map[string]any); ok {
+		walk("", object)
+	} else {
+		walk("value", doc)
+	}
+	return fields
+}
+
+// formatArray formats an array for a single cell
+func formatArray(values []any) string {
+	parts := make([]string, 0, len(values))
+	for _, value := range values {
+		switch value.(type) {
+		case map[string]any, []any:
+			data, _ := json.Marshal(values)
+			return string(data)
+		}
+		parts = append(parts, formatScalar(value))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatScalar formats a JSON scalar for a single cell
+func formatScalar(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,112 @@
+package kbcloud_test
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestValidateFormat(t *testing.T) {
+	for _, format := range []string{kbcloud.FormatJSON, kbcloud.FormatYAML, kbcloud.FormatMarkdown, kbcloud.FormatCSV} {
+		assert.NoError(t, kbcloud.ValidateFormat(format), format)
+	}
+	err := kbcloud.ValidateFormat("table")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must be one of json, yaml, markdown, csv")
+}
+
+func TestOutputFormat(t *testing.T) {
+	t.Run("List backups", func(t *testing.T) {
+		runToolTests(t, kbcloud.ListBackups, []toolTest{
+			{
+				name: "markdown table",
+				args: map[string]any{"instance_ref": "acme/prod/orders-db", "fields": "name,status", "format": "markdown"},
+				check: func(t *testing.T, text string) {
+					assert.Equal(t, strings.Join([]string{
+						"| name | status |",
+						"| --- | --- |",
+						"| orders-db-backup-1 | Completed |",
+						"| orders-db-backup-2 | Completed |",
+						"| orders-db-backup-3 | Running |",
+						"",
+						"- **pageResult.totalSize**: 3",
+						"",
+					}, "\n"), text)
+				},
+			},
+			{
+				name: "csv rows",
+				args: map[string]any{"instance_ref": "acme/prod/orders-db", "fields": []any{"status", "name"}, "format": "csv"},
+				check: func(t *testing.T, text string) {
+					records, err := csv.NewReader(strings.NewReader(text)).ReadAll()
+					require.NoError(t, err)
+					assert.Equal(t, [][]string{
+						{"status", "name"},
+						{"Completed", "orders-db-backup-1"},
+						{"Completed", "orders-db-backup-2"},
+						{"Running", "orders-db-backup-3"},
+					}, records)
+				},
+			},
+		})
+	})
+
+	t.Run("Get instance", func(t *testing.T) {
+		runToolTests(t, kbcloud.GetInstance, []toolTest{
+			{
+				name: "markdown definition list",
+				args: map[string]any{"instance_ref": "acme/prod/orders-db", "fields": "name,engine", "format": "markdown"},
+				check: func(t *testing.T, text string) {
+					assert.Equal(t, "- **engine**: mysql\n- **name**: orders-db\n", text)
+				},
+			},
+			{
+				name: "csv field and value",
+				args: map[string]any{"instance_ref": "acme/prod/orders-db", "fields": "name,components.replicas", "format": "csv"},
+				check: func(t *testing.T, text string) {
+					assert.Equal(t, "field,value\ncomponents,\"[{\"\"replicas\"\":1}]\"\nname,orders-db\n", text)
+				},
+			},
+			{
+				name: "yaml",
+				args: map[string]any{"instance_ref": "acme/prod/orders-db", "view": "summary", "format": "yaml"},
+				check: func(t *testing.T, text string) {
+					var doc map[string]any
+					require.NoError(t, yaml.Unmarshal([]byte(text), &doc))
+					assert.Equal(t, "orders-db", doc["name"])
+					assert.Equal(t, "Running", doc["status"])
+				},
+			},
+			{
+				name:        "invalid format",
+				args:        map[string]any{"instance_ref": "acme/prod/orders-db", "format": "xml"},
+				wantToolErr: `invalid format "xml"`,
+			},
+		})
+	})
+
+	t.Run("Find instance", func(t *testing.T) {
+		runToolTests(t, kbcloud.FindInstance, []toolTest{
+			{
+				name: "markdown",
+				args: map[string]any{"query": "orders-db", "format": "markdown"},
+				check: func(t *testing.T, text string) {
+					assert.Contains(t, text, "| acme/prod/orders-db |")
+					assert.Contains(t, text, "- **totalSize**: 1")
+				},
+			},
+			{
+				name: "no results",
+				args: map[string]any{"query": "zzzzzzzz", "format": "markdown"},
+				check: func(t *testing.T, text string) {
+					assert.True(t, strings.HasPrefix(text, "_No results._\n"), text)
+				},
+			},
+		})
+	})
+}
@@ -0,0 +1,387 @@
+package kbcloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/apecloud/kb-cloud-client-go/api/kbcloud"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxSuggestions bounds the names offered in "did you mean" errors
+const maxSuggestions = 3
+
+// InstanceRef identifies an instance by organization, environment and name
+type InstanceRef struct {
+	Org      string
+	Env      string
+	Instance string
+
+	// lookup is set when the ref came from instance_ref and must be
+	// checked against KB Cloud before use
+	lookup bool
+}
+
+// String returns the ref in org/env/instance form
+func (r InstanceRef) String() string {
+	return r.Org + "/" + r.Env + "/" + r.Instance
+}
+
+// ParseInstanceRef parses an instance reference of the form org/env/instance.
+// A bare instance name is also accepted and leaves Org and Env empty.
+func ParseInstanceRef(ref string) (InstanceRef, error) {
+	parts := strings.Split(strings.TrimSpace(ref), "/")
+	for _, part := range parts {
+		if part == "" {
+			return InstanceRef{}, fmt.Errorf("invalid instance_ref %q: expected org/env/instance or an instance name", ref)
+		}
+	}
+
+	switch len(parts) {
+	case 1:
+		return InstanceRef{Instance: parts[0], lookup: true}, nil
+	case 3:
+		return InstanceRef{Org: parts[0], Env: parts[1], Instance: parts[2], lookup: true}, nil
+	default:
+		return InstanceRef{}, fmt.Errorf("invalid instance_ref %q: expected org/env/instance or an instance name", ref)
+	}
+}
+
+// WithInstanceRef adds the parameters identifying an instance to a tool:
+// org_name, env_name and instance_name, or a single instance_ref
+func WithInstanceRef() mcp.ToolOption {
+	return func(tool *mcp.Tool) {
+		mcp.WithString("org_name",
+			mcp.Description("Organization name; defaults to the session context unless instance_ref is set"),
+		)(tool)
+		mcp.WithString("env_name",
+			mcp.Description("Environment name; defaults to the session context unless instance_ref is set"),
+		)(tool)
+		mcp.WithString("instance_name",
+			mcp.Description("Instance name (required unless instance_ref is set)"),
+		)(tool)
+		mcp.WithString("instance_ref",
+			mcp.Description("Instance as org/env/instance, or a bare instance name to search every organization. Replaces org_name, env_name and instance_name"),
+		)(tool)
+	}
+}
+
+// InstanceRefParams returns the instance a request targets, from instance_ref
+// or from org_name, env_name and instance_name. org_name and env_name fall
+// back to the session context.
+func InstanceRefParams(ctx context.Context, r mcp.CallToolRequest) (InstanceRef, error) {
+	ref, err := OptionalParam[string](r, "instance_ref")
+	if err != nil {
+		return InstanceRef{}, err
+	}
+	if ref != "" {
+		return ParseInstanceRef(ref)
+	}
+
+	orgName, err := orgParam(ctx, r, "org_name")
+	if err != nil {
+		return InstanceRef{}, err
+	}
+	envName, err := envParam(ctx, r, orgName)
+	if err != nil {
+		return InstanceRef{}, err
+	}
+	instanceName, err := RequiredParam[string](r, "instance_name")
+	if err != nil {
+		return InstanceRef{}, err
+	}
+	return InstanceRef{Org: orgName, Env: envName, Instance: instanceName}, nil
+}
+
+// lookupError is a failed instance lookup the caller can fix, such as a
+// misspelled name
+type lookupError struct {
+	message string
+}
+
+func (e *lookupError) Error() string {
+	return e.message
+}
+
+// lookupErrorResult turns a resolveInstance error into a tool result: lookup
+// errors are returned to the agent, anything else is a tool failure
+func lookupErrorResult(err error) (*mcp.CallToolResult, error) {
+	var lookupErr *lookupError
+	if errors.As(err, &lookupErr) {
+		return mcp.NewToolResultError(lookupErr.Error()), nil
+	}
+	return nil, err
+}
+
+// resolveInstance checks a ref given as instance_ref against the instances
+// visible to client, filling in the organization and environment of a bare
+// instance name. Refs built from explicit parameters are returned unchanged.
+func resolveInstance(client *Client, ref InstanceRef) (InstanceRef, error) {
+	if !ref.lookup {
+		return ref, nil
+	}
+	if ref.Org == "" {
+		return resolveInstanceName(client, ref.Instance)
+	}
+
+	instances, found, err := listOrgInstances(client, ref.Org, nil)
+	if err != nil {
+		return InstanceRef{}, err
+	}
+	if !found {
+		return InstanceRef{}, unknownOrgError(client, ref.Org)
+	}
+
+	var names []string
+	for _, instance := range instances {
+		if instance.Instance == ref.Instance {
+			if instance.Env == ref.Env {
+				return instance, nil
+			}
+			return InstanceRef{}, &lookupError{fmt.Sprintf("instance %q is in environment %q, not %q; did you mean %s?", ref.Instance, instance.Env, ref.Env, instance)}
+		}
+		names = append(names, instance.Instance)
+	}
+
+	message := fmt.Sprintf("instance %q not found in %s/%s", ref.Instance, ref.Org, ref.Env)
+	if suggestions := suggest(ref.Instance, names); len(suggestions) > 0 {
+		return InstanceRef{}, &lookupError{fmt.Sprintf("%s; did you mean %s?", message, strings.Join(refsNamed(instances, suggestions), ", "))}
+	}
+	return InstanceRef{}, &lookupError{message + "; use find_instance to search for it"}
+}
+
+// resolveInstanceName finds the only instance with the given name across
+// every accessible organization
+func resolveInstanceName(client *Client, name string) (InstanceRef, error) {
+	instances, err := listAllInstances(client, "", nil)
+	if err != nil {
+		return InstanceRef{}, err
+	}
+
+	var matches, names []string
+	var match InstanceRef
+	for _, instance := range instances {
+		if instance.Instance == name {
+			match = instance
+			matches = append(matches, instance.String())
+		}
+		names = append(names, instance.Instance)
+	}
+
+	switch {
+	case len(matches) == 1:
+		return match, nil
+	case len(matches) > 1:
+		return InstanceRef{}, &lookupError{fmt.Sprintf("instance name %q is ambiguous; use one of: %s", name, strings.Join(matches, ", "))}
+	}
+
+	message := fmt.Sprintf("instance %q not found in any accessible organization", name)
+	if suggestions := suggest(name, names); len(suggestions) > 0 {
+		return InstanceRef{}, &lookupError{fmt.Sprintf("%s; did you mean %s?", message, strings.Join(refsNamed(instances, suggestions), ", "))}
+	}
+	return InstanceRef{}, &lookupError{message + "; use find_instance to search for it"}
+}
+
+// unknownOrgError reports an organization that does not exist, suggesting
+// similarly named ones
+func unknownOrgError(client *Client, org string) error {
+	orgs, err := listOrgNames(client)
+	if err != nil {
+		return err
+	}
+	return orgNotFoundError(org, orgs)
+}
+
+// orgNotFoundError reports that org is not one of orgs
+func orgNotFoundError(org string, orgs []string) error {
+	message := fmt.Sprintf("organization %q not found", org)
+	if suggestions := suggest(org, orgs); len(suggestions) > 0 {
+		return &lookupError{fmt.Sprintf("%s; did you mean %s?", message, strings.Join(suggestions, ", "))}
+	}
+	return &lookupError{fmt.Sprintf("%s; available organizations: %s", message, strings.Join(orgs, ", "))}
+}
+
+// refsNamed returns the refs of the instances with the given names, in the
+// order of names
+func refsNamed(instances []InstanceRef, names []string) []string {
+	var refs []string
+	for _, name := range names {
+		for _, instance := range instances {
+			if instance.Instance == name {
+				refs = append(refs, instance.String())
+			}
+		}
+	}
+	return refs
+}
+
+// listOrgNames returns the names of the organizations visible to client,
+// following pagination
+func listOrgNames(client *Client) ([]string, error) {
+	var names []string
+	opts := kbcloud.NewListOrgOptionalParameters()
+	for {
+		orgs, resp, err := client.Organization.ListOrg(client.Context, *opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list organizations: %w", err)
+		}
+		_ = resp.Body.Close()
+
+		for _, org := range orgs.Items {
+			names = append(names, org.Name)
+		}
+
+		next := orgs.PageResult.GetNext()
+		if next == "" || (opts.PageToken != nil && next == *opts.PageToken) {
+			return names, nil
+		}
+		opts.WithPageToken(next)
+	}
+}
+
+// listAllInstances returns the instances of every accessible organization,
+// or only of org when it is set. Organizations the caller cannot read are
+// skipped.
+func listAllInstances(client *Client, org string, opts *kbcloud.ListClusterOptionalParameters) ([]InstanceRef, error) {
+	orgs := []string{org}
+	if org == "" {
+		var err error
+		if orgs, err = listOrgNames(client); err != nil {
+			return nil, err
+		}
+	}
+
+	var all []InstanceRef
+	for _, name := range orgs {
+		instances, _, err := listOrgInstances(client, name, opts)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, instances...)
+	}
+	return all, nil
+}
+
+// listOrgInstances returns the instances of org matching opts. found is false
+// when the organization does not exist or is not accessible.
+func listOrgInstances(client *Client, org string, opts *kbcloud.ListClusterOptionalParameters) (instances []InstanceRef, found bool, err error) {
+	items, found, err := listOrgClusters(client, org, opts)
+	for _, item := range items {
+		instances = append(instances, InstanceRef{Org: org, Env: item.EnvironmentName, Instance: item.Name})
+	}
+	return instances, found, err
+}
+
+// listOrgClusters lists the clusters of org matching opts. found is false
+// when the organization does not exist or is not accessible.
+func listOrgClusters(client *Client, org string, opts *kbcloud.ListClusterOptionalParameters) (items []kbcloud.ClusterListItem, found bool, err error) {
+	if opts == nil {
+		opts = kbcloud.NewListClusterOptionalParameters()
+	}
+	clusters, resp, err := client.Cluster.ListCluster(client.Context, org, *opts)
+	if resp != nil {
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
+			return nil, false, nil
+		}
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list instances: %w", err)
+	}
+	return clusters.Items, true, nil
+}
+
+// suggest returns up to maxSuggestions candidates that look like name: those
+// containing it, contained in it, or within a small edit distance
+func suggest(name string, candidates []string) []string {
+	type scored struct {
+		name     string
+		distance int
+	}
+
+	lower := strings.ToLower(name)
+	threshold := len(name) / 3
+	if threshold < 2 {
+		threshold = 2
+	}
+
+	seen := map[string]bool{}
+	var matches []scored
+	for _, candidate := range candidates {
+		if seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+
+		other := strings.ToLower(candidate)
+		distance := levenshtein(lower, other)
+		if distance <= threshold || strings.Contains(other, lower) || strings.Contains(lower, other) {
+			matches = append(matches, scored{name: candidate, distance: distance})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	var names []string
+	for i := 0; i < len(matches) && i < maxSuggestions; i++ {
+		names = append(names, matches[i].name)
+	}
+	return names
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// getInstance resolves ref and fetches its instance, making sure it lives in
+// the requested environment. Missing instances are reported as lookupErrors.
+func getInstance(client *Client, ref InstanceRef) (InstanceRef, kbcloud.Cluster, error) {
+	ref, err := resolveInstance(client, ref)
+	if err != nil {
+		return InstanceRef{}, kbcloud.Cluster{}, err
+	}
+
+	instance, resp, err := client.Cluster.GetCluster(client.Context, ref.Org, ref.Instance)
+	if resp != nil {
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode == http.StatusNotFound {
+			return InstanceRef{}, kbcloud.Cluster{}, &lookupError{fmt.Sprintf("instance %q not found in %s/%s; use find_instance to search for it", ref.Instance, ref.Org, ref.Env)}
+		}
+	}
+	if err != nil {
+		return InstanceRef{}, kbcloud.Cluster{}, fmt.Errorf("failed to get instance: %w", err)
+	}
+
+	// Cluster names are unique within an org, so make sure it lives in the requested environment
+	if instance.EnvironmentName != ref.Env {
+		return InstanceRef{}, kbcloud.Cluster{}, &lookupError{fmt.Sprintf("instance %s not found in environment %s", ref.Instance, ref.Env)}
+	}
+	return ref, instance, nil
+}
@@ -0,0 +1,37 @@
+package kbcloud_test
+
+import (
+	"testing"
+
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInstanceRef(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{ref: "acme/prod/orders-db", want: "acme/prod/orders-db"},
+		{ref: " acme/prod/orders-db ", want: "acme/prod/orders-db"},
+		{ref: "orders-db", want: "//orders-db"},
+		{ref: "acme/orders-db", wantErr: true},
+		{ref: "acme//orders-db", wantErr: true},
+		{ref: "acme/prod/orders-db/extra", wantErr: true},
+		{ref: "", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.ref, func(t *testing.T) {
+			ref, err := kbcloud.ParseInstanceRef(tc.ref)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, ref.String())
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package kbcloud
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/invopop/jsonschema"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// outputSchemas caches generated output schemas by type
+var outputSchemas sync.Map
+
+// textMarshalerType is the type of encoding.TextMarshaler
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// WithOutputSchema declares the output schema of a tool from the type T it
+// returns, usually a kb-cloud-client-go model. No field is required, so
+// responses narrowed with fields or view still match.
+func WithOutputSchema[T any]() mcp.ToolOption {
+	return mcp.WithRawOutputSchema(outputSchema(reflect.TypeFor[T]()))
+}
+
+// outputSchema returns the JSON schema of t
+func outputSchema(t reflect.Type) json.RawMessage {
+	if schema, ok := outputSchemas.Load(t); ok {
+		return schema.(json.RawMessage)
+	}
+
+	r := &jsonschema.Reflector{
+		DoNotReference:             true,
+		Anonymous:                  true,
+		AllowAdditionalProperties:  true,
+		RequiredFromJSONSchemaTags: true,
+	}
+	r.Mapper = func(t reflect.Type) *jsonschema.Schema {
+		return mapSchemaType(r, t)
+	}
+
+	schema := r.ReflectFromType(t)
+	schema.Version = ""
+	data, err := json.Marshal(schema)
+	if err != nil {
+		// Schemas are built from static types, so this is a programming error
+		panic(err)
+	}
+	outputSchemas.Store(t, json.RawMessage(data))
+	return data
+}
+
+// mapSchemaType describes the client's JSON encodings that reflection gets
+// wrong: Nullable wrappers hold a value or null, and arrays marshaled as text,
+// such as UUIDs, are strings
+func mapSchemaType(r *jsonschema.Reflector, t reflect.Type) *jsonschema.Schema {
+	if t.Kind() == reflect.Array && t.Implements(textMarshalerType) {
+		return &jsonschema.Schema{Type: "string"}
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	get, ok := t.MethodByName("Get")
+	if _, isSet := t.MethodByName("IsSet"); !ok || !isSet {
+		return nil
+	}
+	if get.Type.NumOut() != 1 || get.Type.Out(0).Kind() != reflect.Pointer {
+		return nil
+	}
+	value := r.ReflectFromType(get.Type.Out(0).Elem())
+	value.Version = ""
+	return &jsonschema.Schema{AnyOf: []*jsonschema.Schema{value, {Type: "null"}}}
+}
@@ -0,0 +1,192 @@
+package kbcloud_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud/kbcloudtest"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputSchemas(t *testing.T) {
+	tests := []struct {
+		name string
+		tool toolFactory
+		args map[string]any
+	}{
+		{name: "list_organizations", tool: kbcloud.ListOrganizations},
+		{name: "get_organization", tool: kbcloud.GetOrganization, args: map[string]any{"name": "acme"}},
+		{name: "list_environments", tool: kbcloud.ListEnvironments, args: map[string]any{"org_name": "acme"}},
+		{name: "get_environment", tool: kbcloud.GetEnvironment, args: map[string]any{"org_name": "acme", "env_name": "prod"}},
+		{name: "list_instances", tool: kbcloud.ListInstances, args: map[string]any{"org_name": "acme", "env_name": "prod"}},
+		{name: "get_instance", tool: kbcloud.GetInstance, args: map[string]any{"instance_ref": "acme/prod/orders-db"}},
+		{name: "get_instance summary", tool: kbcloud.GetInstance, args: map[string]any{"instance_ref": "acme/prod/orders-db", "view": "summary"}},
+		{name: "find_instance", tool: kbcloud.FindInstance, args: map[string]any{"query": "o"}},
+		{name: "get_connection_info", tool: getConnectionInfo(true), args: map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "app_orders", "include_credentials": true}},
+		{name: "list_backups", tool: kbcloud.ListBackups, args: map[string]any{"instance_ref": "acme/prod/orders-db"}},
+		{name: "get_backup", tool: kbcloud.GetBackup, args: map[string]any{"org_name": "acme", "backup_id": "orders-db-backup-1"}},
+		{name: "list_accounts", tool: kbcloud.ListAccounts, args: map[string]any{"instance_ref": "acme/prod/orders-db"}},
+		{name: "create_account", tool: kbcloud.CreateAccount, args: map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "reporting", "preset": "readonly", "databases": []any{"orders"}}},
+		{name: "list_databases", tool: kbcloud.ListDatabases, args: map[string]any{"instance_ref": "acme/staging/analytics"}},
+		{name: "create_database", tool: kbcloud.CreateDatabase, args: map[string]any{"instance_ref": "acme/prod/orders-db", "database_name": "billing", "charset": "utf8mb4"}},
+		{name: "list_instance_parameters", tool: kbcloud.ListInstanceParameters, args: map[string]any{"instance_ref": "acme/prod/orders-db"}},
+		{name: "diff_parameters_from_default", tool: kbcloud.DiffParametersFromDefault, args: map[string]any{"instance_ref": "acme/staging/analytics"}},
+		{name: "update_instance_parameters", tool: kbcloud.UpdateInstanceParameters, args: map[string]any{"instance_ref": "acme/prod/orders-db", "parameters": map[string]any{"max_connections": 300}}},
+		{name: "list_parameter_templates", tool: kbcloud.ListParameterTemplates, args: map[string]any{"org_name": "acme"}},
+		{name: "get_parameter_template", tool: kbcloud.GetParameterTemplate, args: map[string]any{"org_name": "acme", "template_name": "mysql-oltp"}},
+		{name: "create_parameter_template", tool: kbcloud.CreateParameterTemplate, args: map[string]any{"instance_ref": "acme/prod/orders-db", "template_name": "orders-baseline"}},
+		{name: "apply_parameter_template", tool: kbcloud.ApplyParameterTemplate, args: map[string]any{"template_name": "mysql-oltp", "instances": []any{"acme/prod/orders-db"}}},
+		{name: "list_engines", tool: kbcloud.ListEngines, args: map[string]any{"org_name": "acme", "env_name": "prod"}},
+		{name: "list_engine_versions", tool: kbcloud.ListEngineVersions, args: map[string]any{"org_name": "acme", "env_name": "prod", "engine": "mysql"}},
+		{name: "list_instance_classes", tool: kbcloud.ListInstanceClasses, args: map[string]any{"org_name": "acme", "env_name": "prod", "engine": "mysql"}},
+		{name: "list_storage_classes", tool: kbcloud.ListStorageClasses, args: map[string]any{"org_name": "acme", "env_name": "prod"}},
+		{name: "enable_public_endpoint", tool: kbcloud.EnablePublicEndpoint, args: map[string]any{"instance_ref": "acme/prod/cache"}},
+		{name: "list_allowlist", tool: kbcloud.ListAllowlist, args: map[string]any{"instance_ref": "acme/prod/orders-db"}},
+		{name: "add_allowlist_entries", tool: addAllowlistEntries(false), args: map[string]any{"instance_ref": "acme/prod/orders-db", "entries": []any{"192.0.2.0/24", "203.0.113.9"}}},
+		{name: "remove_allowlist_entries", tool: kbcloud.RemoveAllowlistEntries, args: map[string]any{"instance_ref": "acme/prod/orders-db", "entries": []any{"198.51.100.7"}, "confirm": "orders-db"}},
+		{name: "get_instance_metrics", tool: kbcloud.GetInstanceMetrics, args: map[string]any{"instance_ref": "acme/prod/orders-db"}},
+		{name: "search_instance_logs", tool: kbcloud.SearchInstanceLogs, args: map[string]any{"instance_ref": "acme/prod/orders-db", "log_type": "error", "start": "2024-01-15T08:00:00Z", "end": "2024-01-15T09:00:00Z"}},
+		{name: "list_instance_events", tool: kbcloud.ListInstanceEvents, args: map[string]any{"instance_ref": "acme/prod/cache", "start": "2024-01-15T07:00:00Z", "end": "2024-01-15T09:00:00Z"}},
+		{name: "get_instance_components", tool: kbcloud.GetInstanceComponents, args: map[string]any{"instance_ref": "acme/prod/cache"}},
+		{name: "top_slow_queries", tool: kbcloud.TopSlowQueries, args: map[string]any{"instance_ref": "acme/prod/orders-db", "start": "2024-01-15T08:00:00Z", "end": "2024-01-15T09:00:00Z"}},
+		{name: "grant_account_privileges", tool: kbcloud.GrantAccountPrivileges, args: map[string]any{"instance_ref": "acme/prod/cache", "account_name": "default", "preset": "readonly"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := kbcloudtest.NewServer(t)
+			tool, handler := tc.tool(s.GetClientFn())
+
+			var schema map[string]any
+			require.NoError(t, json.Unmarshal(tool.RawOutputSchema, &schema))
+			assert.Equal(t, "object", schema["type"])
+			assert.NotContains(t, string(tool.RawOutputSchema), `"required"`)
+
+			result, err := callTool(handler, tc.args)
+			require.NoError(t, err)
+			require.False(t, result.IsError, resultText(t, result))
+
+			structured := structuredContent(t, result)
+			assert.NoError(t, conforms(schema, structured))
+			assert.JSONEq(t, resultText(t, result), mustJSON(t, structured))
+		})
+	}
+}
+
+func TestOutputSchemaEncodings(t *testing.T) {
+	t.Run("nullable fields accept null", func(t *testing.T) {
+		tool, _ := kbcloud.GetInstance(nil)
+		var schema map[string]any
+		require.NoError(t, json.Unmarshal(tool.RawOutputSchema, &schema))
+
+		clusterType := schema["properties"].(map[string]any)["clusterType"].(map[string]any)
+		assert.NoError(t, conforms(clusterType, "Normal"))
+		assert.NoError(t, conforms(clusterType, nil))
+		assert.Error(t, conforms(clusterType, 1.0))
+	})
+
+	t.Run("UUIDs are strings", func(t *testing.T) {
+		tool, _ := kbcloud.GetEnvironment(nil)
+		var schema map[string]any
+		require.NoError(t, json.Unmarshal(tool.RawOutputSchema, &schema))
+
+		id := schema["properties"].(map[string]any)["id"].(map[string]any)
+		assert.Equal(t, "string", id["type"])
+	})
+}
+
+func TestStructuredContentWithFormat(t *testing.T) {
+	s := kbcloudtest.NewServer(t)
+	_, handler := kbcloud.GetInstance(s.GetClientFn())
+
+	result, err := callTool(handler, map[string]any{"instance_ref": "acme/prod/orders-db", "fields": "name,status", "format": "yaml"})
+	require.NoError(t, err)
+	assert.Equal(t, "name: orders-db\nstatus: Running\n", resultText(t, result))
+	assert.JSONEq(t, `{"name":"orders-db","status":"Running"}`, mustJSON(t, structuredContent(t, result)))
+}
+
+// structuredContent returns the structured content of a result as decoded JSON
+func structuredContent(t *testing.T, result *mcp.CallToolResult) any {
+	t.Helper()
+	require.NotNil(t, result.StructuredContent)
+	var v any
+	require.NoError(t, json.Unmarshal([]byte(mustJSON(t, result.StructuredContent)), &v))
+	return v
+}
+
+// mustJSON marshals v
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(data)
+}
+
+// conforms checks v against the subset of JSON schema used by output
+// schemas: types, properties, items and anyOf. Properties missing from the
+// schema are reported so schemas cannot silently drift from responses.
+func conforms(schema map[string]any, v any) error {
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		for _, option := range anyOf {
+			if conforms(option.(map[string]any), v) == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("%v matches no schema in anyOf", v)
+	}
+
+	switch schema["type"] {
+	case nil:
+		return nil
+	case "object":
+		object, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%v is not an object", v)
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		for key, value := range object {
+			property, ok := properties[key].(map[string]any)
+			if !ok {
+				if properties[key] == true {
+					continue
+				}
+				return fmt.Errorf("property %q is not in the schema", key)
+			}
+			if err := conforms(property, value); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		}
+	case "array":
+		array, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("%v is not an array", v)
+		}
+		items, _ := schema["items"].(map[string]any)
+		for i, item := range array {
+			if err := conforms(items, item); err != nil {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			return fmt.Errorf("%v is not a string", v)
+		}
+	case "integer", "number":
+		if _, ok := v.(float64); !ok {
+			return fmt.Errorf("%v is not a number", v)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%v is not a boolean", v)
+		}
+	case "null":
+		if v != nil {
+			return fmt.Errorf("%v is not null", v)
+		}
+	}
+	return nil
+}
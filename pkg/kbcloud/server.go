@@ -1,33 +1,197 @@
 package kbcloud
 
 import (
+	"fmt"
+	"net/http"
 	"os"
+	"time"
 
+	mcplog "github.com/apecloud/kb-cloud-mcp-server/pkg/log"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/toolsets"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/tracing"
 	"github.com/apecloud/kb-cloud-mcp-server/pkg/translations"
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
-// NewServer creates a new KB Cloud MCP server
-func NewServer(version string) *server.MCPServer {
+// ServerName is the implementation name reported to MCP clients
+const ServerName = "kb-cloud-mcp-server"
+
+// toolsPageSize is the number of tools per tools/list page. With every
+// toolset enabled the full list is larger than the 64 KiB line limit of some
+// stdio clients, such as mcp-go's.
+const toolsPageSize = 20
+
+// DefaultCatalogCacheTTL is how long catalog lookups are reused unless
+// ServerOptions.CatalogCacheTTL says otherwise
+const DefaultCatalogCacheTTL = time.Hour
+
+// ServerOptions configures a KB Cloud MCP server. The zero value is usable:
+// credentials then come from the request context or environment and every
+// tool is registered.
+type ServerOptions struct {
+	// Version is reported to clients during initialization
+	Version string
+
+	// APIKey and APISecret are the KB Cloud credentials. When either is
+	// empty, credentials are resolved per request from the tool context or
+	// the KB_CLOUD_API_KEY_NAME and KB_CLOUD_API_KEY_SECRET variables.
+	APIKey    string
+	APISecret string
+	// Site is the KB Cloud site URL; when empty it is resolved per request
+	// from the tool context or KB_CLOUD_SITE
+	Site string
+	// GetClient overrides how KB Cloud clients are created, e.g. in tests.
+	// Credentials, Site and the cache TTLs are ignored when it is set.
+	GetClient GetClientFn
+
+	// Logger receives server logs; logs are discarded when nil
+	Logger *mcplog.Logger
+	// Translator overrides tool descriptions. When nil, translations are
+	// read from kb-cloud-mcp-server-config.json and KB_CLOUD_MCP_* variables,
+	// and dumped when KB_CLOUD_MCP_EXPORT_TRANSLATIONS is set.
+	Translator translations.TranslationHelperFunc
+
+	// Toolsets are the toolsets to enable; every toolset is enabled when
+	// empty or when it contains "all"
+	Toolsets []string
+	// EnabledTools restricts the server to the named tools; all tools are
+	// enabled when empty
+	EnabledTools []string
+	// DisabledTools are never registered, even if listed in EnabledTools
+	DisabledTools []string
+	// ReadOnly registers only tools annotated as read-only
+	ReadOnly bool
+	// DynamicToolsets starts the server with only discover_toolsets and
+	// enable_toolset; Toolsets is ignored and agents enable toolsets as needed
+	DynamicToolsets bool
+
+	// DefaultOrg and DefaultEnv are the organization and environment tools
+	// use when org_name or env_name is omitted, until a session picks its own
+	// with set_context. DefaultEnv only applies within DefaultOrg.
+	DefaultOrg string
+	DefaultEnv string
+
+	// DefaultFormat is the output format of tools whose call omits format:
+	// json, yaml, markdown or csv. Defaults to json.
+	DefaultFormat string
+
+	// CacheTTL is how long successful KB Cloud GET responses are reused;
+	// caching is disabled when zero
+	CacheTTL time.Duration
+	// CatalogCacheTTL is how long engine, version and class lookups are
+	// reused. They change with KB Cloud releases rather than with user
+	// actions, so they are cached for DefaultCatalogCacheTTL when zero;
+	// caching is disabled when negative.
+	CatalogCacheTTL time.Duration
+
+	// AllowOpenAllowlist lets add_allowlist_entries add the entries allowing
+	// every address, 0.0.0.0/0 and ::/0
+	AllowOpenAllowlist bool
+
+	// contexts holds the per-session context; set by withDefaults
+	contexts *ContextStore
+}
+
+// NewServer creates a KB Cloud MCP server. Every transport builds its server
+// here so that behavior never diverges between entrypoints.
+func NewServer(opts ServerOptions) (*server.MCPServer, error) {
 	// Initialize translation helper
-	t, dumpTranslations := translations.TranslationHelper()
+	var dumpTranslations func()
+	if opts.Translator == nil {
+		opts.Translator, dumpTranslations = translations.TranslationHelper()
+	}
+
+	if opts.DefaultFormat != "" {
+		if err := ValidateFormat(opts.DefaultFormat); err != nil {
+			return nil, fmt.Errorf("invalid default format: %w", err)
+		}
+	}
 
 	// Create a new MCP server
 	s := server.NewMCPServer(
-		"kb-cloud-mcp-server",
-		version,
+		ServerName,
+		opts.Version,
 		server.WithLogging(),
+		server.WithToolCapabilities(true),
+		server.WithPaginationLimit(toolsPageSize),
+		server.WithHooks(tracing.Hooks()),
 	)
 
 	// Register KB Cloud tools
-	getClientFn := GetDefaultClientFn()
-	RegisterTools(s, getClientFn, t)
+	if err := RegisterTools(s, opts); err != nil {
+		return nil, err
+	}
 
 	// Export translations if requested
 	// Get environment variable using os.LookupEnv directly to avoid conflict
-	if val, exists := os.LookupEnv("KB_CLOUD_MCP_EXPORT_TRANSLATIONS"); exists && (val == "true" || val == "1") {
+	if val, exists := os.LookupEnv("KB_CLOUD_MCP_EXPORT_TRANSLATIONS"); exists && (val == "true" || val == "1") && dumpTranslations != nil {
 		dumpTranslations()
 	}
 
-	return s
+	return s, nil
+}
+
+// withDefaults fills in unset options
+func (o ServerOptions) withDefaults() ServerOptions {
+	if o.Logger == nil {
+		o.Logger = mcplog.Nop()
+	}
+	if o.Translator == nil {
+		o.Translator = translations.NullTranslationHelper
+	}
+	if o.contexts == nil {
+		o.contexts = NewContextStore(o.DefaultOrg, o.DefaultEnv)
+	}
+	if o.DefaultFormat == "" {
+		o.DefaultFormat = FormatJSON
+	}
+	if len(o.Toolsets) == 0 {
+		o.Toolsets = []string{toolsets.All}
+	}
+	if o.CatalogCacheTTL == 0 {
+		o.CatalogCacheTTL = DefaultCatalogCacheTTL
+	}
+	if o.GetClient == nil {
+		var cache *responseCache
+		if o.CacheTTL > 0 {
+			cache = newResponseCache(o.CacheTTL)
+		}
+		var catalog *catalogCache
+		if o.CatalogCacheTTL > 0 {
+			catalog = newCatalogCache(o.CatalogCacheTTL)
+		}
+		factory := newClientFactory(http.DefaultTransport, cache, catalog, o.Logger)
+		if o.APIKey != "" && o.APISecret != "" {
+			o.GetClient = factory.staticClientFn(o.APIKey, o.APISecret, o.Site)
+		} else {
+			o.GetClient = factory.contextClientFn(o.Site)
+		}
+	}
+	return o
+}
+
+// toolEnabled reports whether the options allow registering tool
+func (o ServerOptions) toolEnabled(tool mcp.Tool) bool {
+	if contains(o.DisabledTools, tool.Name) {
+		return false
+	}
+	if len(o.EnabledTools) > 0 && !contains(o.EnabledTools, tool.Name) {
+		return false
+	}
+	if o.ReadOnly {
+		readOnly := tool.Annotations.ReadOnlyHint
+		return readOnly != nil && *readOnly
+	}
+	return true
+}
+
+// contains reports whether names includes name
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
 }
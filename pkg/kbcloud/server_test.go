@@ -0,0 +1,304 @@
+package kbcloud_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud/kbcloudtest"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/translations"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toolNames returns the sorted names of the tools registered on s
+func toolNames(s *server.MCPServer) []string {
+	var names []string
+	for name := range s.ListTools() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestNewServer(t *testing.T) {
+	readTools := []string{
+		"diff_parameters_from_default", "find_instance", "get_backup", "get_connection_info", "get_context",
+		"get_environment", "get_instance", "get_instance_components", "get_instance_metrics",
+		"get_organization", "get_parameter_template", "list_accounts", "list_allowlist", "list_backups",
+		"list_databases", "list_engine_versions", "list_engines", "list_environments", "list_instance_classes",
+		"list_instance_events", "list_instance_parameters", "list_instances", "list_organizations",
+		"list_parameter_templates", "list_storage_classes", "list_toolsets", "search_instance_logs",
+		"set_context", "top_slow_queries",
+	}
+	allTools := []string{
+		"add_allowlist_entries", "apply_parameter_template", "create_account", "create_database",
+		"create_parameter_template", "delete_account", "diff_parameters_from_default",
+		"disable_public_endpoint", "drop_database", "enable_public_endpoint", "find_instance", "get_backup",
+		"get_connection_info", "get_context", "get_environment", "get_instance", "get_instance_components",
+		"get_instance_metrics", "get_organization", "get_parameter_template", "grant_account_privileges",
+		"list_accounts", "list_allowlist", "list_backups", "list_databases", "list_engine_versions",
+		"list_engines", "list_environments", "list_instance_classes", "list_instance_events",
+		"list_instance_parameters", "list_instances", "list_organizations", "list_parameter_templates",
+		"list_storage_classes", "list_toolsets", "remove_allowlist_entries", "reset_account_password",
+		"search_instance_logs", "set_context", "top_slow_queries", "update_instance_parameters",
+	}
+
+	tests := []struct {
+		name string
+		opts kbcloud.ServerOptions
+		want []string
+	}{
+		{
+			name: "all tools by default",
+			want: allTools,
+		},
+		{
+			name: "read-only keeps read tools",
+			opts: kbcloud.ServerOptions{ReadOnly: true},
+			want: readTools,
+		},
+		{
+			name: "enabled tools",
+			opts: kbcloud.ServerOptions{EnabledTools: []string{"list_organizations", "get_organization"}},
+			want: []string{"get_organization", "list_organizations"},
+		},
+		{
+			name: "selected toolsets",
+			opts: kbcloud.ServerOptions{Toolsets: []string{kbcloud.ToolsetOrganizations, kbcloud.ToolsetBackups}},
+			want: []string{"get_backup", "get_organization", "list_backups", "list_organizations", "list_toolsets"},
+		},
+		{
+			name: "disabled tools within toolsets",
+			opts: kbcloud.ServerOptions{Toolsets: []string{kbcloud.ToolsetInstances}, DisabledTools: []string{"get_instance"}},
+			want: []string{"find_instance", "get_connection_info", "list_instances", "list_toolsets"},
+		},
+		{
+			name: "disabled tools win over enabled tools",
+			opts: kbcloud.ServerOptions{
+				EnabledTools:  []string{"list_organizations", "get_organization"},
+				DisabledTools: []string{"get_organization"},
+			},
+			want: []string{"list_organizations"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.opts.Translator = translations.NullTranslationHelper
+			s, err := kbcloud.NewServer(tc.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, toolNames(s))
+		})
+	}
+
+	t.Run("unknown toolset", func(t *testing.T) {
+		_, err := kbcloud.NewServer(kbcloud.ServerOptions{
+			Toolsets:   []string{"billing"},
+			Translator: translations.NullTranslationHelper,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `toolset "billing" does not exist`)
+	})
+
+	t.Run("invalid default format", func(t *testing.T) {
+		_, err := kbcloud.NewServer(kbcloud.ServerOptions{
+			DefaultFormat: "xml",
+			Translator:    translations.NullTranslationHelper,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid format "xml"`)
+	})
+}
+
+func TestServerOptionsDefaultFormat(t *testing.T) {
+	s := kbcloudtest.NewServer(t)
+	mcpServer, err := kbcloud.NewServer(kbcloud.ServerOptions{
+		APIKey:        kbcloudtest.APIKey,
+		APISecret:     kbcloudtest.APISecret,
+		Site:          s.URL,
+		DefaultFormat: kbcloud.FormatYAML,
+		Translator:    translations.NullTranslationHelper,
+	})
+	require.NoError(t, err)
+
+	tool := mcpServer.GetTool("get_organization")
+	require.NotNil(t, tool)
+
+	t.Run("default applies", func(t *testing.T) {
+		result, err := callTool(tool.Handler, map[string]any{"name": "acme"})
+		require.NoError(t, err)
+		assert.Contains(t, resultText(t, result), "name: acme\n")
+	})
+
+	t.Run("argument wins", func(t *testing.T) {
+		result, err := callTool(tool.Handler, map[string]any{"name": "acme", "format": "json"})
+		require.NoError(t, err)
+		assert.Contains(t, resultText(t, result), `"name":"acme"`)
+	})
+
+	t.Run("credentials stay json", func(t *testing.T) {
+		tool := mcpServer.GetTool("get_connection_info")
+		require.NotNil(t, tool)
+		result, err := callTool(tool.Handler, map[string]any{"instance_ref": "acme/prod/orders-db", "account_name": "app_orders", "include_credentials": true})
+		require.NoError(t, err)
+		assert.Contains(t, resultText(t, result), `"password":"app_orders-password"`)
+	})
+}
+
+func TestListToolsets(t *testing.T) {
+	s, err := kbcloud.NewServer(kbcloud.ServerOptions{
+		Toolsets:      []string{kbcloud.ToolsetInstances},
+		DisabledTools: []string{"get_instance"},
+		Translator:    translations.NullTranslationHelper,
+	})
+	require.NoError(t, err)
+
+	result, err := callTool(s.GetTool("list_toolsets").Handler, nil)
+	require.NoError(t, err)
+
+	type toolset struct {
+		Name    string   `json:"name"`
+		Enabled bool     `json:"enabled"`
+		Tools   []string `json:"tools"`
+	}
+	got := map[string]toolset{}
+	for _, ts := range decode[[]toolset](t, resultText(t, result)) {
+		got[ts.Name] = ts
+	}
+
+	assert.Equal(t, toolset{Name: kbcloud.ToolsetInstances, Enabled: true, Tools: []string{"list_instances", "find_instance", "get_connection_info"}}, got[kbcloud.ToolsetInstances])
+	assert.Equal(t, toolset{Name: kbcloud.ToolsetBackups, Enabled: false, Tools: []string{"list_backups", "get_backup"}}, got[kbcloud.ToolsetBackups])
+	assert.Len(t, got, len(kbcloud.ToolsetNames()))
+	for _, name := range kbcloud.ToolsetNames() {
+		assert.Contains(t, got, name)
+	}
+}
+
+func TestDynamicToolsets(t *testing.T) {
+	kb := kbcloudtest.NewServer(t)
+	s, err := kbcloud.NewServer(kbcloud.ServerOptions{
+		GetClient:       kb.GetClientFn(),
+		DynamicToolsets: true,
+		DisabledTools:   []string{"get_backup"},
+		Translator:      translations.NullTranslationHelper,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"discover_toolsets", "enable_toolset"}, toolNames(s))
+
+	enable := s.GetTool("enable_toolset").Handler
+
+	t.Run("Discover toolsets", func(t *testing.T) {
+		result, err := callTool(s.GetTool("discover_toolsets").Handler, nil)
+		require.NoError(t, err)
+
+		type toolset struct {
+			Name    string `json:"name"`
+			Enabled bool   `json:"enabled"`
+		}
+		var names []string
+		for _, ts := range decode[[]toolset](t, resultText(t, result)) {
+			assert.False(t, ts.Enabled, ts.Name)
+			names = append(names, ts.Name)
+		}
+		assert.Equal(t, []string{kbcloud.ToolsetOrganizations, kbcloud.ToolsetEnvironments, kbcloud.ToolsetInstances, kbcloud.ToolsetBackups, kbcloud.ToolsetAccounts, kbcloud.ToolsetDatabases, kbcloud.ToolsetParameters, kbcloud.ToolsetCatalog, kbcloud.ToolsetNetwork, kbcloud.ToolsetMonitoring, kbcloud.ToolsetContext}, names)
+	})
+
+	t.Run("Enable toolset", func(t *testing.T) {
+		result, err := callTool(enable, map[string]any{"toolset": kbcloud.ToolsetBackups})
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+		assert.Contains(t, resultText(t, result), "list_backups")
+		assert.Equal(t, []string{"discover_toolsets", "enable_toolset", "list_backups"}, toolNames(s))
+
+		result, err = callTool(s.GetTool("list_backups").Handler, map[string]any{"org_name": "acme", "env_name": "prod", "instance_name": "orders-db"})
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+	})
+
+	t.Run("Enable toolset twice", func(t *testing.T) {
+		result, err := callTool(enable, map[string]any{"toolset": kbcloud.ToolsetBackups})
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+		assert.Contains(t, resultText(t, result), "already enabled")
+	})
+
+	t.Run("Unknown toolset", func(t *testing.T) {
+		result, err := callTool(enable, map[string]any{"toolset": "billing"})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, resultText(t, result), `toolset "billing" does not exist`)
+	})
+
+	t.Run("Missing toolset", func(t *testing.T) {
+		result, err := callTool(enable, nil)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
+
+func TestServerOptionsCredentials(t *testing.T) {
+	s := kbcloudtest.NewServer(t)
+	mcpServer, err := kbcloud.NewServer(kbcloud.ServerOptions{
+		APIKey:     kbcloudtest.APIKey,
+		APISecret:  kbcloudtest.APISecret,
+		Site:       s.URL,
+		Translator: translations.NullTranslationHelper,
+	})
+	require.NoError(t, err)
+
+	tool := mcpServer.GetTool("get_organization")
+	require.NotNil(t, tool)
+
+	result, err := callTool(tool.Handler, map[string]any{"name": "acme"})
+	require.NoError(t, err)
+	assert.Contains(t, resultText(t, result), `"name":"acme"`)
+}
+
+func TestServerOptionsCache(t *testing.T) {
+	s := kbcloudtest.NewServer(t)
+	mcpServer, err := kbcloud.NewServer(kbcloud.ServerOptions{
+		APIKey:     kbcloudtest.APIKey,
+		APISecret:  kbcloudtest.APISecret,
+		Site:       s.URL,
+		Translator: translations.NullTranslationHelper,
+		CacheTTL:   time.Minute,
+	})
+	require.NoError(t, err)
+	handler := mcpServer.GetTool("get_organization").Handler
+
+	for i := 0; i < 3; i++ {
+		result, err := handler(context.Background(), callRequest(map[string]any{"name": "acme"}))
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+	}
+	assert.Len(t, s.Requests(), 1, "repeated lookups should be served from the cache")
+
+	// Errors are never cached
+	for i := 0; i < 2; i++ {
+		_, err := handler(context.Background(), callRequest(map[string]any{"name": "initech"}))
+		require.Error(t, err)
+	}
+	assert.Len(t, s.Requests(), 3)
+
+	// Changes drop cached responses, so reads see them
+	listAccounts := mcpServer.GetTool("list_accounts").Handler
+	list := func() string {
+		result, err := listAccounts(context.Background(), callRequest(map[string]any{"instance_ref": "acme/prod/cache", "fields": "name"}))
+		require.NoError(t, err)
+		return resultText(t, result)
+	}
+	assert.JSONEq(t, `{"items":[{"name":"default"}],"totalSize":1}`, list())
+
+	result, err := mcpServer.GetTool("create_account").Handler(context.Background(), callRequest(map[string]any{
+		"instance_ref": "acme/prod/cache",
+		"account_name": "app",
+		"preset":       "readonly",
+	}))
+	require.NoError(t, err)
+	require.False(t, result.IsError, resultText(t, result))
+	assert.JSONEq(t, `{"items":[{"name":"app"},{"name":"default"}],"totalSize":2}`, list())
+}
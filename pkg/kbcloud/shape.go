@@ -0,0 +1,315 @@
+package kbcloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Views of a resource
+const (
+	ViewFull    = "full"
+	ViewSummary = "summary"
+)
+
+// Resource types with a summary projection
+const (
+	resourceOrganization      = "organization"
+	resourceEnvironment       = "environment"
+	resourceInstance          = "instance"
+	resourceBackup            = "backup"
+	resourceAccount           = "account"
+	resourceDatabase          = "database"
+	resourceParameter         = "parameter"
+	resourceParameterTemplate = "parameter_template"
+	resourceEngine            = "engine"
+	resourceEngineVersion     = "engine_version"
+	resourceInstanceClass     = "instance_class"
+	resourceStorageClass      = "storage_class"
+	resourceConnection        = "connection"
+	resourceAllowlist         = "allowlist"
+	resourceInstanceMetrics   = "instance_metrics"
+	resourceInstanceLogs      = "instance_logs"
+	resourceSlowQuery         = "slow_query"
+	resourceInstanceEvent     = "instance_event"
+	resourceInstanceComponent = "instance_component"
+)
+
+// summaryFields are the fields kept by the summary view of each resource
+// type, as dot-paths. Paths missing from a resource are skipped, so list
+// items and full objects share a projection.
+var summaryFields = map[string][]string{
+	resourceOrganization: {"name", "displayName", "description", "role", "createdAt"},
+	resourceEnvironment:  {"name", "displayName", "provider", "region", "type", "state", "createdAt"},
+	resourceInstance: {
+		"name", "displayName", "environmentName", "engine", "version", "mode", "status",
+		"components.component", "components.replicas", "createdAt",
+	},
+	resourceBackup: {
+		"id", "name", "sourceCluster", "backupType", "backupMethod", "status",
+		"totalSize", "creationTimestamp", "completionTimestamp",
+	},
+	resourceAccount:   {"name", "role", "component", "privilegesList.databaseName", "privilegesList.privileges"},
+	resourceDatabase:  {"name"},
+	resourceParameter: {"name", "value", "default", "modified", "restart_required"},
+	resourceParameterTemplate: {
+		"name", "family", "partition", "description", "parameters.name", "parameters.value",
+	},
+	resourceEngine:        {"name", "type", "status"},
+	resourceEngineVersion: {"major", "default", "default_minor"},
+	resourceInstanceClass: {"code", "cpu", "memory_gib"},
+	resourceStorageClass:  {"name", "default", "allow_volume_expansion"},
+	resourceConnection: {
+		"instance_ref", "engine", "status", "connection_strings.client", "connection_strings.network",
+		"connection_strings.value", "notices",
+	},
+	resourceAllowlist: {"instance_ref", "entries"},
+	resourceInstanceMetrics: {
+		"instance_ref", "component", "start", "end", "metrics.name", "metrics.unit", "metrics.summary", "notices",
+	},
+	resourceInstanceLogs: {
+		"instance_ref", "log_type", "truncated", "entries.time", "entries.pod", "entries.message", "notices",
+	},
+	resourceSlowQuery: {
+		"instance_ref", "order_by", "queries.fingerprint", "queries.count", "queries.total_seconds",
+		"queries.avg_seconds", "queries.max_seconds", "notices",
+	},
+	resourceInstanceEvent: {
+		"instance_ref", "status", "warnings", "truncated", "events.type", "events.reason", "events.count",
+		"events.last_seen", "notices",
+	},
+	resourceInstanceComponent: {
+		"instance_ref", "status", "components.name", "components.replicas", "components.ready",
+		"components.primary", "notices",
+	},
+}
+
+// indexPattern matches JSONPath array indexes such as [0]
+var indexPattern = regexp.MustCompile(`\[(\d+)\]`)
+
+// WithResponseShaping adds the fields, view and format parameters to a tool
+func WithResponseShaping() mcp.ToolOption {
+	return func(tool *mcp.Tool) {
+		mcp.WithArray("fields",
+			mcp.Description("Only return these fields, as dot-paths such as name, status or components.replicas. For lists the paths apply to each item. Overrides view"),
+			mcp.WithStringItems(),
+		)(tool)
+
+		mcp.WithString("view",
+			mcp.Description("summary returns the most useful fields only, full returns the whole object (default full)"),
+			mcp.Enum(ViewSummary, ViewFull),
+		)(tool)
+
+		WithOutputFormat()(tool)
+	}
+}
+
+// ResponseShaping selects the parts of a response returned to the client
+type ResponseShaping struct {
+	View   string
+	Fields []string
+	Format string
+}
+
+// ResponseShapingParams extracts the fields, view and format parameters from
+// a request
+func ResponseShapingParams(ctx context.Context, r mcp.CallToolRequest) (ResponseShaping, error) {
+	format, err := OutputFormatParam(ctx, r)
+	if err != nil {
+		return ResponseShaping{}, err
+	}
+
+	view, err := OptionalParam[string](r, "view")
+	if err != nil {
+		return ResponseShaping{}, err
+	}
+	switch view {
+	case "":
+		view = ViewFull
+	case ViewFull, ViewSummary:
+	default:
+		return ResponseShaping{}, fmt.Errorf("invalid view %q: must be %s or %s", view, ViewSummary, ViewFull)
+	}
+
+	// Accept a comma-separated string as well as an array
+	var fields []string
+	if s, ok := r.GetArguments()["fields"].(string); ok {
+		fields = strings.Split(s, ",")
+	} else if fields, err = OptionalStringArrayParam(r, "fields"); err != nil {
+		return ResponseShaping{}, err
+	}
+
+	shaping := ResponseShaping{View: view, Format: format}
+	for _, field := range fields {
+		path, err := normalizeFieldPath(field)
+		if err != nil {
+			return ResponseShaping{}, err
+		}
+		if path != "" {
+			shaping.Fields = append(shaping.Fields, path)
+		}
+	}
+	return shaping, nil
+}
+
+// normalizeFieldPath turns a dot-path or simple JSONPath such as
+// $.components[0].name into the dot-path components.0.name
+func normalizeFieldPath(field string) (string, error) {
+	path := strings.TrimSpace(field)
+	path = strings.TrimPrefix(path, "$")
+	path = indexPattern.ReplaceAllString(path, ".$1")
+	path = strings.TrimPrefix(path, ".")
+	if path == "" {
+		return "", nil
+	}
+	for _, segment := range strings.Split(path, ".") {
+		if segment == "" {
+			return "", fmt.Errorf("invalid field %q: expected a dot-path such as components.replicas", field)
+		}
+	}
+	return path, nil
+}
+
+// result renders v, a resource or a list of resources of the given type,
+// as a tool result
+func (s ResponseShaping) result(resource string, v any) (*mcp.CallToolResult, error) {
+	paths := s.Fields
+	if len(paths) == 0 && s.View == ViewSummary {
+		paths = summaryFields[resource]
+	}
+
+	return renderResult(s.Format, v, paths)
+}
+
+// renderResult renders v as a tool result in format, keeping only paths when
+// set. The same data is returned as structured content. JSON without a
+// projection is marshaled directly.
+func renderResult(format string, v any, paths []string) (*mcp.CallToolResult, error) {
+	if (format == "" || format == FormatJSON) && len(paths) == 0 {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		return mcp.NewToolResultStructured(v, string(data)), nil
+	}
+
+	doc, err := toJSONValue(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response: %w", err)
+	}
+	if len(paths) > 0 {
+		doc = projectJSON(doc, paths)
+	}
+	data, err := render(format, doc, paths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render response as %s: %w", format, err)
+	}
+	return mcp.NewToolResultStructured(doc, string(data)), nil
+}
+
+// toJSONValue converts v to the generic value it marshals to
+func toJSONValue(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// projectJSON keeps only paths of doc. Lists, objects with an items array,
+// keep their other top-level fields and apply paths to each item.
+func projectJSON(doc any, paths []string) any {
+	split := make([][]string, 0, len(paths))
+	for _, path := range paths {
+		split = append(split, strings.Split(path, "."))
+	}
+
+	if list, ok := doc.(map[string]any); ok {
+		if items, ok := list["items"].([]any); ok {
+			list["items"] = project(items, split)
+			return list
+		}
+	}
+	return project(doc, split)
+}
+
+// project returns the parts of node selected by paths. Arrays are projected
+// element-wise unless a path segment is an index.
+func project(node any, paths [][]string) any {
+	switch n := node.(type) {
+	case map[string]any:
+		out := map[string]any{}
+		children := map[string][][]string{}
+		for _, path := range paths {
+			value, ok := n[path[0]]
+			if !ok {
+				continue
+			}
+			if len(path) == 1 {
+				out[path[0]] = value
+				continue
+			}
+			children[path[0]] = append(children[path[0]], path[1:])
+		}
+		for key, rest := range children {
+			if _, whole := out[key]; whole {
+				continue
+			}
+			if value := project(n[key], rest); value != nil {
+				out[key] = value
+			}
+		}
+		return out
+
+	case []any:
+		// Indexed paths select elements, the others apply to every element
+		var all [][]string
+		indexed := map[int][][]string{}
+		for _, path := range paths {
+			if i, err := strconv.Atoi(path[0]); err == nil {
+				if i >= 0 && i < len(n) {
+					indexed[i] = append(indexed[i], path[1:])
+				}
+				continue
+			}
+			all = append(all, path)
+		}
+
+		out := make([]any, 0, len(n))
+		for i, element := range n {
+			selected, ok := indexed[i]
+			if len(all) == 0 && !ok {
+				continue
+			}
+			if selectsWhole(selected) {
+				out = append(out, element)
+				continue
+			}
+			out = append(out, project(element, append(append([][]string{}, all...), selected...)))
+		}
+		return out
+
+	default:
+		// Scalars have no fields to select
+		return nil
+	}
+}
+
+// selectsWhole reports whether paths select a whole element
+func selectsWhole(paths [][]string) bool {
+	for _, path := range paths {
+		if len(path) == 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package kbcloud_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/kbcloud"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseShapingParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    map[string]any
+		want    kbcloud.ResponseShaping
+		wantErr string
+	}{
+		{name: "defaults", want: kbcloud.ResponseShaping{View: kbcloud.ViewFull, Format: kbcloud.FormatJSON}},
+		{name: "summary", args: map[string]any{"view": "summary"}, want: kbcloud.ResponseShaping{View: kbcloud.ViewSummary, Format: kbcloud.FormatJSON}},
+		{
+			name: "field array",
+			args: map[string]any{"fields": []any{"name", "components.replicas"}},
+			want: kbcloud.ResponseShaping{View: kbcloud.ViewFull, Format: kbcloud.FormatJSON, Fields: []string{"name", "components.replicas"}},
+		},
+		{
+			name: "comma-separated fields",
+			args: map[string]any{"fields": "name, status"},
+			want: kbcloud.ResponseShaping{View: kbcloud.ViewFull, Format: kbcloud.FormatJSON, Fields: []string{"name", "status"}},
+		},
+		{
+			name: "JSONPath fields",
+			args: map[string]any{"fields": []any{"$.components[0].name", "$.status"}},
+			want: kbcloud.ResponseShaping{View: kbcloud.ViewFull, Format: kbcloud.FormatJSON, Fields: []string{"components.0.name", "status"}},
+		},
+		{
+			name: "format",
+			args: map[string]any{"format": "markdown"},
+			want: kbcloud.ResponseShaping{View: kbcloud.ViewFull, Format: kbcloud.FormatMarkdown},
+		},
+		{name: "invalid format", args: map[string]any{"format": "xml"}, wantErr: `invalid format "xml"`},
+		{name: "invalid view", args: map[string]any{"view": "compact"}, wantErr: `invalid view "compact"`},
+		{name: "invalid field", args: map[string]any{"fields": []any{"components..name"}}, wantErr: `invalid field "components..name"`},
+		{name: "invalid field type", args: map[string]any{"fields": float64(1)}, wantErr: "fields"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := kbcloud.ResponseShapingParams(context.Background(), callRequest(tc.args))
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestResponseShaping(t *testing.T) {
+	t.Run("Get instance", func(t *testing.T) {
+		runToolTests(t, kbcloud.GetInstance, []toolTest{
+			{
+				name: "summary view",
+				args: map[string]any{"instance_ref": "acme/prod/orders-db", "view": "summary"},
+				check: func(t *testing.T, text string) {
+					assert.JSONEq(t, `{
+						"name": "orders-db",
+						"environmentName": "prod",
+						"engine": "mysql",
+						"version": "8.0.33",
+						"mode": "standalone",
+						"status": "Running",
+						"components": [{"component": "mysql", "replicas": 1}],
+						"createdAt": "2024-01-15T08:00:00Z"
+					}`, text)
+				},
+			},
+			{
+				name: "selected fields",
+				args: map[string]any{"instance_ref": "acme/prod/orders-db", "fields": []any{"name", "status", "missing.field"}},
+				check: func(t *testing.T, text string) {
+					assert.JSONEq(t, `{"name":"orders-db","status":"Running"}`, text)
+				},
+			},
+			{
+				name: "fields override view",
+				args: map[string]any{"instance_ref": "acme/prod/orders-db", "view": "summary", "fields": "engine"},
+				check: func(t *testing.T, text string) {
+					assert.JSONEq(t, `{"engine":"mysql"}`, text)
+				},
+			},
+			{
+				name:        "invalid view",
+				args:        map[string]any{"instance_ref": "acme/prod/orders-db", "view": "tiny"},
+				wantToolErr: "invalid view",
+			},
+		})
+	})
+
+	t.Run("List backups", func(t *testing.T) {
+		runToolTests(t, kbcloud.ListBackups, []toolTest{
+			{
+				name: "fields apply to each item",
+				args: map[string]any{"instance_ref": "acme/prod/orders-db", "fields": []any{"name", "status"}},
+				check: func(t *testing.T, text string) {
+					assert.JSONEq(t, `{
+						"items": [
+							{"name": "orders-db-backup-1", "status": "Completed"},
+							{"name": "orders-db-backup-2", "status": "Completed"},
+							{"name": "orders-db-backup-3", "status": "Running"}
+						],
+						"pageResult": {"totalSize": 3}
+					}`, text)
+				},
+			},
+			{
+				name: "indexed field",
+				args: map[string]any{"instance_ref": "acme/prod/orders-db", "fields": []any{"$.name", "targetPods[0]"}},
+				check: func(t *testing.T, text string) {
+					assert.Contains(t, text, `{"name":"orders-db-backup-1"}`)
+				},
+			},
+		})
+	})
+
+	t.Run("List organizations", func(t *testing.T) {
+		runToolTests(t, kbcloud.ListOrganizations, []toolTest{
+			{
+				name: "summary view",
+				args: map[string]any{"view": "summary"},
+				check: func(t *testing.T, text string) {
+					assert.Contains(t, text, `{"displayName":"Acme Corp","name":"acme","role":"admin"}`)
+					assert.NotContains(t, text, "orgId")
+				},
+			},
+		})
+	})
+}
@@ -1,37 +1,196 @@
 package kbcloud
 
 import (
-	"github.com/apecloud/kb-cloud-mcp-server/pkg/translations"
+	"context"
+	"fmt"
+	"time"
+
+	mcplog "github.com/apecloud/kb-cloud-mcp-server/pkg/log"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/metrics"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/toolsets"
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/tracing"
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	log "github.com/sirupsen/logrus"
+)
+
+// Toolset names
+const (
+	ToolsetOrganizations = "organizations"
+	ToolsetEnvironments  = "environments"
+	ToolsetInstances     = "instances"
+	ToolsetBackups       = "backups"
+	ToolsetAccounts      = "accounts"
+	ToolsetDatabases     = "databases"
+	ToolsetParameters    = "parameters"
+	ToolsetCatalog       = "catalog"
+	ToolsetNetwork       = "network"
+	ToolsetMonitoring    = "monitoring"
+	ToolsetContext       = "context"
 )
 
-// RegisterTools registers all KB Cloud MCP tools with the MCP server
-func RegisterTools(s *server.MCPServer, getClientFn GetClientFn, t translations.TranslationHelperFunc) {
-	// Organization tools
-	organizationTool, organizationHandler := ListOrganizations(getClientFn)
-	s.AddTool(organizationTool, organizationHandler)
+// RegisterTools registers the KB Cloud MCP tools allowed by opts with the MCP server
+func RegisterTools(s *server.MCPServer, opts ServerOptions) error {
+	opts = opts.withDefaults()
+
+	group := NewToolsetGroup(opts)
 
-	orgDetailTool, orgDetailHandler := GetOrganization(getClientFn)
-	s.AddTool(orgDetailTool, orgDetailHandler)
+	// In dynamic mode toolsets are enabled on request by the agent
+	if opts.DynamicToolsets {
+		discoverTool, discoverHandler := DiscoverToolsets(group)
+		addTool(s, opts, discoverTool, discoverHandler)
+		enableTool, enableHandler := EnableToolset(s, group)
+		addTool(s, opts, enableTool, enableHandler)
+		return nil
+	}
 
-	// Environment tools
-	environmentsTool, environmentsHandler := ListEnvironments(getClientFn)
-	s.AddTool(environmentsTool, environmentsHandler)
+	if err := group.EnableToolsets(opts.Toolsets); err != nil {
+		return err
+	}
+	group.RegisterTools(s)
+
+	// list_toolsets is always available so agents can see what is enabled
+	listTool, listHandler := ListToolsets(group)
+	addTool(s, opts, listTool, listHandler)
+	return nil
+}
+
+// ToolsetNames returns the names of every KB Cloud toolset, in the order
+// list_toolsets reports them
+func ToolsetNames() []string {
+	return NewToolsetGroup(ServerOptions{GetClient: func(context.Context) (*Client, error) {
+		return nil, fmt.Errorf("no KB Cloud client")
+	}}).Names()
+}
 
-	envDetailTool, envDetailHandler := GetEnvironment(getClientFn)
-	s.AddTool(envDetailTool, envDetailHandler)
+// NewToolsetGroup builds every KB Cloud toolset, all disabled, keeping only
+// the tools opts allow
+func NewToolsetGroup(opts ServerOptions) *toolsets.Group {
+	opts = opts.withDefaults()
+	getClientFn := opts.GetClient
+	toolLogger := opts.Logger.Component(mcplog.ComponentKBCloud)
 
-	// Instance tools
-	instancesTool, instancesHandler := ListInstances(getClientFn)
-	s.AddTool(instancesTool, instancesHandler)
+	// Wrap every handler so tool calls see the session context and default
+	// format and are logged, traced, counted and timed
+	newToolset := func(name, description string, tools ...server.ServerTool) *toolsets.Toolset {
+		toolset := toolsets.NewToolset(name, description)
+		for _, t := range tools {
+			if !opts.toolEnabled(t.Tool) {
+				toolLogger.WithField("tool", t.Tool.Name).Debug("Tool disabled by server options")
+				continue
+			}
+			t.Handler = withDefaultFormat(opts.DefaultFormat, opts.contexts.WrapHandler(t.Handler))
+			toolset.AddTools(instrumentTool(toolLogger, t))
+		}
+		return toolset
+	}
 
-	instanceDetailTool, instanceDetailHandler := GetInstance(getClientFn)
-	s.AddTool(instanceDetailTool, instanceDetailHandler)
+	group := toolsets.NewGroup()
+	group.AddToolset(newToolset(ToolsetOrganizations, "Organizations you have access to",
+		serverTool(ListOrganizations(getClientFn)),
+		serverTool(GetOrganization(getClientFn)),
+	))
+	group.AddToolset(newToolset(ToolsetEnvironments, "Environments (cloud regions or Kubernetes clusters) within an organization",
+		serverTool(ListEnvironments(getClientFn)),
+		serverTool(GetEnvironment(getClientFn)),
+	))
+	group.AddToolset(newToolset(ToolsetInstances, "Database instances within an environment",
+		serverTool(ListInstances(getClientFn)),
+		serverTool(GetInstance(getClientFn)),
+		serverTool(FindInstance(getClientFn)),
+		serverTool(GetConnectionInfo(getClientFn, !opts.ReadOnly)),
+	))
+	group.AddToolset(newToolset(ToolsetBackups, "Backups of database instances",
+		serverTool(ListBackups(getClientFn)),
+		serverTool(GetBackup(getClientFn)),
+	))
+	group.AddToolset(newToolset(ToolsetAccounts, "Database accounts of instances",
+		serverTool(ListAccounts(getClientFn)),
+		serverTool(CreateAccount(getClientFn)),
+		serverTool(ResetAccountPassword(getClientFn)),
+		serverTool(GrantAccountPrivileges(getClientFn)),
+		serverTool(DeleteAccount(getClientFn)),
+	))
+	group.AddToolset(newToolset(ToolsetDatabases, "Logical databases of MySQL and PostgreSQL instances",
+		serverTool(ListDatabases(getClientFn)),
+		serverTool(CreateDatabase(getClientFn)),
+		serverTool(DropDatabase(getClientFn)),
+	))
+	group.AddToolset(newToolset(ToolsetParameters, "Engine parameters of instances and parameter templates",
+		serverTool(ListInstanceParameters(getClientFn)),
+		serverTool(DiffParametersFromDefault(getClientFn)),
+		serverTool(UpdateInstanceParameters(getClientFn)),
+		serverTool(ListParameterTemplates(getClientFn)),
+		serverTool(GetParameterTemplate(getClientFn)),
+		serverTool(CreateParameterTemplate(getClientFn)),
+		serverTool(ApplyParameterTemplate(getClientFn)),
+	))
+	group.AddToolset(newToolset(ToolsetCatalog, "Engines, engine versions, instance classes and storage classes available for new instances",
+		serverTool(ListEngines(getClientFn)),
+		serverTool(ListEngineVersions(getClientFn)),
+		serverTool(ListInstanceClasses(getClientFn)),
+		serverTool(ListStorageClasses(getClientFn)),
+	))
+	group.AddToolset(newToolset(ToolsetNetwork, "Public endpoints and IP allowlists of instances",
+		serverTool(EnablePublicEndpoint(getClientFn)),
+		serverTool(DisablePublicEndpoint(getClientFn)),
+		serverTool(ListAllowlist(getClientFn)),
+		serverTool(AddAllowlistEntries(getClientFn, opts.AllowOpenAllowlist)),
+		serverTool(RemoveAllowlistEntries(getClientFn)),
+	))
+	group.AddToolset(newToolset(ToolsetMonitoring, "Metrics, logs, events and pods of instances for diagnosing load and failures",
+		serverTool(GetInstanceMetrics(getClientFn)),
+		serverTool(SearchInstanceLogs(getClientFn)),
+		serverTool(TopSlowQueries(getClientFn)),
+		serverTool(ListInstanceEvents(getClientFn)),
+		serverTool(GetInstanceComponents(getClientFn)),
+	))
+	group.AddToolset(newToolset(ToolsetContext, "Current organization and environment of the session",
+		serverTool(SetContext(getClientFn, opts.contexts)),
+		serverTool(GetContext(opts.contexts)),
+	))
+	return group
+}
+
+// addTool registers a tool outside of any toolset, honoring opts
+func addTool(s *server.MCPServer, opts ServerOptions, tool mcp.Tool, handler server.ToolHandlerFunc) {
+	if !opts.toolEnabled(tool) {
+		return
+	}
+	s.AddTools(instrumentTool(opts.Logger.Component(mcplog.ComponentKBCloud), server.ServerTool{Tool: tool, Handler: handler}))
+}
+
+// serverTool pairs a tool with its handler
+func serverTool(tool mcp.Tool, handler server.ToolHandlerFunc) server.ServerTool {
+	return server.ServerTool{Tool: tool, Handler: handler}
+}
+
+// instrumentTool wraps a tool handler so calls are logged, traced, counted and timed
+func instrumentTool(logger *log.Entry, t server.ServerTool) server.ServerTool {
+	handler := logToolHandler(logger, t.Tool.Name, t.Handler)
+	handler = metrics.InstrumentToolHandler(t.Tool.Name, handler)
+	handler = tracing.InstrumentToolHandler(t.Tool.Name, handler)
+	return server.ServerTool{Tool: t.Tool, Handler: handler}
+}
 
-	// Backup tools
-	backupsTool, backupsHandler := ListBackups(getClientFn)
-	s.AddTool(backupsTool, backupsHandler)
+// logToolHandler wraps a tool handler to log each call with its outcome and duration
+func logToolHandler(logger *log.Entry, name string, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		result, err := next(ctx, request)
 
-	backupDetailTool, backupDetailHandler := GetBackup(getClientFn)
-	s.AddTool(backupDetailTool, backupDetailHandler)
+		entry := logger.WithFields(log.Fields{
+			"tool":     name,
+			"duration": time.Since(start).String(),
+		})
+		switch {
+		case err != nil:
+			entry.WithError(err).Error("Tool call failed")
+		case result != nil && result.IsError:
+			entry.Warn("Tool call returned an error result")
+		default:
+			entry.Debug("Tool call succeeded")
+		}
+		return result, err
+	}
 }
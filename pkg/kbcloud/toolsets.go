@@ -0,0 +1,105 @@
+package kbcloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/apecloud/kb-cloud-mcp-server/pkg/toolsets"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// toolsetInfo describes a toolset to MCP clients
+type toolsetInfo struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Enabled     bool     `json:"enabled"`
+	Tools       []string `json:"tools"`
+}
+
+// describeToolsets returns a description of every toolset in group
+func describeToolsets(group *toolsets.Group) []toolsetInfo {
+	infos := []toolsetInfo{}
+	for _, t := range group.Toolsets() {
+		infos = append(infos, toolsetInfo{
+			Name:        t.Name,
+			Description: t.Description,
+			Enabled:     group.IsEnabled(t.Name),
+			Tools:       t.ToolNames(),
+		})
+	}
+	return infos
+}
+
+// marshalToolsets returns the toolsets of group as a tool result
+func marshalToolsets(group *toolsets.Group) (*mcp.CallToolResult, error) {
+	result, err := json.Marshal(describeToolsets(group))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response: %w", err)
+	}
+	return mcp.NewToolResultText(string(result)), nil
+}
+
+// ListToolsets creates a tool to list the available toolsets and whether they are enabled
+func ListToolsets(group *toolsets.Group) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("list_toolsets",
+			mcp.WithDescription("List the toolsets this server offers, the tools in each and whether they are enabled"),
+			mcp.WithReadOnlyHintAnnotation(true),
+		),
+		func(_ context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return marshalToolsets(group)
+		}
+}
+
+// DiscoverToolsets creates a tool to discover the toolsets that can be enabled
+// in dynamic mode
+func DiscoverToolsets(group *toolsets.Group) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("discover_toolsets",
+			mcp.WithDescription("Discover the toolsets this server offers and the tools in each. Call enable_toolset to start using a toolset that is not enabled yet"),
+			mcp.WithReadOnlyHintAnnotation(true),
+		),
+		func(_ context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return marshalToolsets(group)
+		}
+}
+
+// EnableToolset creates a tool to enable a toolset at runtime. Its tools are
+// added to s, which notifies connected clients that the tool list changed.
+func EnableToolset(s *server.MCPServer, group *toolsets.Group) (tool mcp.Tool, handler server.ToolHandlerFunc) {
+	return mcp.NewTool("enable_toolset",
+			mcp.WithDescription("Enable a toolset so that its tools become available. Use discover_toolsets to see the toolsets this server offers"),
+			mcp.WithString("toolset",
+				mcp.Required(),
+				mcp.Description("Name of the toolset to enable"),
+			),
+			// Enabling a toolset changes which tools are offered, never KB Cloud resources
+			mcp.WithReadOnlyHintAnnotation(true),
+		),
+		func(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			name, err := RequiredParam[string](request, "toolset")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			toolset, err := group.Toolset(name)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			enabled, err := group.EnableToolset(name)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if !enabled {
+				return mcp.NewToolResultText(fmt.Sprintf("Toolset %s is already enabled", name)), nil
+			}
+
+			tools := toolset.Tools()
+			if len(tools) == 0 {
+				return mcp.NewToolResultText(fmt.Sprintf("Enabled toolset %s; all of its tools are disabled by server options", name)), nil
+			}
+			s.AddTools(tools...)
+			return mcp.NewToolResultText(fmt.Sprintf("Enabled toolset %s with tools: %s", name, strings.Join(toolset.ToolNames(), ", "))), nil
+		}
+}
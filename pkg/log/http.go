@@ -0,0 +1,104 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// scrubbedHeaders matches header lines whose values must never be logged
+var scrubbedHeaders = regexp.MustCompile(`(?im)^((?:Proxy-)?Authorization|WWW-Authenticate|Cookie|Set-Cookie|X-Api-Key):.*$`)
+
+// HTTPDebugTransport dumps KB Cloud HTTP requests and responses to a logger
+// with authentication headers scrubbed
+type HTTPDebugTransport struct {
+	next   http.RoundTripper
+	logger *log.Entry
+	level  log.Level
+}
+
+// NewHTTPDebugTransport wraps next so requests and responses are dumped at
+// debug level. When force is set they are dumped at info level instead, so
+// KB_CLOUD_DEBUG works without lowering the log level.
+func NewHTTPDebugTransport(next http.RoundTripper, logger *log.Entry, force bool) *HTTPDebugTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	level := log.DebugLevel
+	if force {
+		level = log.InfoLevel
+	}
+	return &HTTPDebugTransport{next: next, logger: logger, level: level}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *HTTPDebugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.logger.Logger.IsLevelEnabled(t.level) {
+		return t.next.RoundTrip(req)
+	}
+
+	// Headers and bodies are dumped separately, so bodies are redacted as
+	// sent rather than with their transfer encoding
+	if dump, err := httputil.DumpRequestOut(req, false); err == nil {
+		body := readBody(&req.Body)
+		t.logger.WithFields(log.Fields{
+			"direction": "request",
+			"method":    req.Method,
+			"url":       req.URL.Redacted(),
+		}).Log(t.level, ScrubHTTPDump(dump, body))
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		t.logger.WithError(err).WithField("url", req.URL.Redacted()).Log(t.level, "KB Cloud request failed")
+		return resp, err
+	}
+
+	if dump, err := httputil.DumpResponse(resp, false); err == nil {
+		body := readBody(&resp.Body)
+		t.logger.WithFields(log.Fields{
+			"direction": "response",
+			"method":    req.Method,
+			"url":       req.URL.Redacted(),
+			"status":    resp.StatusCode,
+		}).Log(t.level, ScrubHTTPDump(dump, body))
+	}
+	return resp, nil
+}
+
+// readBody reads body and replaces it with a reader returning the same
+// bytes, and the read error if any
+func readBody(body *io.ReadCloser) []byte {
+	if *body == nil || *body == http.NoBody {
+		return nil
+	}
+	data, err := io.ReadAll(*body)
+	_ = (*body).Close()
+	var rest io.Reader = bytes.NewReader(data)
+	if err != nil {
+		rest = io.MultiReader(rest, errReader{err})
+	}
+	*body = io.NopCloser(rest)
+	return data
+}
+
+// ScrubHTTPDump removes authentication header values from the header dump of
+// an HTTP message, and sensitive fields such as passwords from its body. A
+// body that is not JSON is replaced with a placeholder.
+func ScrubHTTPDump(header, body []byte) string {
+	scrubbed := scrubbedHeaders.ReplaceAllString(strings.TrimRight(string(header), "\r\n"), "$1: "+redactedValue)
+	if len(bytes.TrimSpace(body)) == 0 {
+		return scrubbed
+	}
+	return scrubbed + "\r\n\r\n" + string(RedactJSON(body))
+}
+
+// errReader fails every read with err
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
@@ -1,45 +1,272 @@
 package log
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
+	"strings"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
 )
 
+// DefaultMaxPayloadSize is the default number of payload bytes logged per frame
+const DefaultMaxPayloadSize = 2048
+
+// redactedValue replaces the value of sensitive fields in logged frames
+const redactedValue = "[REDACTED]"
+
+// omittedValue replaces data that cannot be parsed for redaction, so it is
+// never logged unredacted
+const omittedValue = "[OMITTED: not valid JSON]"
+
+// defaultRedactedKeys are matched case-insensitively as substrings of JSON object keys
+var defaultRedactedKeys = []string{
+	"password",
+	"secret",
+	"access_token",
+	"accesstoken",
+	"refresh_token",
+	"authorization",
+	"api_key",
+	"apikey",
+	"credential",
+	"private_key",
+}
+
 // IOLogger is a wrapper around io.Reader and io.Writer that can be used
-// to log the data being read and written from the underlying streams
+// to log the data being read and written from the underlying streams.
+// Data is split into newline-delimited JSON-RPC frames, each logged with
+// structured fields; sensitive values are redacted and payloads truncated.
 type IOLogger struct {
 	reader io.Reader
 	writer io.Writer
-	logger *log.Logger
+	logger log.FieldLogger
+
+	maxPayloadSize int
+	redactedKeys   []string
+
+	readMu  sync.Mutex
+	readBuf bytes.Buffer
+
+	writeMu  sync.Mutex
+	writeBuf bytes.Buffer
+}
+
+// IOLoggerOption configures an IOLogger
+type IOLoggerOption func(*IOLogger)
+
+// WithMaxPayloadSize sets the maximum number of payload bytes logged per frame.
+// A value <= 0 disables payload logging entirely.
+func WithMaxPayloadSize(n int) IOLoggerOption {
+	return func(l *IOLogger) {
+		l.maxPayloadSize = n
+	}
+}
+
+// WithRedactedKeys adds JSON object keys whose values are redacted in logged frames
+func WithRedactedKeys(keys ...string) IOLoggerOption {
+	return func(l *IOLogger) {
+		for _, k := range keys {
+			l.redactedKeys = append(l.redactedKeys, strings.ToLower(k))
+		}
+	}
 }
 
 // NewIOLogger creates a new IOLogger instance
-func NewIOLogger(r io.Reader, w io.Writer, logger *log.Logger) *IOLogger {
-	return &IOLogger{
-		reader: r,
-		writer: w,
-		logger: logger,
+func NewIOLogger(r io.Reader, w io.Writer, logger log.FieldLogger, opts ...IOLoggerOption) *IOLogger {
+	l := &IOLogger{
+		reader:         r,
+		writer:         w,
+		logger:         logger,
+		maxPayloadSize: DefaultMaxPayloadSize,
+		redactedKeys:   append([]string{}, defaultRedactedKeys...),
+	}
+	for _, opt := range opts {
+		opt(l)
 	}
+	return l
 }
 
-// Read reads data from the underlying io.Reader and logs it.
+// Read reads data from the underlying io.Reader and logs each complete frame.
 func (l *IOLogger) Read(p []byte) (n int, err error) {
 	if l.reader == nil {
 		return 0, io.EOF
 	}
 	n, err = l.reader.Read(p)
+
+	l.readMu.Lock()
+	defer l.readMu.Unlock()
 	if n > 0 {
-		l.logger.Infof("[stdin]: received %d bytes: %s", n, string(p[:n]))
+		l.readBuf.Write(p[:n])
+		l.logFrames(&l.readBuf, "stdin")
+	}
+	// Log whatever is left of an unterminated frame once the stream ends
+	if err != nil && l.readBuf.Len() > 0 {
+		l.logFrame(l.readBuf.Bytes(), "stdin")
+		l.readBuf.Reset()
 	}
 	return n, err
 }
 
-// Write writes data to the underlying io.Writer and logs it.
+// Write writes data to the underlying io.Writer and logs each complete frame.
 func (l *IOLogger) Write(p []byte) (n int, err error) {
 	if l.writer == nil {
 		return 0, io.ErrClosedPipe
 	}
-	l.logger.Infof("[stdout]: sending %d bytes: %s", len(p), string(p))
+
+	l.writeMu.Lock()
+	l.writeBuf.Write(p)
+	l.logFrames(&l.writeBuf, "stdout")
+	l.writeMu.Unlock()
+
 	return l.writer.Write(p)
 }
+
+// logFrames logs and consumes every newline-terminated frame in buf
+func (l *IOLogger) logFrames(buf *bytes.Buffer, direction string) {
+	for {
+		i := bytes.IndexByte(buf.Bytes(), '\n')
+		if i < 0 {
+			return
+		}
+		l.logFrame(buf.Next(i + 1)[:i], direction)
+	}
+}
+
+// logFrame logs a single frame with structured JSON-RPC fields
+func (l *IOLogger) logFrame(frame []byte, direction string) {
+	frame = bytes.TrimSpace(frame)
+	if len(frame) == 0 {
+		return
+	}
+
+	fields := log.Fields{
+		"direction": direction,
+		"size":      len(frame),
+	}
+
+	var msg struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Error  *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	payload := frame
+	if err := json.Unmarshal(frame, &msg); err == nil {
+		if msg.Method != "" {
+			fields["method"] = msg.Method
+		}
+		if len(msg.ID) > 0 && string(msg.ID) != "null" {
+			fields["id"] = strings.Trim(string(msg.ID), `"`)
+		}
+		if msg.Error != nil {
+			fields["error_code"] = msg.Error.Code
+		}
+		payload = l.redact(frame)
+	} else {
+		fields["invalid_json"] = true
+	}
+
+	if l.maxPayloadSize > 0 {
+		if len(payload) > l.maxPayloadSize {
+			fields["truncated"] = true
+			payload = payload[:l.maxPayloadSize]
+		}
+		fields["payload"] = string(payload)
+	}
+
+	verb := "received"
+	if direction == "stdout" {
+		verb = "sending"
+	}
+	l.logger.WithFields(fields).Infof("[%s]: %s %d bytes", direction, verb, len(frame))
+}
+
+// redact returns frame with the values of sensitive keys replaced
+func (l *IOLogger) redact(frame []byte) []byte {
+	if out, ok := redactJSON(frame, l.redactedKeys); ok {
+		return out
+	}
+	return []byte(omittedValue)
+}
+
+// RedactJSON returns data with the values of sensitive object keys replaced.
+// The default sensitive keys are always redacted; extraKeys adds more. Data
+// that is not a single valid JSON value is replaced with a placeholder.
+func RedactJSON(data []byte, extraKeys ...string) []byte {
+	keys := append([]string{}, defaultRedactedKeys...)
+	for _, k := range extraKeys {
+		keys = append(keys, strings.ToLower(k))
+	}
+	if out, ok := redactJSON(data, keys); ok {
+		return out
+	}
+	return []byte(omittedValue)
+}
+
+// redactJSON replaces the values of keys in a JSON document. It reports
+// false when data is not exactly one JSON value.
+func redactJSON(data []byte, keys []string) ([]byte, bool) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, false
+	}
+	// Trailing data, such as a second value, would be logged unredacted
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, false
+	}
+
+	out, err := json.Marshal(redactValue(v, keys))
+	if err != nil {
+		return []byte(fmt.Sprintf("<unloggable frame: %v>", err)), true
+	}
+	return out, true
+}
+
+// redactValue walks a decoded JSON value and redacts sensitive object keys.
+// Strings holding JSON documents, such as the text of tool results, are
+// redacted too.
+func redactValue(v any, keys []string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if isRedactedKey(k, keys) {
+				v[k] = redactedValue
+				continue
+			}
+			v[k] = redactValue(child, keys)
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = redactValue(child, keys)
+		}
+		return v
+	case string:
+		if trimmed := strings.TrimSpace(v); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+			if out, ok := redactJSON([]byte(v), keys); ok {
+				return string(out)
+			}
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// isRedactedKey reports whether key names a sensitive value
+func isRedactedKey(key string, keys []string) bool {
+	key = strings.ToLower(key)
+	for _, k := range keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,28 +2,48 @@ package log
 
 import (
 	"bytes"
+	"encoding/json"
 	"strings"
 	"testing"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// newTestLogger returns a logger writing JSON entries to buf
+func newTestLogger(buf *bytes.Buffer) *log.Logger {
+	logger := log.New()
+	logger.SetOutput(buf)
+	logger.SetFormatter(&log.JSONFormatter{
+		DisableTimestamp: true,
+	})
+	return logger
+}
+
+// logEntries decodes the JSON log entries written to buf
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
 func TestLoggedReadWriter(t *testing.T) {
 	t.Run("Read method logs and passes data", func(t *testing.T) {
 		// Setup
-		inputData := "test input data"
+		inputData := `{"jsonrpc":"2.0","id":1,"method":"tools/list"}` + "\n"
 		reader := strings.NewReader(inputData)
 
 		// Create logger with buffer to capture output
 		var logBuffer bytes.Buffer
-		logger := log.New()
-		logger.SetOutput(&logBuffer)
-		logger.SetFormatter(&log.TextFormatter{
-			DisableTimestamp: true,
-		})
-
-		lrw := NewIOLogger(reader, nil, logger)
+		lrw := NewIOLogger(reader, nil, newTestLogger(&logBuffer))
 
 		// Test Read
 		buf := make([]byte, 100)
@@ -33,24 +53,24 @@ func TestLoggedReadWriter(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, len(inputData), n)
 		assert.Equal(t, inputData, string(buf[:n]))
-		assert.Contains(t, logBuffer.String(), "[stdin]")
-		assert.Contains(t, logBuffer.String(), inputData)
+
+		entries := logEntries(t, &logBuffer)
+		require.Len(t, entries, 1)
+		assert.Contains(t, entries[0]["msg"], "[stdin]")
+		assert.Equal(t, "stdin", entries[0]["direction"])
+		assert.Equal(t, "tools/list", entries[0]["method"])
+		assert.Equal(t, "1", entries[0]["id"])
+		assert.Equal(t, float64(len(inputData)-1), entries[0]["size"])
 	})
 
 	t.Run("Write method logs and passes data", func(t *testing.T) {
 		// Setup
-		outputData := "test output data"
+		outputData := `{"jsonrpc":"2.0","id":"abc","result":{}}` + "\n"
 		var writeBuffer bytes.Buffer
 
 		// Create logger with buffer to capture output
 		var logBuffer bytes.Buffer
-		logger := log.New()
-		logger.SetOutput(&logBuffer)
-		logger.SetFormatter(&log.TextFormatter{
-			DisableTimestamp: true,
-		})
-
-		lrw := NewIOLogger(nil, &writeBuffer, logger)
+		lrw := NewIOLogger(nil, &writeBuffer, newTestLogger(&logBuffer))
 
 		// Test Write
 		n, err := lrw.Write([]byte(outputData))
@@ -59,7 +79,76 @@ func TestLoggedReadWriter(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, len(outputData), n)
 		assert.Equal(t, outputData, writeBuffer.String())
-		assert.Contains(t, logBuffer.String(), "[stdout]")
-		assert.Contains(t, logBuffer.String(), outputData)
+
+		entries := logEntries(t, &logBuffer)
+		require.Len(t, entries, 1)
+		assert.Contains(t, entries[0]["msg"], "[stdout]")
+		assert.Equal(t, "stdout", entries[0]["direction"])
+		assert.Equal(t, "abc", entries[0]["id"])
+		assert.NotContains(t, entries[0], "method")
+	})
+
+	t.Run("Frames split across reads are logged once complete", func(t *testing.T) {
+		var logBuffer bytes.Buffer
+		pr := strings.NewReader(`{"jsonrpc":"2.0","method":"notifications/initialized"}` + "\n" + `{"jsonrpc":"2.0","id":2,`)
+		lrw := NewIOLogger(pr, nil, newTestLogger(&logBuffer))
+
+		buf := make([]byte, 10)
+		for {
+			if _, err := lrw.Read(buf); err != nil {
+				break
+			}
+		}
+
+		entries := logEntries(t, &logBuffer)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "notifications/initialized", entries[0]["method"])
+		assert.Equal(t, true, entries[1]["invalid_json"])
 	})
+
+	t.Run("Credentials are redacted", func(t *testing.T) {
+		var logBuffer bytes.Buffer
+		lrw := NewIOLogger(nil, &bytes.Buffer{}, newTestLogger(&logBuffer), WithRedactedKeys("connection_string"))
+
+		frame := `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"create_account","arguments":{"account_name":"app","password":"hunter2","connection_string":"mysql://app:hunter2@db"}}}` + "\n"
+		_, err := lrw.Write([]byte(frame))
+		require.NoError(t, err)
+
+		entries := logEntries(t, &logBuffer)
+		require.Len(t, entries, 1)
+		payload := entries[0]["payload"].(string)
+		assert.NotContains(t, payload, "hunter2")
+		assert.Contains(t, payload, `"account_name":"app"`)
+		assert.Contains(t, payload, redactedValue)
+	})
+
+	t.Run("Large payloads are truncated", func(t *testing.T) {
+		var logBuffer bytes.Buffer
+		lrw := NewIOLogger(nil, &bytes.Buffer{}, newTestLogger(&logBuffer), WithMaxPayloadSize(16))
+
+		frame := `{"jsonrpc":"2.0","id":4,"result":{"content":[{"type":"text","text":"` + strings.Repeat("x", 100) + `"}]}}` + "\n"
+		_, err := lrw.Write([]byte(frame))
+		require.NoError(t, err)
+
+		entries := logEntries(t, &logBuffer)
+		require.Len(t, entries, 1)
+		assert.Len(t, entries[0]["payload"], 16)
+		assert.Equal(t, true, entries[0]["truncated"])
+		assert.Equal(t, float64(len(frame)-1), entries[0]["size"])
+	})
+}
+
+func TestRedactJSON(t *testing.T) {
+	out := RedactJSON([]byte(`{"user":"app","Password":"hunter2","nested":[{"token":"abc","apiKey":"k"}]}`), "token")
+	assert.JSONEq(t, `{"user":"app","Password":"[REDACTED]","nested":[{"token":"[REDACTED]","apiKey":"[REDACTED]"}]}`, string(out))
+
+	// Data that cannot be redacted is never passed through
+	assert.Equal(t, omittedValue, string(RedactJSON([]byte("not json"))))
+	assert.Equal(t, omittedValue, string(RedactJSON([]byte(`{"name":"a"} {"password":"hunter2"}`))))
+	assert.Equal(t, omittedValue, string(RedactJSON([]byte("25\r\n[{\"password\":\"hunter2\"}]"))))
+
+	// Tool results carry JSON as text
+	out = RedactJSON([]byte(`{"content":[{"type":"text","text":"{\"name\":\"app\",\"password\":\"hunter2\"}"}]}`))
+	assert.NotContains(t, string(out), "hunter2")
+	assert.Contains(t, string(out), `\"name\":\"app\"`)
 }
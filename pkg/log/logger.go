@@ -0,0 +1,205 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Supported log formats
+const (
+	FormatText   = "text"
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+)
+
+// Well-known logging components
+const (
+	ComponentServer  = "server"
+	ComponentStdio   = "stdio"
+	ComponentKBCloud = "kbcloud"
+	ComponentHTTP    = "http"
+)
+
+// Config holds logging configuration
+type Config struct {
+	// Level is the default log level (debug, info, warn, error)
+	Level string
+	// Format is one of "text", "json" or "logfmt"
+	Format string
+	// ComponentLevels overrides Level for individual components
+	ComponentLevels map[string]string
+
+	// File is the log file path; stderr is used when empty
+	File string
+	// MaxSizeMB is the size at which the log file is rotated
+	MaxSizeMB int
+	// MaxBackups is the number of rotated log files to keep
+	MaxBackups int
+	// MaxAgeDays is the number of days to keep rotated log files
+	MaxAgeDays int
+	// Compress gzips rotated log files
+	Compress bool
+}
+
+// Logger is the server-wide logger. Components get their own child logger
+// sharing output and format but with an independently configured level.
+type Logger struct {
+	*log.Logger
+
+	mu              sync.Mutex
+	componentLevels map[string]log.Level
+	components      map[string]*log.Logger
+	closer          io.Closer
+}
+
+// New creates a Logger from cfg
+func New(cfg Config) (*Logger, error) {
+	level, err := parseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	formatter, err := newFormatter(cfg.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	componentLevels := make(map[string]log.Level, len(cfg.ComponentLevels))
+	for name, l := range cfg.ComponentLevels {
+		lvl, err := parseLevel(l)
+		if err != nil {
+			return nil, fmt.Errorf("component %s: %w", name, err)
+		}
+		componentLevels[name] = lvl
+	}
+
+	logger := &Logger{
+		Logger:          log.New(),
+		componentLevels: componentLevels,
+		components:      make(map[string]*log.Logger),
+	}
+	logger.SetLevel(level)
+	logger.SetFormatter(formatter)
+	logger.SetOutput(os.Stderr)
+
+	if cfg.File != "" {
+		file := &lumberjack.Logger{
+			Filename:   cfg.File,
+			MaxSize:    cfg.MaxSizeMB,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAgeDays,
+			Compress:   cfg.Compress,
+		}
+		logger.SetOutput(file)
+		logger.closer = file
+	}
+
+	return logger, nil
+}
+
+// Nop returns a Logger that discards everything, for tests and library use
+func Nop() *Logger {
+	logger := &Logger{
+		Logger:          log.New(),
+		componentLevels: map[string]log.Level{},
+		components:      map[string]*log.Logger{},
+	}
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// Component returns a log entry for the named component, honoring its level override
+func (l *Logger) Component(name string) *log.Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	child, ok := l.components[name]
+	if !ok {
+		child = &log.Logger{
+			Out:          l.Out,
+			Formatter:    l.Formatter,
+			Hooks:        l.Hooks,
+			Level:        l.GetLevel(),
+			ExitFunc:     l.ExitFunc,
+			ReportCaller: l.ReportCaller,
+		}
+		if lvl, ok := l.componentLevels[name]; ok {
+			child.SetLevel(lvl)
+		}
+		l.components[name] = child
+	}
+	return child.WithField("component", name)
+}
+
+// SetComponentLevel changes the level of a component at runtime
+func (l *Logger) SetComponentLevel(name string, level log.Level) {
+	l.mu.Lock()
+	l.componentLevels[name] = level
+	child, ok := l.components[name]
+	l.mu.Unlock()
+
+	if ok {
+		child.SetLevel(level)
+	}
+}
+
+// Close releases the log file, if any
+func (l *Logger) Close() error {
+	if l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
+
+// ParseComponentLevels parses "component=level" pairs, e.g. "kbcloud=debug,http=warn"
+func ParseComponentLevels(s string) (map[string]string, error) {
+	levels := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, level, ok := strings.Cut(pair, "=")
+		if !ok || name == "" || level == "" {
+			return nil, fmt.Errorf("invalid component log level %q, expected component=level", pair)
+		}
+		levels[strings.TrimSpace(name)] = strings.TrimSpace(level)
+	}
+	return levels, nil
+}
+
+// parseLevel parses a log level, defaulting to info when empty
+func parseLevel(s string) (log.Level, error) {
+	if s == "" {
+		return log.InfoLevel, nil
+	}
+	level, err := log.ParseLevel(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid log level %q: %w", s, err)
+	}
+	return level, nil
+}
+
+// newFormatter returns the logrus formatter for a log format
+func newFormatter(format string) (log.Formatter, error) {
+	switch format {
+	case "", FormatJSON:
+		return &log.JSONFormatter{}, nil
+	case FormatText:
+		return &log.TextFormatter{FullTimestamp: true}, nil
+	case FormatLogfmt:
+		return &log.TextFormatter{
+			DisableColors:    true,
+			FullTimestamp:    true,
+			QuoteEmptyFields: true,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported log format %q (expected text, json or logfmt)", format)
+	}
+}
@@ -0,0 +1,186 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("Formats", func(t *testing.T) {
+		tests := []struct {
+			format string
+			want   string
+		}{
+			{format: FormatJSON, want: `"msg":"hello"`},
+			{format: FormatLogfmt, want: `msg=hello`},
+			{format: FormatText, want: `msg=hello`},
+		}
+		for _, tc := range tests {
+			logger, err := New(Config{Format: tc.format})
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			logger.SetOutput(&buf)
+			logger.Info("hello")
+			assert.Contains(t, buf.String(), tc.want, tc.format)
+		}
+	})
+
+	t.Run("Invalid configuration", func(t *testing.T) {
+		_, err := New(Config{Format: "xml"})
+		assert.Error(t, err)
+
+		_, err = New(Config{Level: "loud"})
+		assert.Error(t, err)
+
+		_, err = New(Config{ComponentLevels: map[string]string{"http": "loud"}})
+		assert.Error(t, err)
+	})
+
+	t.Run("Log file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "server.log")
+		logger, err := New(Config{File: path, MaxSizeMB: 1})
+		require.NoError(t, err)
+
+		logger.Info("to file")
+		require.NoError(t, logger.Close())
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "to file")
+	})
+}
+
+func TestComponent(t *testing.T) {
+	logger, err := New(Config{
+		Level:           "info",
+		Format:          FormatJSON,
+		ComponentLevels: map[string]string{ComponentHTTP: "debug"},
+	})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+
+	logger.Component(ComponentKBCloud).Debug("hidden")
+	logger.Component(ComponentHTTP).Debug("shown")
+
+	assert.NotContains(t, buf.String(), "hidden")
+	assert.Contains(t, buf.String(), "shown")
+	assert.Contains(t, buf.String(), `"component":"http"`)
+
+	logger.SetComponentLevel(ComponentKBCloud, log.DebugLevel)
+	logger.Component(ComponentKBCloud).Debug("now shown")
+	assert.Contains(t, buf.String(), "now shown")
+}
+
+func TestParseComponentLevels(t *testing.T) {
+	levels, err := ParseComponentLevels("kbcloud=debug, http=warn,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"kbcloud": "debug", "http": "warn"}, levels)
+
+	_, err = ParseComponentLevels("kbcloud")
+	assert.Error(t, err)
+}
+
+func TestHTTPDebugTransport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Set-Cookie", "session=abc123")
+		_, _ = w.Write([]byte(`{"name":"org"}`))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	logger := log.New()
+	logger.SetOutput(&buf)
+	logger.SetLevel(log.InfoLevel)
+
+	t.Run("Skipped below debug level", func(t *testing.T) {
+		client := &http.Client{Transport: NewHTTPDebugTransport(nil, log.NewEntry(logger), false)}
+		resp, err := client.Get(ts.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("Forced dumps scrub auth headers", func(t *testing.T) {
+		client := &http.Client{Transport: NewHTTPDebugTransport(nil, log.NewEntry(logger), true)}
+		req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", `Digest username="key", response="deadbeef"`)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		out := buf.String()
+		assert.Contains(t, out, `{\"name\":\"org\"}`)
+		assert.Contains(t, out, "Authorization: "+redactedValue)
+		assert.NotContains(t, out, "deadbeef")
+		assert.NotContains(t, out, "abc123")
+	})
+
+	t.Run("Forced dumps scrub passwords in bodies", func(t *testing.T) {
+		buf.Reset()
+		client := &http.Client{Transport: NewHTTPDebugTransport(nil, log.NewEntry(logger), true)}
+		resp, err := client.Post(ts.URL, "application/json", strings.NewReader(`{"name":"app","password":"hunter2"}`))
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		out := buf.String()
+		assert.Contains(t, out, `\"name\":\"app\"`)
+		assert.NotContains(t, out, "hunter2")
+	})
+
+	t.Run("Forced dumps redact chunked bodies", func(t *testing.T) {
+		chunked := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			// Flushing before the end forces chunked transfer encoding
+			_, _ = w.Write([]byte(`[{"name":"a","password":"hunter2"},`))
+			w.(http.Flusher).Flush()
+			_, _ = w.Write([]byte(`{"name":"b","password":"hunter3"}]`))
+		}))
+		defer chunked.Close()
+
+		buf.Reset()
+		client := &http.Client{Transport: NewHTTPDebugTransport(nil, log.NewEntry(logger), true)}
+		resp, err := client.Get(chunked.URL)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"chunked"}, resp.TransferEncoding)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Contains(t, string(body), "hunter2", "the caller still gets the body")
+
+		out := buf.String()
+		assert.Contains(t, out, `\"name\":\"b\"`)
+		assert.NotContains(t, out, "hunter2")
+		assert.NotContains(t, out, "hunter3")
+	})
+
+	t.Run("Forced dumps omit bodies that are not JSON", func(t *testing.T) {
+		text := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("password=hunter2"))
+		}))
+		defer text.Close()
+
+		buf.Reset()
+		client := &http.Client{Transport: NewHTTPDebugTransport(nil, log.NewEntry(logger), true)}
+		resp, err := client.Get(text.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		out := buf.String()
+		assert.Contains(t, out, omittedValue)
+		assert.NotContains(t, out, "hunter2")
+	})
+}
@@ -0,0 +1,200 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/apecloud/kb-cloud-client-go/api/common"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "kb_cloud_mcp"
+
+// Tool call outcomes
+const (
+	OutcomeSuccess   = "success"
+	OutcomeToolError = "tool_error"
+	OutcomeError     = "error"
+)
+
+var (
+	// ToolCalls counts tool calls by tool name and outcome
+	ToolCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tool_calls_total",
+		Help:      "Total number of MCP tool calls by tool and outcome.",
+	}, []string{"tool", "outcome"})
+
+	// ToolCallDuration observes tool call latency by tool name
+	ToolCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "tool_call_duration_seconds",
+		Help:      "Latency of MCP tool calls in seconds.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"tool"})
+
+	// APIRequests counts KB Cloud API requests by endpoint, method and status code
+	APIRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_requests_total",
+		Help:      "Total number of KB Cloud API requests by endpoint, method and status code.",
+	}, []string{"endpoint", "method", "code"})
+
+	// APIRequestDuration observes KB Cloud API latency by endpoint and method
+	APIRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "api_request_duration_seconds",
+		Help:      "Latency of KB Cloud API requests in seconds.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"endpoint", "method"})
+
+	// APIRetries counts KB Cloud API responses that make the client retry the request
+	APIRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_retries_total",
+		Help:      "Total number of KB Cloud API responses that trigger a client retry (429 and 5xx).",
+	}, []string{"endpoint"})
+
+	// CacheRequests counts cache lookups by cache name and result
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Total number of cache lookups by cache and result (hit or miss).",
+	}, []string{"cache", "result"})
+
+	// ActiveSessions tracks the number of connected MCP client sessions
+	ActiveSessions = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_sessions",
+		Help:      "Number of active MCP client sessions.",
+	})
+)
+
+// Registry is the Prometheus registry holding all server metrics
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		ToolCalls,
+		ToolCallDuration,
+		APIRequests,
+		APIRequestDuration,
+		APIRetries,
+		CacheRequests,
+		ActiveSessions,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler returns an HTTP handler exposing the registered metrics
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Serve starts an HTTP server exposing /metrics on addr until ctx is done
+func Serve(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- srv.ListenAndServe()
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	case err := <-errC:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	}
+}
+
+// ObserveCache records a cache lookup result
+func ObserveCache(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	CacheRequests.WithLabelValues(cache, result).Inc()
+}
+
+// InstrumentToolHandler wraps a tool handler to record call counts and latency
+func InstrumentToolHandler(name string, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		result, err := next(ctx, request)
+		ToolCallDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+
+		outcome := OutcomeSuccess
+		switch {
+		case err != nil:
+			outcome = OutcomeError
+		case result != nil && result.IsError:
+			outcome = OutcomeToolError
+		}
+		ToolCalls.WithLabelValues(name, outcome).Inc()
+
+		return result, err
+	}
+}
+
+// roundTripper records KB Cloud API request metrics
+type roundTripper struct {
+	next http.RoundTripper
+}
+
+// InstrumentRoundTripper wraps an http.RoundTripper to record KB Cloud API metrics.
+// Requests are labelled with the OpenAPI operation ID set by the KB Cloud client.
+func InstrumentRoundTripper(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &roundTripper{next: next}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := Endpoint(req)
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	APIRequestDuration.WithLabelValues(endpoint, req.Method).Observe(time.Since(start).Seconds())
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+			APIRetries.WithLabelValues(endpoint).Inc()
+		}
+	}
+	APIRequests.WithLabelValues(endpoint, req.Method, code).Inc()
+
+	return resp, err
+}
+
+// Endpoint returns the KB Cloud operation ID for a request, or "unknown"
+func Endpoint(req *http.Request) string {
+	if info, ok := req.Context().Value(common.APIInfoCtxKey).(common.APIInfo); ok && info.OperationID != "" {
+		return info.OperationID
+	}
+	return "unknown"
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/apecloud/kb-cloud-client-go/api/common"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstrumentToolHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  *mcp.CallToolResult
+		err     error
+		outcome string
+	}{
+		{name: "success", result: mcp.NewToolResultText("ok"), outcome: OutcomeSuccess},
+		{name: "tool error", result: mcp.NewToolResultError("bad input"), outcome: OutcomeToolError},
+		{name: "handler error", err: errors.New("boom"), outcome: OutcomeError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tool := "test_tool_" + tc.outcome
+			handler := InstrumentToolHandler(tool, func(_ context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return tc.result, tc.err
+			})
+
+			result, err := handler(context.Background(), mcp.CallToolRequest{})
+
+			assert.Equal(t, tc.result, result)
+			assert.Equal(t, tc.err, err)
+			assert.Equal(t, float64(1), testutil.ToFloat64(ToolCalls.WithLabelValues(tool, tc.outcome)))
+		})
+	}
+}
+
+func TestInstrumentRoundTripper(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	client := &http.Client{Transport: InstrumentRoundTripper(nil)}
+
+	do := func(path, operationID string) {
+		ctx := context.WithValue(context.Background(), common.APIInfoCtxKey, common.APIInfo{OperationID: operationID})
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+path, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+
+	do("/ok", "testOk")
+	do("/fail", "testFail")
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(APIRequests.WithLabelValues("testOk", http.MethodGet, "200")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(APIRequests.WithLabelValues("testFail", http.MethodGet, "503")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(APIRetries.WithLabelValues("testOk")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(APIRetries.WithLabelValues("testFail")))
+}
+
+func TestObserveCache(t *testing.T) {
+	ObserveCache("test", true)
+	ObserveCache("test", false)
+	ObserveCache("test", false)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(CacheRequests.WithLabelValues("test", "hit")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(CacheRequests.WithLabelValues("test", "miss")))
+}
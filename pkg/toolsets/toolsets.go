@@ -0,0 +1,167 @@
+// Package toolsets groups MCP tools into named sets that a deployment can
+// enable or disable as a unit.
+package toolsets
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// All is the toolset name that enables every toolset
+const All = "all"
+
+// Toolset is a named group of related tools
+type Toolset struct {
+	Name        string
+	Description string
+	Enabled     bool
+
+	tools []server.ServerTool
+}
+
+// NewToolset creates an empty, disabled toolset
+func NewToolset(name, description string) *Toolset {
+	return &Toolset{Name: name, Description: description}
+}
+
+// AddTools adds tools to the toolset
+func (t *Toolset) AddTools(tools ...server.ServerTool) *Toolset {
+	t.tools = append(t.tools, tools...)
+	return t
+}
+
+// Tools returns the tools of the toolset
+func (t *Toolset) Tools() []server.ServerTool {
+	return t.tools
+}
+
+// ToolNames returns the names of the tools in the toolset
+func (t *Toolset) ToolNames() []string {
+	names := make([]string, 0, len(t.tools))
+	for _, tool := range t.tools {
+		names = append(names, tool.Tool.Name)
+	}
+	return names
+}
+
+// RegisterTools adds the toolset's tools to s if the toolset is enabled
+func (t *Toolset) RegisterTools(s *server.MCPServer) {
+	if !t.Enabled || len(t.tools) == 0 {
+		return
+	}
+	s.AddTools(t.tools...)
+}
+
+// Group is an ordered collection of toolsets. Toolsets may be enabled while
+// the server is running, so enabled state is guarded by the group.
+type Group struct {
+	mu       sync.RWMutex
+	toolsets map[string]*Toolset
+	order    []string
+}
+
+// NewGroup creates an empty toolset group
+func NewGroup() *Group {
+	return &Group{toolsets: map[string]*Toolset{}}
+}
+
+// AddToolset adds a toolset to the group
+func (g *Group) AddToolset(t *Toolset) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, exists := g.toolsets[t.Name]; !exists {
+		g.order = append(g.order, t.Name)
+	}
+	g.toolsets[t.Name] = t
+}
+
+// Toolsets returns the toolsets in the order they were added
+func (g *Group) Toolsets() []*Toolset {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	toolsets := make([]*Toolset, 0, len(g.order))
+	for _, name := range g.order {
+		toolsets = append(toolsets, g.toolsets[name])
+	}
+	return toolsets
+}
+
+// Names returns the toolset names in the order they were added
+func (g *Group) Names() []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return append([]string{}, g.order...)
+}
+
+// Toolset returns the named toolset
+func (g *Group) Toolset(name string) (*Toolset, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	t, ok := g.toolsets[name]
+	if !ok {
+		return nil, g.unknownToolsetError(name)
+	}
+	return t, nil
+}
+
+// IsEnabled reports whether the named toolset is enabled
+func (g *Group) IsEnabled(name string) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	t, ok := g.toolsets[name]
+	return ok && t.Enabled
+}
+
+// EnableToolsets enables the named toolsets; "all" enables every toolset
+func (g *Group) EnableToolsets(names []string) error {
+	for _, name := range names {
+		if name == All {
+			g.mu.Lock()
+			for _, t := range g.toolsets {
+				t.Enabled = true
+			}
+			g.mu.Unlock()
+			continue
+		}
+		if _, err := g.EnableToolset(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnableToolset enables the named toolset. It reports whether the toolset
+// was newly enabled, i.e. not already enabled.
+func (g *Group) EnableToolset(name string) (bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	t, ok := g.toolsets[name]
+	if !ok {
+		return false, g.unknownToolsetError(name)
+	}
+	if t.Enabled {
+		return false, nil
+	}
+	t.Enabled = true
+	return true, nil
+}
+
+// RegisterTools adds the tools of every enabled toolset to s
+func (g *Group) RegisterTools(s *server.MCPServer) {
+	for _, t := range g.Toolsets() {
+		if g.IsEnabled(t.Name) {
+			s.AddTools(t.tools...)
+		}
+	}
+}
+
+// unknownToolsetError lists the valid toolset names; g.mu must be held
+func (g *Group) unknownToolsetError(name string) error {
+	names := append([]string{}, g.order...)
+	sort.Strings(names)
+	return fmt.Errorf("toolset %q does not exist (available: %s)", name, strings.Join(names, ", "))
+}
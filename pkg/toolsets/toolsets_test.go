@@ -0,0 +1,92 @@
+package toolsets
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTool returns a tool that does nothing
+func newTool(name string) server.ServerTool {
+	return server.ServerTool{
+		Tool: mcp.NewTool(name),
+		Handler: func(_ context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText(name), nil
+		},
+	}
+}
+
+// newGroup returns a group with two toolsets
+func newGroup() *Group {
+	g := NewGroup()
+	g.AddToolset(NewToolset("orgs", "Organizations").AddTools(newTool("list_orgs"), newTool("get_org")))
+	g.AddToolset(NewToolset("backups", "Backups").AddTools(newTool("list_backups")))
+	return g
+}
+
+func TestGroup(t *testing.T) {
+	t.Run("Toolsets keep insertion order", func(t *testing.T) {
+		var names []string
+		for _, ts := range newGroup().Toolsets() {
+			names = append(names, ts.Name)
+		}
+		assert.Equal(t, []string{"orgs", "backups"}, names)
+		assert.Equal(t, names, newGroup().Names())
+	})
+
+	t.Run("Enable selected toolsets", func(t *testing.T) {
+		g := newGroup()
+		require.NoError(t, g.EnableToolsets([]string{"backups"}))
+
+		s := server.NewMCPServer("test", "1.0.0")
+		g.RegisterTools(s)
+		assert.Len(t, s.ListTools(), 1)
+		assert.NotNil(t, s.GetTool("list_backups"))
+	})
+
+	t.Run("Enable all toolsets", func(t *testing.T) {
+		g := newGroup()
+		require.NoError(t, g.EnableToolsets([]string{All}))
+
+		s := server.NewMCPServer("test", "1.0.0")
+		g.RegisterTools(s)
+		assert.Len(t, s.ListTools(), 3)
+	})
+
+	t.Run("Enable a single toolset", func(t *testing.T) {
+		g := newGroup()
+		enabled, err := g.EnableToolset("orgs")
+		require.NoError(t, err)
+		assert.True(t, enabled)
+		assert.True(t, g.IsEnabled("orgs"))
+		assert.False(t, g.IsEnabled("backups"))
+
+		// Enabling twice reports that nothing changed
+		enabled, err = g.EnableToolset("orgs")
+		require.NoError(t, err)
+		assert.False(t, enabled)
+
+		_, err = g.EnableToolset("billing")
+		assert.Error(t, err)
+	})
+
+	t.Run("Unknown toolset", func(t *testing.T) {
+		err := newGroup().EnableToolsets([]string{"orgs", "billing"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "available: backups, orgs")
+	})
+}
+
+func TestToolset(t *testing.T) {
+	ts := NewToolset("orgs", "Organizations").AddTools(newTool("list_orgs"), newTool("get_org"))
+	assert.Equal(t, []string{"list_orgs", "get_org"}, ts.ToolNames())
+
+	// Disabled toolsets register nothing
+	s := server.NewMCPServer("test", "1.0.0")
+	ts.RegisterTools(s)
+	assert.Empty(t, s.ListTools())
+}
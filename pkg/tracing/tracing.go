@@ -0,0 +1,272 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/apecloud/kb-cloud-client-go/api/common"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/apecloud/kb-cloud-mcp-server"
+
+// Supported exporters
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Config holds tracing configuration
+type Config struct {
+	// Exporter is one of "none", "stdout" or "otlp"
+	Exporter string
+	// Endpoint is the OTLP/HTTP collector endpoint (host:port). When empty the
+	// standard OTEL_EXPORTER_OTLP_* environment variables are used.
+	Endpoint string
+	// Insecure disables TLS for the OTLP exporter
+	Insecure bool
+	// ServiceVersion is reported as the service.version resource attribute
+	ServiceVersion string
+	// Writer receives spans for the stdout exporter (defaults to os.Stderr,
+	// as stdout carries the JSON-RPC stream in stdio mode)
+	Writer io.Writer
+}
+
+// Setup installs the global tracer provider and propagator described by cfg.
+// The returned function flushes and stops the exporter.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		w := cfg.Writer
+		if w == nil {
+			w = os.Stderr
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(w))
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported trace exporter: %s", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName("kb-cloud-mcp-server"),
+		semconv.ServiceVersion(cfg.ServiceVersion),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+
+	return tp.Shutdown, nil
+}
+
+// tracer returns the tracer from the current global provider
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// metaCarrier adapts MCP request _meta fields to a propagation.TextMapCarrier
+type metaCarrier map[string]any
+
+// Get implements propagation.TextMapCarrier
+func (c metaCarrier) Get(key string) string {
+	if v, ok := c[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// Set implements propagation.TextMapCarrier
+func (c metaCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// Keys implements propagation.TextMapCarrier
+func (c metaCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// ExtractMeta returns ctx with any trace context (traceparent, tracestate,
+// baggage) carried in an MCP request's _meta field
+func ExtractMeta(ctx context.Context, meta *mcp.Meta) context.Context {
+	if meta == nil || len(meta.AdditionalFields) == 0 {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, metaCarrier(meta.AdditionalFields))
+}
+
+// InstrumentToolHandler wraps a tool handler in a span covering the tools/call request
+func InstrumentToolHandler(name string, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx = ExtractMeta(ctx, request.Params.Meta)
+		ctx, span := tracer().Start(ctx, string(mcp.MethodToolsCall)+" "+name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("mcp.method.name", string(mcp.MethodToolsCall)),
+				attribute.String("mcp.tool.name", name),
+			),
+		)
+		defer span.End()
+
+		result, err := next(ctx, request)
+		switch {
+		case err != nil:
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		case result != nil && result.IsError:
+			span.SetStatus(codes.Error, "tool returned an error result")
+		}
+		return result, err
+	}
+}
+
+// requestKey identifies an in-flight MCP request
+type requestKey struct {
+	session string
+	id      any
+}
+
+// Hooks returns server hooks that record a span for every MCP request other
+// than tools/call, which is traced by InstrumentToolHandler so that KB Cloud
+// calls made by the tool become child spans.
+func Hooks() *server.Hooks {
+	var spans sync.Map
+
+	key := func(ctx context.Context, id any) requestKey {
+		k := requestKey{id: fmt.Sprint(id)}
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			k.session = session.SessionID()
+		}
+		return k
+	}
+
+	end := func(ctx context.Context, id any, err error) {
+		v, ok := spans.LoadAndDelete(key(ctx, id))
+		if !ok {
+			return
+		}
+		span := v.(trace.Span)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+
+	hooks := &server.Hooks{}
+	hooks.AddBeforeAny(func(ctx context.Context, id any, method mcp.MCPMethod, _ any) {
+		if method == mcp.MethodToolsCall {
+			return
+		}
+		_, span := tracer().Start(ctx, string(method),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attribute.String("mcp.method.name", string(method))),
+		)
+		spans.Store(key(ctx, id), span)
+	})
+	hooks.AddOnSuccess(func(ctx context.Context, id any, _ mcp.MCPMethod, _ any, _ any) {
+		end(ctx, id, nil)
+	})
+	hooks.AddOnError(func(ctx context.Context, id any, _ mcp.MCPMethod, _ any, err error) {
+		end(ctx, id, err)
+	})
+	return hooks
+}
+
+// roundTripper records a client span per KB Cloud HTTP call
+type roundTripper struct {
+	next http.RoundTripper
+}
+
+// InstrumentRoundTripper wraps an http.RoundTripper so every KB Cloud API call
+// is recorded as a client span and carries W3C trace headers
+func InstrumentRoundTripper(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &roundTripper{next: next}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	name := req.Method
+	attrs := []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String(req.Method),
+		semconv.URLFull(req.URL.Redacted()),
+		semconv.ServerAddress(req.URL.Hostname()),
+	}
+	if info, ok := req.Context().Value(common.APIInfoCtxKey).(common.APIInfo); ok {
+		name = "kbcloud." + info.OperationID
+		attrs = append(attrs,
+			attribute.String("kbcloud.operation", info.OperationID),
+			semconv.HTTPRoute(info.Path),
+		)
+	}
+
+	ctx, span := tracer().Start(req.Context(), name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	defer span.End()
+
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return resp, err
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,118 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/apecloud/kb-cloud-client-go/api/common"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	remoteTraceID  = "4bf92f3577b34da6a3ce929d0e0e4736"
+	remoteParentID = "00f067aa0ba902b7"
+)
+
+// setupRecorder installs an in-memory span recorder as the global tracer provider
+func setupRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	return recorder
+}
+
+func TestToolSpanPropagation(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	var gotTraceparent string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTraceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	client := &http.Client{Transport: InstrumentRoundTripper(nil)}
+
+	handler := InstrumentToolHandler("get_instance", func(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx = context.WithValue(ctx, common.APIInfoCtxKey, common.APIInfo{OperationID: "getCluster", Path: "/api/v1/organizations/{orgName}/clusters/{clusterName}"})
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return mcp.NewToolResultText("ok"), nil
+	})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "get_instance"
+	request.Params.Meta = &mcp.Meta{AdditionalFields: map[string]any{
+		"traceparent": "00-" + remoteTraceID + "-" + remoteParentID + "-01",
+	}}
+
+	_, err := handler(context.Background(), request)
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	httpSpan, toolSpan := spans[0], spans[1]
+
+	assert.Equal(t, "tools/call get_instance", toolSpan.Name())
+	assert.Equal(t, remoteTraceID, toolSpan.SpanContext().TraceID().String())
+	assert.Equal(t, remoteParentID, toolSpan.Parent().SpanID().String())
+
+	assert.Equal(t, "kbcloud.getCluster", httpSpan.Name())
+	assert.Equal(t, toolSpan.SpanContext().SpanID(), httpSpan.Parent().SpanID())
+	assert.Contains(t, gotTraceparent, remoteTraceID)
+	assert.Contains(t, gotTraceparent, httpSpan.SpanContext().SpanID().String())
+}
+
+func TestToolSpanErrorResult(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	handler := InstrumentToolHandler("list_instances", func(_ context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultError("missing required parameter: org_name"), nil
+	})
+
+	_, err := handler(context.Background(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "Error", spans[0].Status().Code.String())
+}
+
+func TestSetup(t *testing.T) {
+	t.Run("none exporter is a no-op", func(t *testing.T) {
+		shutdown, err := Setup(context.Background(), Config{Exporter: ExporterNone})
+		require.NoError(t, err)
+		assert.NoError(t, shutdown(context.Background()))
+	})
+
+	t.Run("unknown exporter fails", func(t *testing.T) {
+		_, err := Setup(context.Background(), Config{Exporter: "zipkin"})
+		assert.Error(t, err)
+	})
+
+	t.Run("stdout exporter writes spans", func(t *testing.T) {
+		var buf bytes.Buffer
+		shutdown, err := Setup(context.Background(), Config{Exporter: ExporterStdout, Writer: &buf})
+		require.NoError(t, err)
+
+		_, span := tracer().Start(context.Background(), "test-span")
+		span.End()
+
+		require.NoError(t, shutdown(context.Background()))
+		assert.Contains(t, buf.String(), "test-span")
+	})
+}